@@ -9,6 +9,7 @@ import (
 	"log"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const (
@@ -31,6 +32,9 @@ var (
 	// ErrServerShutdown throws an error if remote server has shutdown.
 	ErrServerShutdown = errors.New("server has shutdown")
 
+	// ErrConnectionLost throws an error if the connection was lost before the response was received.
+	ErrConnectionLost = errors.New("connection to server lost")
+
 	// ErrTimeout throws an error if request has timed out
 	ErrTimeout = errors.New("request timeout")
 
@@ -56,7 +60,14 @@ type container struct {
 
 // Client stores information about the remote server.
 type Client struct {
-	transport Transport
+	transport     Transport
+	transportLock sync.RWMutex
+	dial          func(context.Context) (Transport, error)
+	// drop receives the connections to give up on, see dropTransport.
+	drop chan dropped
+	// ready is closed once the connection is usable, that is after the session is
+	// restored on a new connection.
+	ready chan struct{}
 
 	handlers     map[uint64]chan *container
 	handlersLock sync.RWMutex
@@ -64,50 +75,79 @@ type Client struct {
 	pushHandlers     map[string][]chan *container
 	pushHandlersLock sync.RWMutex
 
-	Error chan error
-	quit  chan struct{}
+	// version and replays restore the session on a new connection.
+	version     func(context.Context) error
+	replays     []func(context.Context) error
+	headers     bool
+	replaysLock sync.Mutex
+
+	pingInterval         time.Duration
+	reconnectInterval    time.Duration
+	reconnectIntervalMax time.Duration
+	backoff              time.Duration // owned by listen
+
+	Error  chan error
+	quit   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	nextID uint64
 }
 
 // NewClientTCP initialize a new client for remote server and connects to the remote server using TCP
-func NewClientTCP(ctx context.Context, addr string) (*Client, error) {
-	transport, err := NewTCPTransport(ctx, addr)
-	if err != nil {
-		return nil, err
-	}
-
-	c := &Client{
-		handlers:     make(map[uint64]chan *container),
-		pushHandlers: make(map[string][]chan *container),
-
-		Error: make(chan error),
-		quit:  make(chan struct{}),
-	}
-
-	c.transport = transport
-	go c.listen()
-
-	return c, nil
+func NewClientTCP(ctx context.Context, addr string, opts ...ClientOption) (*Client, error) {
+	return newClient(ctx, func(ctx context.Context) (Transport, error) {
+		transport, err := NewTCPTransport(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		return transport, nil
+	}, opts)
 }
 
 // NewClientSSL initialize a new client for remote server and connects to the remote server using SSL
-func NewClientSSL(ctx context.Context, addr string, config *tls.Config) (*Client, error) {
-	transport, err := NewSSLTransport(ctx, addr, config)
+func NewClientSSL(ctx context.Context, addr string, config *tls.Config, opts ...ClientOption) (*Client, error) {
+	return newClient(ctx, func(ctx context.Context) (Transport, error) {
+		transport, err := NewSSLTransport(ctx, addr, config)
+		if err != nil {
+			return nil, err
+		}
+		return transport, nil
+	}, opts)
+}
+
+func newClient(ctx context.Context, dial func(context.Context) (Transport, error), opts []ClientOption) (*Client, error) {
+	transport, err := dial(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	c := &Client{
+		transport: transport,
+		dial:      dial,
+		drop:      make(chan dropped, 1),
+		ready:     make(chan struct{}),
+
 		handlers:     make(map[uint64]chan *container),
 		pushHandlers: make(map[string][]chan *container),
 
+		pingInterval:         DefaultPingInterval,
+		reconnectInterval:    DefaultReconnectInterval,
+		reconnectIntervalMax: DefaultReconnectIntervalMax,
+
 		Error: make(chan error),
 		quit:  make(chan struct{}),
 	}
+	close(c.ready)
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
 
-	c.transport = transport
 	go c.listen()
+	if c.pingInterval > 0 {
+		go c.keepAlive()
+	}
 
 	return c, nil
 }
@@ -132,54 +172,64 @@ func (s *Client) listen() {
 		if s.IsShutdown() {
 			break
 		}
-		if s.transport == nil {
+		transport := s.getTransport()
+		if transport == nil {
 			break
 		}
 		select {
 		case <-s.quit:
 			return
-		case err := <-s.transport.Errors():
-			s.Error <- err
-			s.Shutdown()
-		case bytes := <-s.transport.Responses():
-			result := &container{
-				content: bytes,
+		case err := <-transport.Errors():
+			s.handleError(transport, err)
+		case d := <-s.drop:
+			if d.transport == transport {
+				s.handleError(transport, d.err)
 			}
+		case bytes := <-transport.Responses():
+			s.dispatch(bytes)
+		}
+	}
+}
 
-			msg := &response{}
-			err := json.Unmarshal(bytes, msg)
-			if err != nil {
-				if DebugMode {
-					log.Printf("Unmarshal received message failed: %v", err)
-				}
-				result.err = fmt.Errorf("Unmarshal received message failed: %v", err)
-			} else if msg.Error != "" {
-				result.err = errors.New(msg.Error)
-			}
+// dispatch delivers a message received from the server to the push handlers of its method
+// and to the pending request with its ID.
+func (s *Client) dispatch(bytes []byte) {
+	result := &container{
+		content: bytes,
+	}
 
-			if len(msg.Method) > 0 {
-				s.pushHandlersLock.RLock()
-				handlers := s.pushHandlers[msg.Method]
-				s.pushHandlersLock.RUnlock()
-
-				for _, handler := range handlers {
-					select {
-					case handler <- result:
-					default:
-					}
-				}
-			}
+	msg := &response{}
+	err := json.Unmarshal(bytes, msg)
+	if err != nil {
+		if DebugMode {
+			log.Printf("Unmarshal received message failed: %v", err)
+		}
+		result.err = fmt.Errorf("Unmarshal received message failed: %v", err)
+	} else if msg.Error != "" {
+		result.err = errors.New(msg.Error)
+	}
 
-			s.handlersLock.RLock()
-			c, ok := s.handlers[msg.ID]
-			s.handlersLock.RUnlock()
+	if len(msg.Method) > 0 {
+		s.pushHandlersLock.RLock()
+		handlers := s.pushHandlers[msg.Method]
+		s.pushHandlersLock.RUnlock()
 
-			if ok {
-				// TODO: very rare case. fix this memory leak, when nobody will read channel (in case of error)
-				c <- result
+		for _, handler := range handlers {
+			select {
+			case handler <- result:
+			default:
 			}
 		}
 	}
+
+	s.handlersLock.RLock()
+	c, ok := s.handlers[msg.ID]
+	s.handlersLock.RUnlock()
+
+	if ok {
+		// TODO: very rare case. fix this memory leak, when nobody will read channel (in case of error)
+		c <- result
+	}
 }
 
 func (s *Client) listenPush(method string) <-chan *container {
@@ -198,12 +248,34 @@ type request struct {
 }
 
 func (s *Client) request(ctx context.Context, method string, params []interface{}, v interface{}) error {
+	s.transportLock.RLock()
+	ready := s.ready
+	s.transportLock.RUnlock()
+
+	select {
+	case <-ready:
+	case <-s.quit:
+		return ErrServerShutdown
+	case <-ctx.Done():
+		return ErrTimeout
+	}
+
+	return s.call(ctx, method, params, v)
+}
+
+// call sends a request on the current connection, without waiting for the session to be restored.
+func (s *Client) call(ctx context.Context, method string, params []interface{}, v interface{}) error {
 	select {
 	case <-s.quit:
 		return ErrServerShutdown
 	default:
 	}
 
+	transport := s.getTransport()
+	if transport == nil {
+		return ErrServerShutdown
+	}
+
 	msg := request{
 		ID:     atomic.AddUint64(&s.nextID, 1),
 		Method: method,
@@ -217,12 +289,6 @@ func (s *Client) request(ctx context.Context, method string, params []interface{
 
 	bytes = append(bytes, nl)
 
-	err = s.transport.SendMessage(bytes)
-	if err != nil {
-		s.Shutdown()
-		return err
-	}
-
 	c := make(chan *container, 1)
 
 	s.handlersLock.Lock()
@@ -235,6 +301,16 @@ func (s *Client) request(ctx context.Context, method string, params []interface{
 		s.handlersLock.Unlock()
 	}()
 
+	err = transport.SendMessage(bytes)
+	if err != nil {
+		if s.reconnectInterval == 0 {
+			s.Shutdown()
+		} else {
+			s.dropTransport(transport, err)
+		}
+		return err
+	}
+
 	var resp *container
 	select {
 	case resp = <-c:
@@ -256,16 +332,31 @@ func (s *Client) request(ctx context.Context, method string, params []interface{
 	return nil
 }
 
+func (s *Client) getTransport() Transport {
+	s.transportLock.RLock()
+	defer s.transportLock.RUnlock()
+	return s.transport
+}
+
 func (s *Client) Shutdown() {
 	if !s.IsShutdown() {
 		close(s.quit)
 	}
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.transportLock.Lock()
 	if s.transport != nil {
 		_ = s.transport.Close()
 	}
 	s.transport = nil
+	s.transportLock.Unlock()
+	s.handlersLock.Lock()
 	s.handlers = nil
+	s.handlersLock.Unlock()
+	s.pushHandlersLock.Lock()
 	s.pushHandlers = nil
+	s.pushHandlersLock.Unlock()
 }
 
 func (s *Client) IsShutdown() bool {
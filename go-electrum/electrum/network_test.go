@@ -0,0 +1,211 @@
+package electrum
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testServer is a fake Electrum server answering with results that depend on the number of
+// connections accepted so far.
+type testServer struct {
+	listener net.Listener
+	methods  chan string
+
+	mu    sync.Mutex
+	conns []net.Conn
+	// pings is closed to stop answering pings.
+	pings chan struct{}
+	// status is the status of the scripthashes if set, "status<n>" on the n-th connection otherwise.
+	status string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &testServer{listener: listener, methods: make(chan string, 100), pings: make(chan struct{})}
+	t.Cleanup(func() {
+		listener.Close()
+		srv.drop()
+	})
+	go srv.serve()
+	return srv
+}
+
+func (srv *testServer) serve() {
+	for {
+		conn, err := srv.listener.Accept()
+		if err != nil {
+			return
+		}
+		srv.mu.Lock()
+		srv.conns = append(srv.conns, conn)
+		n := len(srv.conns)
+		srv.mu.Unlock()
+		go srv.handle(conn, n)
+	}
+}
+
+func (srv *testServer) handle(conn net.Conn, n int) {
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes(nl)
+		if err != nil {
+			return
+		}
+		var req request
+		if json.Unmarshal(line, &req) != nil {
+			return
+		}
+		srv.methods <- req.Method
+		var result interface{}
+		switch req.Method {
+		case "server.version":
+			result = []string{"test", ProtocolVersion}
+		case "server.ping":
+			select {
+			case <-srv.pings:
+				continue
+			default:
+			}
+		case "blockchain.headers.subscribe":
+			result = SubscribeHeadersResult{Height: int32(n), Hex: "00"}
+		case "blockchain.scripthash.subscribe":
+			srv.mu.Lock()
+			result = srv.status
+			srv.mu.Unlock()
+			if result == "" {
+				result = fmt.Sprintf("status%d", n)
+			}
+		}
+		data, _ := json.Marshal(result)
+		fmt.Fprintf(conn, "{\"id\":%d,\"result\":%s}\n", req.ID, data)
+	}
+}
+
+// drop closes the connections, as a server restart would.
+func (srv *testServer) drop() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for _, conn := range srv.conns {
+		conn.Close()
+	}
+}
+
+func (srv *testServer) expect(t *testing.T, methods ...string) {
+	for _, method := range methods {
+		select {
+		case m := <-srv.methods:
+			require.Equal(t, method, m)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timeout waiting for "+method)
+		}
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	srv := newTestServer(t)
+	ctx := context.Background()
+	client, err := NewClientTCP(ctx, srv.listener.Addr().String(), WithPingInterval(0), WithReconnect(10*time.Millisecond, 50*time.Millisecond))
+	require.NoError(t, err)
+	defer client.Shutdown()
+
+	_, _, err = client.ServerVersion(ctx)
+	require.NoError(t, err)
+	headers, err := client.SubscribeHeaders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), (<-headers).Height)
+	sub, notifs := client.SubscribeScripthash()
+	require.NoError(t, sub.Add(ctx, "sh"))
+	assert.Equal(t, "status1", (<-notifs).Params[1])
+	srv.expect(t, "server.version", "blockchain.headers.subscribe", "blockchain.scripthash.subscribe")
+
+	// The session is restored on the new connection, server.version first.
+	srv.drop()
+	srv.expect(t, "server.version", "blockchain.headers.subscribe", "blockchain.scripthash.subscribe")
+	assert.Equal(t, int32(2), (<-headers).Height)
+	assert.Equal(t, [2]string{"sh", "status2"}, (<-notifs).Params)
+	require.NoError(t, client.Ping(ctx))
+	srv.expect(t, "server.ping")
+	assert.False(t, client.IsShutdown())
+}
+
+func TestClientKeepAlive(t *testing.T) {
+	srv := newTestServer(t)
+	client, err := NewClientTCP(context.Background(), srv.listener.Addr().String(), WithPingInterval(20*time.Millisecond), WithReconnect(10*time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+	defer client.Shutdown()
+	srv.expect(t, "server.ping", "server.ping")
+
+	// An unanswered ping drops the connection.
+	close(srv.pings)
+	srv.expect(t, "server.ping")
+	select {
+	case err := <-client.Error:
+		assert.Equal(t, ErrTimeout, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for the connection to be dropped")
+	}
+	require.Eventually(t, func() bool {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		return len(srv.conns) > 1
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestClientNoReconnect(t *testing.T) {
+	srv := newTestServer(t)
+	client, err := NewClientTCP(context.Background(), srv.listener.Addr().String(), WithPingInterval(0), WithReconnect(0, 0))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		return len(srv.conns) == 1
+	}, 5*time.Second, time.Millisecond)
+	srv.drop()
+	assert.Error(t, <-client.Error)
+	require.Eventually(t, client.IsShutdown, 5*time.Second, time.Millisecond)
+	assert.Equal(t, ErrServerShutdown, client.Ping(context.Background()))
+}
+
+func TestClientRestoreSlowReader(t *testing.T) {
+	srv := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := NewClientTCP(ctx, srv.listener.Addr().String(), WithPingInterval(0), WithReconnect(10*time.Millisecond, 50*time.Millisecond))
+	require.NoError(t, err)
+	defer client.Shutdown()
+	sub, notifs := client.SubscribeScripthash()
+	require.NoError(t, sub.Add(ctx, "sh"))
+	srv.expect(t, "blockchain.scripthash.subscribe")
+
+	// The channel is full, the session is restored anyway.
+	srv.drop()
+	srv.expect(t, "blockchain.scripthash.subscribe")
+	require.NoError(t, client.Ping(ctx))
+	srv.expect(t, "server.ping")
+	assert.Equal(t, "status1", (<-notifs).Params[1])
+	assert.Equal(t, "status2", (<-notifs).Params[1])
+
+	// An unchanged status isn't pushed again.
+	srv.mu.Lock()
+	srv.status = "status2"
+	srv.mu.Unlock()
+	srv.drop()
+	srv.expect(t, "blockchain.scripthash.subscribe")
+	require.NoError(t, client.Ping(ctx))
+	select {
+	case notif := <-notifs:
+		assert.Fail(t, "unexpected notification", notif.Params)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,219 @@
+package electrum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultPingInterval is the default interval between the keepalive pings.
+	DefaultPingInterval = time.Minute
+
+	// DefaultReconnectInterval is the default delay before the first reconnection attempt.
+	DefaultReconnectInterval = time.Second
+
+	// DefaultReconnectIntervalMax is the default maximum delay between reconnection attempts.
+	DefaultReconnectIntervalMax = time.Minute
+
+	// RestoreTimeout is the timeout of each request replayed to restore the session on a new connection.
+	RestoreTimeout = 30 * time.Second
+)
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithPingInterval sets the interval between the pings keeping the session alive. A connection
+// whose ping isn't answered within the interval is dropped. Zero disables the pings.
+func WithPingInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.pingInterval = interval
+	}
+}
+
+// WithReconnect sets the delay before the first reconnection attempt, doubling up to max after
+// each failed attempt. Zero disables reconnection: the first transport error is sent on the
+// Error channel and the client shuts down.
+func WithReconnect(interval, max time.Duration) ClientOption {
+	return func(c *Client) {
+		c.reconnectInterval = interval
+		c.reconnectIntervalMax = max
+		if c.reconnectIntervalMax < interval {
+			c.reconnectIntervalMax = interval
+		}
+	}
+}
+
+// dropped is a connection given up on because of err.
+type dropped struct {
+	transport Transport
+	err       error
+}
+
+// dropTransport asks the listener to give up on the connection transport because of err.
+func (s *Client) dropTransport(transport Transport, err error) {
+	select {
+	case s.drop <- dropped{transport, err}:
+	case <-s.quit:
+	}
+}
+
+// handleError handles the failure err of the connection transport. Without reconnection it's sent
+// on the Error channel and the client shuts down.
+func (s *Client) handleError(transport Transport, err error) {
+	if s.reconnectInterval == 0 {
+		s.Error <- err
+		s.Shutdown()
+		return
+	}
+	s.reportError(err)
+	s.reconnect(transport)
+}
+
+// reportError sends err on the Error channel if a receiver is waiting.
+func (s *Client) reportError(err error) {
+	select {
+	case s.Error <- err:
+	default:
+	}
+}
+
+// reconnect replaces the broken transport old, retrying with backoff until a new connection is
+// established or the client is shut down. The session is restored in the background, requests
+// wait until it's done.
+func (s *Client) reconnect(old Transport) {
+	s.transportLock.Lock()
+	select {
+	case <-s.ready:
+		// The session was restored on the broken connection, start over.
+		s.ready = make(chan struct{})
+		s.backoff = 0
+	default:
+	}
+	ready := s.ready
+	s.transportLock.Unlock()
+
+	_ = old.Close()
+	s.failPending()
+
+	for {
+		if s.backoff == 0 {
+			s.backoff = s.reconnectInterval
+		} else {
+			s.backoff = min(2*s.backoff, s.reconnectIntervalMax)
+		}
+		select {
+		case <-s.quit:
+			return
+		case <-time.After(s.backoff):
+		}
+
+		transport, err := s.dial(s.ctx)
+		if err != nil {
+			s.reportError(err)
+			continue
+		}
+
+		s.transportLock.Lock()
+		if s.IsShutdown() {
+			s.transportLock.Unlock()
+			_ = transport.Close()
+			return
+		}
+		s.transport = transport
+		s.transportLock.Unlock()
+
+		go s.restore(transport, ready)
+		return
+	}
+}
+
+// failPending fails the requests waiting for a response on the lost connection.
+func (s *Client) failPending() {
+	s.handlersLock.RLock()
+	defer s.handlersLock.RUnlock()
+	for _, c := range s.handlers {
+		select {
+		case c <- &container{err: ErrConnectionLost}:
+		default:
+		}
+	}
+}
+
+// restore replays the server.version negotiation then the subscriptions on the new connection
+// transport, and marks the client ready. On failure the connection is dropped to try again.
+func (s *Client) restore(transport Transport, ready chan struct{}) {
+	s.replaysLock.Lock()
+	var replays []func(context.Context) error
+	if s.version != nil {
+		replays = append(replays, s.version)
+	}
+	replays = append(replays, s.replays...)
+	s.replaysLock.Unlock()
+
+	for _, replay := range replays {
+		ctx, cancel := context.WithTimeout(s.ctx, RestoreTimeout)
+		err := replay(ctx)
+		cancel()
+		if err != nil {
+			s.dropTransport(transport, fmt.Errorf("restoring session failed: %v", err))
+			return
+		}
+	}
+
+	close(ready)
+}
+
+// addReplay records a request to replay on a new connection.
+func (s *Client) addReplay(replay func(context.Context) error) {
+	s.replaysLock.Lock()
+	s.replays = append(s.replays, replay)
+	s.replaysLock.Unlock()
+}
+
+// dispatchPush delivers a notification of method to its push handlers, as if sent by the server.
+func (s *Client) dispatchPush(method string, params interface{}) error {
+	bytes, err := json.Marshal(struct {
+		Method string      `json:"method"`
+		Params interface{} `json:"params"`
+	}{method, params})
+	if err != nil {
+		return err
+	}
+
+	s.dispatch(bytes)
+	return nil
+}
+
+// keepAlive pings the server every pingInterval, and drops the connection when a ping isn't
+// answered in time.
+func (s *Client) keepAlive() {
+	ticker := time.NewTicker(s.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-ticker.C:
+		}
+
+		s.transportLock.RLock()
+		transport, ready := s.transport, s.ready
+		s.transportLock.RUnlock()
+		select {
+		case <-ready:
+		default:
+			// Reconnecting.
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(s.ctx, s.pingInterval)
+		err := s.Ping(ctx)
+		cancel()
+		if err == ErrTimeout {
+			s.dropTransport(transport, err)
+		}
+	}
+}
@@ -103,6 +103,13 @@ func (s *Client) ServerVersion(ctx context.Context) (serverVer, protocolVer stri
 	} else {
 		serverVer = resp.Result[0]
 		protocolVer = resp.Result[1]
+
+		// Negotiate again first thing on a new connection.
+		s.replaysLock.Lock()
+		s.version = func(ctx context.Context) error {
+			return s.call(ctx, "server.version", []interface{}{ClientVersion, ProtocolVersion}, nil)
+		}
+		s.replaysLock.Unlock()
 	}
 
 	return
@@ -36,6 +36,23 @@ func (s *Client) SubscribeHeaders(ctx context.Context) (<-chan *SubscribeHeaders
 	respChan := make(chan *SubscribeHeadersResult, 1)
 	respChan <- resp.Result
 
+	s.replaysLock.Lock()
+	if !s.headers {
+		s.headers = true
+		s.replays = append(s.replays, func(ctx context.Context) error {
+			var resp SubscribeHeadersResp
+
+			err := s.call(ctx, "blockchain.headers.subscribe", []interface{}{}, &resp)
+			if err != nil {
+				return err
+			}
+
+			// The tip may have changed while disconnected.
+			return s.dispatchPush("blockchain.headers.subscribe", []*SubscribeHeadersResult{resp.Result})
+		})
+	}
+	s.replaysLock.Unlock()
+
 	go func() {
 		for msg := range s.listenPush("blockchain.headers.subscribe") {
 			if msg.err != nil {
@@ -65,8 +82,16 @@ type ScripthashSubscription struct {
 
 	subscribedSH  []string
 	scripthashMap map[string]string
+	// statuses holds the last status pushed by scripthash. restores counts the calls to
+	// Resubscribe, received holds its value when the last notification of a scripthash
+	// was received.
+	statuses map[string]string
+	restores uint64
+	received map[string]uint64
 
 	lock sync.RWMutex
+	// notifLock orders the pushes onto notifChan, it's never held with lock.
+	notifLock sync.Mutex
 }
 
 // SubscribeNotif represent the notification to SubscribeScripthash() and SubscribeMasternode().
@@ -80,7 +105,10 @@ func (s *Client) SubscribeScripthash() (*ScripthashSubscription, <-chan *Subscri
 		server:        s,
 		notifChan:     make(chan *SubscribeNotif, 1),
 		scripthashMap: make(map[string]string),
+		statuses:      make(map[string]string),
+		received:      make(map[string]uint64),
 	}
+	s.addReplay(sub.Resubscribe)
 
 	go func() {
 		for msg := range s.listenPush("blockchain.scripthash.subscribe") {
@@ -95,14 +123,18 @@ func (s *Client) SubscribeScripthash() (*ScripthashSubscription, <-chan *Subscri
 				return
 			}
 
+			sub.notifLock.Lock()
 			sub.lock.Lock()
-			for _, a := range sub.subscribedSH {
-				if a == resp.Params[0] {
-					sub.notifChan <- &resp
-					break
-				}
+			subscribed := sub.subscribed(resp.Params[0])
+			if subscribed {
+				sub.statuses[resp.Params[0]] = resp.Params[1]
+				sub.received[resp.Params[0]] = sub.restores
 			}
 			sub.lock.Unlock()
+			if subscribed {
+				sub.notifChan <- &resp
+			}
+			sub.notifLock.Unlock()
 		}
 	}()
 
@@ -119,7 +151,12 @@ func (sub *ScripthashSubscription) Add(ctx context.Context, scripthash string, a
 	}
 
 	if len(resp.Result) > 0 {
+		sub.notifLock.Lock()
+		sub.lock.Lock()
+		sub.statuses[scripthash] = resp.Result
+		sub.lock.Unlock()
 		sub.notifChan <- &SubscribeNotif{[2]string{scripthash, resp.Result}}
+		sub.notifLock.Unlock()
 	}
 
 	sub.lock.Lock()
@@ -172,6 +209,7 @@ func (sub *ScripthashSubscription) Remove(scripthash string) error {
 		if v == scripthash {
 			sub.lock.Lock()
 			sub.subscribedSH = append(sub.subscribedSH[:i], sub.subscribedSH[i+1:]...)
+			delete(sub.statuses, scripthash)
 			sub.lock.Unlock()
 			return nil
 		}
@@ -192,6 +230,7 @@ func (sub *ScripthashSubscription) RemoveAddress(address string) error {
 			sub.lock.Lock()
 			sub.subscribedSH = append(sub.subscribedSH[:i], sub.subscribedSH[i+1:]...)
 			delete(sub.scripthashMap, scripthash)
+			delete(sub.statuses, scripthash)
 			sub.lock.Unlock()
 			return nil
 		}
@@ -200,18 +239,67 @@ func (sub *ScripthashSubscription) RemoveAddress(address string) error {
 	return errors.New("scripthash not found")
 }
 
-// Resubscribe ...
+// Resubscribe subscribes again to all the scripthashes of the subscription. The client calls it
+// after reconnecting. The statuses that changed while disconnected are pushed in the background,
+// so that a slow reader of the channel doesn't fail the reconnection.
 func (sub *ScripthashSubscription) Resubscribe(ctx context.Context) error {
-	for _, v := range sub.subscribedSH {
-		err := sub.Add(ctx, v)
+	sub.lock.Lock()
+	sub.restores++
+	restore := sub.restores
+	scripthashes := append([]string(nil), sub.subscribedSH...)
+	sub.lock.Unlock()
+
+	var notifs []*SubscribeNotif
+	for _, v := range scripthashes {
+		var resp basicResp
+
+		err := sub.server.call(ctx, "blockchain.scripthash.subscribe", []interface{}{v}, &resp)
 		if err != nil {
 			return err
 		}
+
+		if len(resp.Result) > 0 {
+			notifs = append(notifs, &SubscribeNotif{[2]string{v, resp.Result}})
+		}
 	}
 
+	if len(notifs) > 0 {
+		go sub.pushRestored(restore, notifs)
+	}
 	return nil
 }
 
+// pushRestored pushes the statuses got by the Resubscribe call number restore, skipping the
+// unchanged ones and the ones outdated by a notification or a later Resubscribe call.
+func (sub *ScripthashSubscription) pushRestored(restore uint64, notifs []*SubscribeNotif) {
+	sub.notifLock.Lock()
+	defer sub.notifLock.Unlock()
+
+	for _, notif := range notifs {
+		scripthash, status := notif.Params[0], notif.Params[1]
+		sub.lock.Lock()
+		push := sub.restores == restore && sub.received[scripthash] != restore &&
+			sub.subscribed(scripthash) && sub.statuses[scripthash] != status
+		if push {
+			sub.statuses[scripthash] = status
+		}
+		sub.lock.Unlock()
+		if push {
+			sub.notifChan <- notif
+		}
+	}
+}
+
+// subscribed returns whether scripthash is subscribed, sub.lock must be held.
+func (sub *ScripthashSubscription) subscribed(scripthash string) bool {
+	for _, v := range sub.subscribedSH {
+		if v == scripthash {
+			return true
+		}
+	}
+	return false
+}
+
 // SubscribeMasternode subscribes to receive notifications when a masternode status changes.
 // https://electrumx.readthedocs.io/en/latest/protocol-methods.html#blockchain-headers-subscribe
 func (s *Client) SubscribeMasternode(ctx context.Context, collateral string) (<-chan string, error) {
@@ -227,6 +315,17 @@ func (s *Client) SubscribeMasternode(ctx context.Context, collateral string) (<-
 		respChan <- resp.Result
 	}
 
+	s.addReplay(func(ctx context.Context) error {
+		var resp basicResp
+
+		err := s.call(ctx, "blockchain.masternode.subscribe", []interface{}{collateral}, &resp)
+		if err != nil {
+			return err
+		}
+
+		return s.dispatchPush("blockchain.masternode.subscribe", [2]string{collateral, resp.Result})
+	})
+
 	go func() {
 		for msg := range s.listenPush("blockchain.masternode.subscribe") {
 			if msg.err != nil {
@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"log"
 	"net"
+	"sync"
 	"time"
 )
 
@@ -14,6 +15,8 @@ type TCPTransport struct {
 	conn      net.Conn
 	responses chan []byte
 	errors    chan error
+	quit      chan struct{}
+	closeOnce sync.Once
 }
 
 // NewTCPTransport opens a new TCP connection to the remote server.
@@ -29,6 +32,7 @@ func NewTCPTransport(ctx context.Context, addr string) (*TCPTransport, error) {
 		conn:      conn,
 		responses: make(chan []byte),
 		errors:    make(chan error),
+		quit:      make(chan struct{}),
 	}
 
 	go tcp.listen()
@@ -51,6 +55,7 @@ func NewSSLTransport(ctx context.Context, addr string, config *tls.Config) (*TCP
 		conn:      conn,
 		responses: make(chan []byte),
 		errors:    make(chan error),
+		quit:      make(chan struct{}),
 	}
 
 	go tcp.listen()
@@ -65,14 +70,21 @@ func (t *TCPTransport) listen() {
 	for {
 		line, err := reader.ReadBytes(nl)
 		if err != nil {
-			t.errors <- err
+			select {
+			case t.errors <- err:
+			case <-t.quit:
+			}
 			break
 		}
 		if DebugMode {
 			log.Printf("%s [debug] %s -> %s", time.Now().Format("2006-01-02 15:04:05"), t.conn.RemoteAddr(), line)
 		}
 
-		t.responses <- line
+		select {
+		case t.responses <- line:
+		case <-t.quit:
+			return
+		}
 	}
 }
 
@@ -96,6 +108,8 @@ func (t *TCPTransport) Errors() <-chan error {
 	return t.errors
 }
 
+// Close closes the connection, the pending responses and errors are discarded.
 func (t *TCPTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.quit) })
 	return t.conn.Close()
 }
@@ -0,0 +1,92 @@
+package jsonrpc
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// SatoshiPerBitcoin is the number of satoshis in one bitcoin.
+const SatoshiPerBitcoin = 1e8
+
+// Amount represents a bitcoin amount in satoshis.
+//
+// It is marshalled to and unmarshalled from the JSON decimal BTC numbers
+// used by bitcoind (eg 0.00010000) without going through float64, so no
+// precision is lost.
+type Amount int64
+
+// NewAmount converts a float BTC value to an Amount, rounding to the
+// nearest satoshi. It is meant for migrating float64 code, prefer
+// ParseAmount for user input.
+func NewAmount(btc float64) (Amount, error) {
+	if math.IsNaN(btc) || math.IsInf(btc, 0) {
+		return 0, errors.New("Bad amount " + strconv.FormatFloat(btc, 'g', -1, 64))
+	}
+	return Amount(math.Round(btc * SatoshiPerBitcoin)), nil
+}
+
+// ToBTC returns the amount in BTC. The result may be inexact, only use it
+// for display.
+func (a Amount) ToBTC() float64 {
+	return float64(a) / SatoshiPerBitcoin
+}
+
+// String returns the amount in BTC with 8 decimals, eg "0.00010000".
+func (a Amount) String() string {
+	return string(a.appendDecimal(nil))
+}
+
+// appendDecimal appends the 8 decimals BTC representation of a to dst.
+func (a Amount) appendDecimal(dst []byte) []byte {
+	u := uint64(a)
+	if a < 0 {
+		dst = append(dst, '-')
+		u = uint64(-a)
+	}
+	dst = strconv.AppendUint(dst, u/SatoshiPerBitcoin, 10)
+	frac := strconv.FormatUint(u%SatoshiPerBitcoin+SatoshiPerBitcoin, 10)
+	dst = append(dst, '.')
+	return append(dst, frac[1:]...)
+}
+
+// MarshalJSON marshals the amount as a decimal BTC number.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return a.appendDecimal(nil), nil
+}
+
+// UnmarshalJSON unmarshals a decimal BTC number. It fails if the number has
+// more than 8 decimals.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	// Some wrappers quote amounts
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// ParseAmount parses a decimal BTC string such as "0.0001" or "1e-8".
+func ParseAmount(s string) (Amount, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, errors.New("Bad amount " + strconv.Quote(s))
+	}
+	r.Mul(r, big.NewRat(SatoshiPerBitcoin, 1))
+	if !r.IsInt() {
+		return 0, errors.New("Bad amount " + strconv.Quote(s) + ": more than 8 decimals")
+	}
+	if !r.Num().IsInt64() {
+		return 0, errors.New("Bad amount " + strconv.Quote(s) + ": out of range")
+	}
+	return Amount(r.Num().Int64()), nil
+}
@@ -0,0 +1,61 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"math"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Amount", func() {
+	Describe("unmarshal", func() {
+		Context("when the number is exact", func() {
+			var amounts []Amount
+			err := json.Unmarshal([]byte(`[0.1, 0.00000001, 20999999.97690000, -0.3, 1e-8, "0.5", 0]`), &amounts)
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should be exact", func() {
+				Expect(amounts).To(Equal([]Amount{10000000, 1, 2099999997690000, -30000000, 1, 50000000, 0}))
+			})
+		})
+
+		Context("when the number has more than 8 decimals", func() {
+			var amount Amount
+			err := json.Unmarshal([]byte(`0.000000001`), &amount)
+			It("error should occured", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("marshal", func() {
+		data, err := json.Marshal([]Amount{10000000, 1, -30000000, 0, 2099999997690000})
+		It("should not error", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should use 8 decimals", func() {
+			Expect(string(data)).To(Equal(`[0.10000000,0.00000001,-0.30000000,0.00000000,20999999.97690000]`))
+		})
+	})
+
+	Describe("NewAmount", func() {
+		Context("when the float is inexact", func() {
+			amount, err := NewAmount(0.1 + 0.2)
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should round to the nearest satoshi", func() {
+				Expect(amount).To(Equal(Amount(30000000)))
+			})
+		})
+
+		Context("when the float is not a number", func() {
+			_, err := NewAmount(math.NaN())
+			It("error should occured", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// A BatchCall is a single call queued in a Batch.
+type BatchCall struct {
+	// Method is the RPC method name
+	Method string
+
+	// Params are the RPC parameters
+	Params interface{}
+
+	// Result is where the call result is unmarshaled into once the batch
+	// is sent. It must be a pointer, or nil if the result is not needed.
+	Result interface{}
+
+	// Err is set by Batch.Send when the call failed, either because the
+	// server returned an error for it or because its result could not be
+	// unmarshaled into Result.
+	Err error
+}
+
+// A Batch queues RPC calls and sends them to bitcoind in a single
+// JSON-RPC batch request (one HTTP round-trip).
+//
+// A Batch is not safe for concurrent use.
+type Batch struct {
+	client *rpcClient
+	calls  []*BatchCall
+}
+
+// NewBatch returns an empty batch sent through b.
+// The batch inherits the context b is bound to.
+func (b *Bitcoind) NewBatch() *Batch {
+	return &Batch{client: b.client}
+}
+
+// Queue adds a call to the batch. result must be a pointer the call result
+// is unmarshaled into, or nil.
+func (bt *Batch) Queue(method string, params interface{}, result interface{}) *BatchCall {
+	call := &BatchCall{Method: method, Params: params, Result: result}
+	bt.calls = append(bt.calls, call)
+	return call
+}
+
+// Len returns the number of queued calls.
+func (bt *Batch) Len() int {
+	return len(bt.calls)
+}
+
+// Calls returns the queued calls, in queue order.
+func (bt *Batch) Calls() []*BatchCall {
+	return bt.calls
+}
+
+// Reset removes all queued calls so the batch can be reused.
+func (bt *Batch) Reset() {
+	bt.calls = nil
+}
+
+// Send sends all queued calls in a single request.
+// The returned error is only about the request as a whole (transport
+// failure, batch rejected by the server...); per call errors are
+// reported in each BatchCall.Err.
+func (bt *Batch) Send() error {
+	if len(bt.calls) == 0 {
+		return nil
+	}
+	base := time.Now().UnixNano()
+	requests := make([]rpcRequest, len(bt.calls))
+	for i, call := range bt.calls {
+		requests[i] = rpcRequest{call.Method, call.Params, base + int64(i), "1.0"}
+	}
+	responses, err := bt.client.callBatch(requests)
+	if err != nil {
+		return err
+	}
+	for i, call := range bt.calls {
+		r := responses[i]
+		if call.Err = handleError(nil, &r); call.Err != nil {
+			continue
+		}
+		if call.Result != nil {
+			call.Err = json.Unmarshal(r.Result, call.Result)
+		}
+	}
+	return nil
+}
+
+// GetBlockHash queues a getblockhash call, hash is set once the batch is sent.
+func (bt *Batch) GetBlockHash(index uint64, hash *string) *BatchCall {
+	return bt.Queue("getblockhash", []uint64{index}, hash)
+}
+
+// GetBlock queues a getblock call, block is set once the batch is sent.
+func (bt *Batch) GetBlock(blockHash string, block *Block) *BatchCall {
+	return bt.Queue("getblock", []string{blockHash}, block)
+}
+
+// GetBlockV2 queues a verbose "2" getblock call, block is set once the batch is sent.
+func (bt *Batch) GetBlockV2(blockHash string, block *BlockV2) *BatchCall {
+	return bt.Queue("getblock", []interface{}{blockHash, 2}, block)
+}
+
+// GetBlockV3 queues a verbose "3" getblock call, block is set once the batch is sent.
+func (bt *Batch) GetBlockV3(blockHash string, block *BlockV3) *BatchCall {
+	return bt.Queue("getblock", []interface{}{blockHash, 3}, block)
+}
+
+// GetBlockheader queues a getblockheader call, header is set once the batch is sent.
+func (bt *Batch) GetBlockheader(blockHash string, header *BlockHeader) *BatchCall {
+	return bt.Queue("getblockheader", []string{blockHash}, header)
+}
+
+// GetRawTransaction queues a verbose getrawtransaction call, rawTx is set
+// once the batch is sent.
+func (bt *Batch) GetRawTransaction(txId string, rawTx *RawTransaction) *BatchCall {
+	return bt.Queue("getrawtransaction", []interface{}{txId, 1}, rawTx)
+}
+
+// GetRawTransactionHex queues a non verbose getrawtransaction call, hex is
+// set to the serialized transaction once the batch is sent.
+func (bt *Batch) GetRawTransactionHex(txId string, hex *string) *BatchCall {
+	return bt.Queue("getrawtransaction", []interface{}{txId, 0}, hex)
+}
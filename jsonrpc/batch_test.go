@@ -0,0 +1,68 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Batch", func() {
+	Describe("send a batch", func() {
+		Context("when success with a per call error", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				var reqs []rpcRequest
+				json.Unmarshal(body, &reqs)
+				// Reply in reverse order, the client must reorder by id.
+				fmt.Fprintf(w, `[{"result":null,"error":{"code":-8,"message":"Block height out of range"},"id":%d},`+
+					`{"result":"00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048","error":null,"id":%d}]`,
+					reqs[1].Id, reqs[0].Id)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			batch := bitcoindClient.NewBatch()
+			var hash1, hash2 string
+			call1 := batch.GetBlockHash(1, &hash1)
+			call2 := batch.GetBlockHash(100000000, &hash2)
+			err = batch.Send()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("first call should succeed", func() {
+				Expect(call1.Err).NotTo(HaveOccurred())
+				Expect(hash1).To(Equal("00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048"))
+			})
+			It("second call should fail", func() {
+				Expect(call2.Err).To(HaveOccurred())
+				Expect(hash2).To(BeEmpty())
+			})
+		})
+
+		Context("when the batch is rejected", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":null,"error":{"code":-32700,"message":"Parse error"},"id":null}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			batch := bitcoindClient.NewBatch()
+			batch.Queue("getblockcount", nil, nil)
+			err = batch.Send()
+			It("error should occured", func() {
+				Expect(err).Should(MatchError("-32700: Parse error"))
+			})
+		})
+	})
+})
@@ -2,11 +2,15 @@
 package jsonrpc
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"strconv"
 
 	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
 )
 
 const (
@@ -22,6 +26,8 @@ type Bitcoind struct {
 }
 
 // New return a new bitcoind
+// When useSSL is set the server certificate is not verified, use
+// NewWithOptions and WithTLS or WithRootCAs to verify it.
 func New(host string, port int, user, passwd string, useSSL bool, timeoutParam ...int) (*Bitcoind, error) {
 	var timeout int = RPCCLIENT_TIMEOUT
 	// If the timeout is specified in timeoutParam, allow it.
@@ -33,7 +39,30 @@ func New(host string, port int, user, passwd string, useSSL bool, timeoutParam .
 	if err != nil {
 		return nil, err
 	}
-	return &Bitcoind{rpcClient}, nil
+	return &Bitcoind{client: rpcClient}, nil
+}
+
+// WithContext returns a copy of b whose calls are bound to ctx.
+// When ctx is canceled or its deadline expires, in-flight calls made through
+// the returned Bitcoind are aborted and return the context error.
+// The copy shares the underlying HTTP connections with b.
+func (b *Bitcoind) WithContext(ctx context.Context) *Bitcoind {
+	if ctx == nil {
+		panic("nil context")
+	}
+	return &Bitcoind{client: b.client.withContext(ctx)}
+}
+
+// WithRetry returns a copy of b whose calls are retried with policy.
+// The copy shares the underlying HTTP connections with b.
+func (b *Bitcoind) WithRetry(policy RetryPolicy) *Bitcoind {
+	return &Bitcoind{client: b.client.withRetry(&policy)}
+}
+
+// Context returns the context calls are bound to.
+// It defaults to context.Background().
+func (b *Bitcoind) Context() context.Context {
+	return b.client.context()
 }
 
 // BackupWallet Safely copies wallet.dat to destination,
@@ -60,6 +89,8 @@ func (b *Bitcoind) EncryptWallet(passphrase string) error {
 }
 
 // GetAccount returns the account associated with the given address.
+//
+// Deprecated: Bitcoin Core 0.18 removed accounts, use GetAddressInfo and its labels instead.
 func (b *Bitcoind) GetAccount(address string) (account string, err error) {
 	r, err := b.client.call("getaccount", []string{address})
 	if err = handleError(err, &r); err != nil {
@@ -73,6 +104,8 @@ func (b *Bitcoind) GetAccount(address string) (account string, err error) {
 // payments to this account.
 // If account does not exist, it will be created along with an
 // associated new address that will be returned.
+//
+// Deprecated: Bitcoin Core 0.18 removed accounts, use GetNewAddress and SetLabel instead.
 func (b *Bitcoind) GetAccountAddress(account string) (address string, err error) {
 	r, err := b.client.call("getaccountaddress", []string{account})
 	if err = handleError(err, &r); err != nil {
@@ -83,6 +116,8 @@ func (b *Bitcoind) GetAccountAddress(account string) (address string, err error)
 }
 
 // GetAddressesByAccount return addresses associated with account <account>
+//
+// Deprecated: Bitcoin Core 0.18 removed accounts, use GetAddressesByLabel instead.
 func (b *Bitcoind) GetAddressesByAccount(account string) (addresses []string, err error) {
 	r, err := b.client.call("getaddressesbyaccount", []string{account})
 	if err = handleError(err, &r); err != nil {
@@ -95,12 +130,22 @@ func (b *Bitcoind) GetAddressesByAccount(account string) (addresses []string, er
 // GetBalance return the balance of the server or of a specific account
 // If [account] is "", returns the server's total available balance.
 // If [account] is specified, returns the balance in the account
+//
+// Deprecated: float64 loses precision, use GetBalanceAmount instead.
 func (b *Bitcoind) GetBalance(account string, minconf uint64) (balance float64, err error) {
+	amount, err := b.GetBalanceAmount(account, minconf)
+	return amount.ToBTC(), err
+}
+
+// GetBalanceAmount return the balance of the server or of a specific account
+// If [account] is "", returns the server's total available balance.
+// If [account] is specified, returns the balance in the account
+func (b *Bitcoind) GetBalanceAmount(account string, minconf uint64) (balance Amount, err error) {
 	r, err := b.client.call("getbalance", []interface{}{account, minconf})
 	if err = handleError(err, &r); err != nil {
 		return
 	}
-	balance, err = strconv.ParseFloat(string(r.Result), 64)
+	err = json.Unmarshal(r.Result, &balance)
 	return
 }
 
@@ -164,6 +209,38 @@ func (b *Bitcoind) GetBlockV2(blockHash string) (block BlockV2, err error) {
 	return
 }
 
+// GetBlockV3 returns verbose "3" information about the block with the given
+// hash: like GetBlockV2 with the output spent by each input (Vin.Prevout).
+func (b *Bitcoind) GetBlockV3(blockHash string) (block BlockV3, err error) {
+	r, err := b.client.call("getblock", []interface{}{blockHash, 3})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &block)
+	return
+}
+
+// GetBlockMsg returns the block with the given hash, decoded.
+func (b *Bitcoind) GetBlockMsg(blockHash string) (*wire.MsgBlock, error) {
+	r, err := b.client.call("getblock", []interface{}{blockHash, 0})
+	if err = handleError(err, &r); err != nil {
+		return nil, err
+	}
+	var blockHex string
+	if err = json.Unmarshal(r.Result, &blockHex); err != nil {
+		return nil, err
+	}
+	serialized, err := hex.DecodeString(blockHex)
+	if err != nil {
+		return nil, err
+	}
+	block := &wire.MsgBlock{}
+	if err = block.Deserialize(bytes.NewReader(serialized)); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
 // GetRawBlock returns information about the block with the given hash.
 func (b *Bitcoind) GetRawBlock(blockHash string) (str string, err error) {
 	r, err := b.client.call("getblock", []interface{}{blockHash, false})
@@ -242,6 +319,24 @@ func (b *Bitcoind) GetBlockTemplate(rules []string, capabilities []string, mode
 	return blockTemplateResponse, nil
 }
 
+// GetBlockTemplateLongPoll is GetBlockTemplate with BIP22 long polling: the
+// call blocks until bitcoind's template differs from the one identified by
+// longpollid, ie a new block was found or the mempool changed, and returns
+// the new template. An empty longpollid returns immediately.
+// The call is not subject to the client timeout, bound it with WithContext.
+func (b *Bitcoind) GetBlockTemplateLongPoll(rules []string, longpollid string) (template btcjson.GetBlockTemplateResult, err error) {
+	params := getBlockTemplateParams{
+		Rules:      rules,
+		LongPollID: longpollid,
+	}
+	r, err := b.client.withTimeout(0).call("getblocktemplate", []getBlockTemplateParams{params})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &template)
+	return
+}
+
 type ChainTip struct {
 	// The height of the current tip
 	Height int
@@ -284,6 +379,8 @@ func (b *Bitcoind) GetDifficulty() (difficulty float64, err error) {
 }
 
 // GetGenerate returns true or false whether bitcoind is currently generating hashes
+//
+// Deprecated: Bitcoin Core 0.13 removed getgenerate.
 func (b *Bitcoind) GetGenerate() (generate bool, err error) {
 	r, err := b.client.call("getgenerate", nil)
 	if err = handleError(err, &r); err != nil {
@@ -294,6 +391,8 @@ func (b *Bitcoind) GetGenerate() (generate bool, err error) {
 }
 
 // GetHashesPerSec returns a recent hashes per second performance measurement while generating.
+//
+// Deprecated: Bitcoin Core 0.13 removed gethashespersec.
 func (b *Bitcoind) GetHashesPerSec() (hashpersec float64, err error) {
 	r, err := b.client.call("gethashespersec", nil)
 	if err = handleError(err, &r); err != nil {
@@ -305,15 +404,15 @@ func (b *Bitcoind) GetHashesPerSec() (hashpersec float64, err error) {
 
 // See https://developer.bitcoin.org/reference/rpc/getmempoolinfo.html
 type MemPoolInfo struct {
-	Loaded           bool    `json:"loaded"`
-	Size             uint64  `json:"size"`
-	Bytes            uint64  `json:"bytes"`
-	Usage            uint64  `json:"usage"`
-	TotalFee         float64 `json:"total_fee"`
-	MaxMemPool       uint64  `json:"maxmempool"`
-	MemPoolMinFee    float64 `json:"mempoolminfee"`
-	MinRelayTxFee    float64 `json:"minrelaytxfee"`
-	UnbroadcastCount uint64  `json:"unbroadcastcount"`
+	Loaded           bool   `json:"loaded"`
+	Size             uint64 `json:"size"`
+	Bytes            uint64 `json:"bytes"`
+	Usage            uint64 `json:"usage"`
+	TotalFee         Amount `json:"total_fee"`
+	MaxMemPool       uint64 `json:"maxmempool"`
+	MemPoolMinFee    Amount `json:"mempoolminfee"`
+	MinRelayTxFee    Amount `json:"minrelaytxfee"`
+	UnbroadcastCount uint64 `json:"unbroadcastcount"`
 }
 
 // GetMemPoolInfo returns an object containing mem pool info
@@ -374,8 +473,8 @@ type NetworkInfo struct {
 	ConnectionsOut     uint64         `json:"connections_out"`
 	NetworkActive      bool           `json:"networkactive"`
 	Networks           []Network      `json:"networks"`
-	RelayFee           float64        `json:"relayfee"`
-	IncrementalFee     float64        `json:"incrementalfee"`
+	RelayFee           Amount         `json:"relayfee"`
+	IncrementalFee     Amount         `json:"incrementalfee"`
 	LocalAddresses     []LocalAddress `json:"localaddresses"`
 	Warnings           string         `json:"warnings"`
 }
@@ -414,7 +513,7 @@ type WalletInfo struct {
 	KeyPoolSize           uint64   `json:"keypoolsize"`
 	KeyPoolSizeHdInternal uint64   `json:"keypoolsize_hd_internal"`
 	UnlockedUntil         uint64   `json:"unlocked_until"`
-	PayTxFee              float64  `json:"paytxfee"`
+	PayTxFee              Amount   `json:"paytxfee"`
 	HdSeedId              string   `json:"hdseedid"`
 	PrivateKeysEnabled    bool     `json:"private_keys_enabled"`
 	AvoidReuse            bool     `json:"avoid_reuse"`
@@ -502,9 +601,9 @@ type VerboseTx struct {
 	// Virtual transaction size as defined in BIP 141
 	Size uint32
 	// Transaction fee in BTC
-	Fee float64
+	Fee Amount
 	// Transaction fee with fee deltas used for mining priority
-	ModifiedFee float64
+	ModifiedFee Amount
 	// Local time when tx entered pool
 	Time uint32
 	// Block height when tx entered pool
@@ -514,13 +613,13 @@ type VerboseTx struct {
 	// Virtual transaction size of in-mempool descendants (including this one)
 	DescendantSize uint32
 	// Modified fees (see above) of in-mempool descendants (including this one)
-	DescendantFees float64
+	DescendantFees Amount
 	// Number of in-mempool ancestor transactions (including this one)
 	AncestorCount uint32
 	// Virtual transaction size of in-mempool ancestors (including this one)
 	AncestorSize uint32
 	// Modified fees (see above) of in-mempool ancestors (including this one)
-	AncestorFees uint32
+	AncestorFees Amount
 	// Hash of serialized transaction, including witness data
 	WTxId string
 	// Unconfirmed transactions used as inputs for this transaction
@@ -529,6 +628,23 @@ type VerboseTx struct {
 	SpentBy []string
 }
 
+// UnmarshalJSON unmarshals a verbose mempool entry. Unlike the other amounts,
+// bitcoind reports descendantfees and ancestorfees in satoshis.
+func (tx *VerboseTx) UnmarshalJSON(data []byte) error {
+	type verboseTx VerboseTx
+	v := struct {
+		*verboseTx
+		DescendantFees int64 `json:"descendantfees"`
+		AncestorFees   int64 `json:"ancestorfees"`
+	}{verboseTx: (*verboseTx)(tx)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	tx.DescendantFees = Amount(v.DescendantFees)
+	tx.AncestorFees = Amount(v.AncestorFees)
+	return nil
+}
+
 // GetRawMempoolVerbose returns a verbose set of transactions
 // map [TxId] => VerboseTx
 func (b *Bitcoind) GetRawMempoolVerbose() (txs map[string]VerboseTx, err error) {
@@ -564,6 +680,8 @@ func (b *Bitcoind) GetRawTransaction(txId string, verbose bool) (rawTx interface
 // GetReceivedByAccount Returns the total amount received by addresses with [account] in
 // transactions with at least [minconf] confirmations. If [account] is set to all return
 // will include all transactions to all accounts
+//
+// Deprecated: Bitcoin Core 0.18 removed accounts, use GetReceivedByLabel instead.
 func (b *Bitcoind) GetReceivedByAccount(account string, minconf uint32) (amount float64, err error) {
 	if account == "all" {
 		account = ""
@@ -580,7 +698,16 @@ func (b *Bitcoind) GetReceivedByAccount(account string, minconf uint32) (amount
 // It correctly handles the case where someone has sent to the address in multiple transactions.
 // Keep in mind that addresses are only ever used for receiving transactions. Works only for addresses
 // in the local wallet, external addresses will always show 0.
+//
+// Deprecated: float64 loses precision, use GetReceivedByAddressAmount instead.
 func (b *Bitcoind) GetReceivedByAddress(address string, minconf uint32) (amount float64, err error) {
+	a, err := b.GetReceivedByAddressAmount(address, minconf)
+	return a.ToBTC(), err
+}
+
+// GetReceivedByAddressAmount returns the amount received by <address> in transactions
+// with at least [minconf] confirmations.
+func (b *Bitcoind) GetReceivedByAddressAmount(address string, minconf uint32) (amount Amount, err error) {
 	r, err := b.client.call("getreceivedbyaddress", []interface{}{address, minconf})
 	if err = handleError(err, &r); err != nil {
 		return
@@ -622,6 +749,8 @@ func (b *Bitcoind) GetTxOutsetInfo() (txOutSet TransactionOutSet, err error) {
 // GetWork
 // If [data] is not specified, returns formatted hash data to work on
 // If [data] is specified, tries to solve the block and returns true if it was successful.
+//
+// Deprecated: Bitcoin Core 0.10 removed getwork, use GetBlockTemplate instead.
 func (b *Bitcoind) GetWork(data ...string) (response interface{}, err error) {
 	if len(data) > 1 {
 		err = errors.New("bad parameters for GetWork: you can set 0 or 1 parameter data")
@@ -666,6 +795,8 @@ func (b *Bitcoind) KeyPoolRefill() error {
 }
 
 // ListAccounts returns Object that has account names as keys, account balances as values.
+//
+// Deprecated: Bitcoin Core 0.18 removed accounts, use ListLabels and GetBalances instead.
 func (b *Bitcoind) ListAccounts(minconf int32) (accounts map[string]float64, err error) {
 	r, err := b.client.call("listaccounts", []int32{minconf})
 	if err = handleError(err, &r); err != nil {
@@ -678,7 +809,7 @@ func (b *Bitcoind) ListAccounts(minconf int32) (accounts map[string]float64, err
 // ListAddressResult represents a result composing ListAddressGroupings slice reply
 type ListAddressResult struct {
 	Address string
-	Amount  float64
+	Amount  Amount
 	Account string
 }
 
@@ -689,11 +820,30 @@ func (b *Bitcoind) ListAddressGroupings() (list []ListAddressResult, err error)
 		return
 	}
 	// hum.....
-	var t [][][]interface{}
-	err = json.Unmarshal(r.Result, &t)
+	var t [][][]json.RawMessage
+	if err = json.Unmarshal(r.Result, &t); err != nil {
+		return
+	}
 	for _, tt := range t {
 		for _, ttt := range tt {
-			list = append(list, ListAddressResult{ttt[0].(string), ttt[1].(float64), ttt[2].(string)})
+			var res ListAddressResult
+			if len(ttt) < 2 {
+				err = errors.New("Bad listaddressgroupings entry")
+				return
+			}
+			if err = json.Unmarshal(ttt[0], &res.Address); err != nil {
+				return
+			}
+			if err = json.Unmarshal(ttt[1], &res.Amount); err != nil {
+				return
+			}
+			// the label is only present for addresses that have one
+			if len(ttt) > 2 {
+				if err = json.Unmarshal(ttt[2], &res.Account); err != nil {
+					return
+				}
+			}
+			list = append(list, res)
 		}
 	}
 	return
@@ -704,12 +854,14 @@ type ReceivedByAccount struct {
 	// the account of the receiving addresses
 	Account string
 	// total amount received by addresses with this account
-	Amount float64
+	Amount Amount
 	// number of confirmations of the most recent transaction included
 	Confirmations uint32
 }
 
 // ListReceivedByAccount Returns an slice of AccountRecieved:
+//
+// Deprecated: Bitcoin Core 0.18 removed accounts, use ListReceivedByLabel instead.
 func (b *Bitcoind) ListReceivedByAccount(minConf uint32, includeEmpty bool) (list []ReceivedByAccount, err error) {
 	r, err := b.client.call("listreceivedbyaccount", []interface{}{minConf, includeEmpty})
 	if err = handleError(err, &r); err != nil {
@@ -726,7 +878,7 @@ type ReceivedByAddress struct {
 	// The corresponding account
 	Account string
 	// total amount received by addresses with this account
-	Amount float64
+	Amount Amount
 	// number of confirmations of the most recent transaction included
 	Confirmations uint32
 	// Tansactions ID
@@ -812,6 +964,8 @@ func (b *Bitcoind) LockUnspent(lock bool, outputs []UnspendableOutput) (success
 }
 
 // Move from one account in your wallet to another
+//
+// Deprecated: Bitcoin Core 0.18 removed accounts.
 func (b *Bitcoind) Move(formAccount, toAccount string, amount float64, minconf uint32, comment string) (success bool, err error) {
 	r, err := b.client.call("move", []interface{}{formAccount, toAccount, amount, minconf, comment})
 	if err = handleError(err, &r); err != nil {
@@ -826,6 +980,8 @@ func (b *Bitcoind) Move(formAccount, toAccount string, amount float64, minconf u
 //
 //	amount is a real and is rounded to 8 decimal places.
 //	Will send the given amount to the given address, ensuring the account has a valid balance using [minconf] confirmations.
+//
+// Deprecated: Bitcoin Core 0.18 removed accounts, use Send instead.
 func (b *Bitcoind) SendFrom(fromAccount, toAddress string, amount float64, minconf uint32, comment, commentTo string) (txID string, err error) {
 	r, err := b.client.call("sendfrom", []interface{}{fromAccount, toAddress, amount, minconf, comment, commentTo})
 	if err = handleError(err, &r); err != nil {
@@ -836,38 +992,55 @@ func (b *Bitcoind) SendFrom(fromAccount, toAddress string, amount float64, minco
 }
 
 // SenMany send multiple times
+//
+// Deprecated: float64 loses precision, use SendManyAmount instead.
 func (b *Bitcoind) SendMany(fromAccount string, amounts map[string]float64, minconf uint32, comment string) (txID string, err error) {
-	r, err := b.client.call("sendmany", []interface{}{fromAccount, amounts, minconf, comment})
-	if err = handleError(err, &r); err != nil {
+	a, err := toAmounts(amounts)
+	if err != nil {
 		return
 	}
-	err = json.Unmarshal(r.Result, &txID)
-	return
+	return b.SendManyAmount(fromAccount, a, minconf, comment, nil, nil)
 }
 
 // SendManySubtractFeeFrom send multiple times (with fee from)
 // https://bitcoincore.org/en/doc/0.16.0/rpc/wallet/sendmany/
+//
+// Deprecated: float64 loses precision, use SendManyAmount instead.
 func (b *Bitcoind) SendManySubtractFeeFrom(fromAccount string, amounts map[string]float64, minconf uint32, comment string, feefrom []string) (txID string, err error) {
-	r, err := b.client.call("sendmany", []interface{}{fromAccount, amounts, minconf, comment, feefrom})
-	if err = handleError(err, &r); err != nil {
+	a, err := toAmounts(amounts)
+	if err != nil {
 		return
 	}
-	err = json.Unmarshal(r.Result, &txID)
-	return
+	return b.SendManyAmount(fromAccount, a, minconf, comment, feefrom, nil)
 }
 
 // SendManyReplacable send multiple times (with fee from)
 // https://bitcoincore.org/en/doc/0.16.0/rpc/wallet/sendmany/
+//
+// Deprecated: float64 loses precision, use SendManyAmount instead.
 func (b *Bitcoind) SendManyReplaceable(fromAccount string, amounts map[string]float64, minconf uint32, comment string, feefrom []string, replaceable *bool) (txID string, err error) {
+	a, err := toAmounts(amounts)
+	if err != nil {
+		return
+	}
+	return b.SendManyAmount(fromAccount, a, minconf, comment, feefrom, replaceable)
+}
 
-	var r rpcResponse
-
+// SendManyAmount sends [amounts] (address => amount) in a single transaction.
+// [subtractFeeFrom] and [replaceable] are optional and only sent when set.
+// https://bitcoincore.org/en/doc/0.16.0/rpc/wallet/sendmany/
+func (b *Bitcoind) SendManyAmount(fromAccount string, amounts map[string]Amount, minconf uint32, comment string, subtractFeeFrom []string, replaceable *bool) (txID string, err error) {
+	params := []interface{}{fromAccount, amounts, minconf, comment}
+	if subtractFeeFrom != nil || replaceable != nil {
+		if subtractFeeFrom == nil {
+			subtractFeeFrom = []string{}
+		}
+		params = append(params, subtractFeeFrom)
+	}
 	if replaceable != nil {
-		r, err = b.client.call("sendmany", []interface{}{fromAccount, amounts, minconf, comment, feefrom})
-	} else {
-		r, err = b.client.call("sendmany", []interface{}{fromAccount, amounts, minconf, comment, feefrom, *replaceable})
+		params = append(params, *replaceable)
 	}
-
+	r, err := b.client.call("sendmany", params)
 	if err = handleError(err, &r); err != nil {
 		return
 	}
@@ -875,8 +1048,32 @@ func (b *Bitcoind) SendManyReplaceable(fromAccount string, amounts map[string]fl
 	return
 }
 
+// toAmounts converts a float64 BTC map to an Amount map
+func toAmounts(amounts map[string]float64) (map[string]Amount, error) {
+	a := make(map[string]Amount, len(amounts))
+	for addr, v := range amounts {
+		amount, err := NewAmount(v)
+		if err != nil {
+			return nil, err
+		}
+		a[addr] = amount
+	}
+	return a, nil
+}
+
 // SendToAddress send an amount to a given address
+//
+// Deprecated: float64 loses precision, use SendToAddressAmount instead.
 func (b *Bitcoind) SendToAddress(toAddress string, amount float64, comment, commentTo string) (txID string, err error) {
+	a, err := NewAmount(amount)
+	if err != nil {
+		return
+	}
+	return b.SendToAddressAmount(toAddress, a, comment, commentTo)
+}
+
+// SendToAddressAmount send an amount to a given address
+func (b *Bitcoind) SendToAddressAmount(toAddress string, amount Amount, comment, commentTo string) (txID string, err error) {
 	r, err := b.client.call("sendtoaddress", []interface{}{toAddress, amount, comment, commentTo})
 	if err = handleError(err, &r); err != nil {
 		return
@@ -886,6 +1083,8 @@ func (b *Bitcoind) SendToAddress(toAddress string, amount float64, comment, comm
 }
 
 // SetAccount sets the account associated with the given address
+//
+// Deprecated: Bitcoin Core 0.18 removed accounts, use SetLabel instead.
 func (b *Bitcoind) SetAccount(address, account string) error {
 	r, err := b.client.call("setaccount", []interface{}{address, account})
 	return handleError(err, &r)
@@ -893,13 +1092,26 @@ func (b *Bitcoind) SetAccount(address, account string) error {
 
 // SetGenerate turns generation on or off.
 // Generation is limited to [genproclimit] processors, -1 is unlimited.
+//
+// Deprecated: Bitcoin Core 0.13 removed setgenerate, use generatetoaddress instead.
 func (b *Bitcoind) SetGenerate(generate bool, genProcLimit int32) error {
 	r, err := b.client.call("setgenerate", []interface{}{generate, genProcLimit})
 	return handleError(err, &r)
 }
 
 // SetTxFee set the transaction fee per kB
+//
+// Deprecated: float64 loses precision, use SetTxFeeAmount instead.
 func (b *Bitcoind) SetTxFee(amount float64) error {
+	a, err := NewAmount(amount)
+	if err != nil {
+		return err
+	}
+	return b.SetTxFeeAmount(a)
+}
+
+// SetTxFeeAmount set the transaction fee per kB
+func (b *Bitcoind) SetTxFeeAmount(amount Amount) error {
 	r, err := b.client.call("settxfee", []interface{}{amount})
 	return handleError(err, &r)
 }
@@ -981,7 +1193,7 @@ const (
 // EstimateSmartFeeResult result for call estimatesmartfee
 // https://bitcoincore.org/en/doc/0.16.0/rpc/util/estimatesmartfee/
 type EstimateSmartFeeResult struct {
-	FeeRate float64  `json:"feerate"`
+	FeeRate Amount   `json:"feerate"`
 	Errors  []string `json:"errors"`
 	Blocks  int      `json:"blocks"`
 }
@@ -1022,6 +1234,27 @@ func (b *Bitcoind) SubmitBlock(block string, dummy string) (res string, err erro
 	return
 }
 
+// SubmitBlockMsg serializes and submits block. It returns a
+// *BlockRejectedError when bitcoind does not accept it.
+func (b *Bitcoind) SubmitBlockMsg(block *wire.MsgBlock) error {
+	var buf bytes.Buffer
+	if err := block.Serialize(&buf); err != nil {
+		return err
+	}
+	r, err := b.client.call("submitblock", []interface{}{hex.EncodeToString(buf.Bytes())})
+	if err = handleError(err, &r); err != nil {
+		return err
+	}
+	var reason *string
+	if err = json.Unmarshal(r.Result, &reason); err != nil {
+		return err
+	}
+	if reason != nil {
+		return &BlockRejectedError{Hash: block.BlockHash().String(), Reason: *reason}
+	}
+	return nil
+}
+
 func (b *Bitcoind) WalletProcessPsbt(psbt string, sign bool, signHashType string, bip32derivs bool, finalize bool) (*btcjson.WalletProcessPsbtResult, error) {
 	r, err := b.client.call("walletprocesspsbt", []interface{}{psbt, sign, signHashType, bip32derivs, finalize})
 	if err = handleError(err, &r); err != nil {
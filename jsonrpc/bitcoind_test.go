@@ -2,6 +2,7 @@ package jsonrpc
 
 import (
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -1125,7 +1126,7 @@ var _ = Describe("Bitcoind", func() {
 						}},
 					Vout: []Vout{
 						{
-							Value: 0.0101,
+							Value: Amount(1010000),
 							N:     0,
 							ScriptPubKey: ScriptPubKey{
 								Asm:       "OP_DUP OP_HASH160 e5344f52ecc92c279028a851c9d8ed57bb5dfc60 OP_EQUALVERIFY OP_CHECKSIG",
@@ -1136,7 +1137,7 @@ var _ = Describe("Bitcoind", func() {
 							},
 						},
 						{
-							Value: 0.01492249,
+							Value: Amount(1492249),
 							N:     1,
 							ScriptPubKey: ScriptPubKey{
 								Asm:       "OP_DUP OP_HASH160 3399e4655281f5dbb3e157930c724dd3e748a420 OP_EQUALVERIFY OP_CHECKSIG",
@@ -1218,7 +1219,7 @@ var _ = Describe("Bitcoind", func() {
 
 			It("should return Transaction", func() {
 				Expect(transaction).Should(Equal(Transaction{
-					Amount:          0.0001,
+					Amount:          Amount(10000),
 					Account:         "",
 					Address:         "",
 					Category:        "",
@@ -1236,7 +1237,7 @@ var _ = Describe("Bitcoind", func() {
 							Account:  "tests",
 							Address:  "1Pyizp4HK7Bfz7CdbSwHHtprk7Ghumhxmy",
 							Category: "receive",
-							Amount:   0.0001,
+							Amount:   Amount(10000),
 							Fee:      0,
 						},
 					},
@@ -1264,7 +1265,7 @@ var _ = Describe("Bitcoind", func() {
 
 			It("should return Transaction", func() {
 				Expect(transaction).Should(Equal(Transaction{
-					Amount:          0.0001,
+					Amount:          Amount(10000),
 					Account:         "",
 					Address:         "",
 					Category:        "",
@@ -1282,7 +1283,7 @@ var _ = Describe("Bitcoind", func() {
 							Account:  "tests",
 							Address:  "1Pyizp4HK7Bfz7CdbSwHHtprk7Ghumhxmy",
 							Category: "receive",
-							Amount:   0.0001,
+							Amount:   Amount(10000),
 							Fee:      0,
 							Label:    "some-detail",
 						},
@@ -1313,7 +1314,7 @@ var _ = Describe("Bitcoind", func() {
 				Expect(uTxOut).Should(Equal(UTransactionOut{
 					Bestblock:     "00000000000000005fc5487bb67b58573eef3ba369972f6acfc5240cf375878f",
 					Confirmations: 7,
-					Value:         0.0001,
+					Value:         Amount(10000),
 					ScriptPubKey: ScriptPubKey{
 						Asm:       "OP_DUP OP_HASH160 fc0d1e43cea1c5df928971f8add5d67ce4313003 OP_EQUALVERIFY OP_CHECKSIG",
 						Hex:       "76a914fc0d1e43cea1c5df928971f8add5d67ce431300388ac",
@@ -1352,7 +1353,7 @@ var _ = Describe("Bitcoind", func() {
 					TxOuts:          1.1028067e+07,
 					BytesSerialized: 3.82233349e+08,
 					HashSerialized:  "6aa4a70a010a7ac8e41e335007ee2f7cfb81db2bd1093bc27663aed55e6fc001",
-					TotalAmount:     1.279763979102867e+07,
+					TotalAmount:     Amount(1279763979102867),
 				}))
 			})
 		})
@@ -1485,12 +1486,12 @@ var _ = Describe("Bitcoind", func() {
 				Expect(list).Should(Equal([]ListAddressResult{
 					{
 						Address: "1Pyizp4HK7Bfz7CdbSwHHtprk7Ghumhxmy",
-						Amount:  0.0002,
+						Amount:  Amount(20000),
 						Account: "tests",
 					},
 					{
 						Address: "114fREEjA8XZUypygprUSbrynsUrr4TKjz",
-						Amount:  0.0001,
+						Amount:  Amount(10000),
 						Account: "test2",
 					},
 					{
@@ -1526,7 +1527,7 @@ var _ = Describe("Bitcoind", func() {
 						Confirmations: 0,
 					}, {
 						Account:       "tests",
-						Amount:        0.0002,
+						Amount:        Amount(20000),
 						Confirmations: 12,
 					},
 				}))
@@ -1554,7 +1555,7 @@ var _ = Describe("Bitcoind", func() {
 					{
 						Address:       "1Pyizp4HK7Bfz7CdbSwHHtprk7Ghumhxmy",
 						Account:       "tests",
-						Amount:        0.00020000,
+						Amount:        Amount(20000),
 						Confirmations: 13,
 						TxIds:         []string{"a1b7093d041bc1b763ba1ad894d2bd5376b38e6c7369613684e7140e8d9f7515", "eb1c979a968f724f6114c2cce579bd2cac599c154870dae4fdd669319d332346"},
 					}, {
@@ -1587,7 +1588,7 @@ var _ = Describe("Bitcoind", func() {
 			It("sould return a slice of Transaction", func() {
 				Expect(transactions).Should(Equal([]Transaction{
 					{
-						Amount:          0.0002,
+						Amount:          Amount(20000),
 						Account:         "test2",
 						Address:         "1Bwq28f3eE1Aa3eKsc9ma2o7KX8S6PnHTK",
 						Category:        "receive",
@@ -1604,7 +1605,7 @@ var _ = Describe("Bitcoind", func() {
 						Hex:             "",
 					},
 					{
-						Amount:          0.0001,
+						Amount:          Amount(10000),
 						Account:         "test2",
 						Address:         "114fREEjA8XZUypygprUSbrynsUrr4TKjz",
 						Category:        "receive",
@@ -1643,7 +1644,7 @@ var _ = Describe("Bitcoind", func() {
 			It("should return a slice of transactions", func() {
 				Expect(transactions).Should(Equal([]Transaction{
 					{
-						Amount:          0.0001,
+						Amount:          Amount(10000),
 						Account:         "tests",
 						Address:         "1Pyizp4HK7Bfz7CdbSwHHtprk7Ghumhxmy",
 						Category:        "receive",
@@ -1660,7 +1661,7 @@ var _ = Describe("Bitcoind", func() {
 						Hex:             "",
 					},
 					{
-						Amount:          0.0001,
+						Amount:          Amount(10000),
 						Account:         "tests",
 						Address:         "1Pyizp4HK7Bfz7CdbSwHHtprk7Ghumhxmy",
 						Category:        "receive",
@@ -1699,7 +1700,7 @@ var _ = Describe("Bitcoind", func() {
 			It("should return a transaction ID ", func() {
 				Expect(transactions).Should(Equal([]Transaction{
 					{
-						Amount:          0.0001,
+						Amount:          Amount(10000),
 						Account:         "test2",
 						Address:         "114fREEjA8XZUypygprUSbrynsUrr4TKjz",
 						Category:        "",
@@ -1716,7 +1717,7 @@ var _ = Describe("Bitcoind", func() {
 						Hex:             "",
 					},
 					{
-						Amount:          0.0001,
+						Amount:          Amount(10000),
 						Account:         "tests",
 						Address:         "1Pyizp4HK7Bfz7CdbSwHHtprk7Ghumhxmy",
 						Category:        "",
@@ -1854,6 +1855,33 @@ var _ = Describe("Bitcoind", func() {
 		})
 	})
 
+	Describe("Testing SendManyAmount", func() {
+		Context("when success", func() {
+			var body []byte
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = ioutil.ReadAll(r.Body)
+				fmt.Fprintln(w, `{"result":"ddb9d58a175de0173d6f2d16c5159f3bc747baf3c1af5926d916b185da5b6882","error":null,"id":1400852951961136429}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			replaceable := true
+			txID, err := bitcoindClient.SendManyAmount("", map[string]Amount{"1HgpsmxV52eAjDcoNpVGpYEhGfgN7mM1JB": 10001}, 1, "", nil, &replaceable)
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should send exact amounts and the optional params", func() {
+				Expect(string(body)).To(ContainSubstring(`"params":["",{"1HgpsmxV52eAjDcoNpVGpYEhGfgN7mM1JB":0.00010001},1,"",[],true]`))
+			})
+			It("should return a transaction ID ", func() {
+				Expect(txID).Should(Equal("ddb9d58a175de0173d6f2d16c5159f3bc747baf3c1af5926d916b185da5b6882"))
+			})
+		})
+	})
+
 	Describe("Testing SendToAddress", func() {
 		Context("when success", func() {
 			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
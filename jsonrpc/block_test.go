@@ -0,0 +1,67 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Blocks", func() {
+	Describe("getblock decoded", func() {
+		Context("when success", func() {
+			genesis := chaincfg.RegressionNetParams.GenesisBlock
+			var buf bytes.Buffer
+			genesis.Serialize(&buf)
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"result":"%s","error":null,"id":1400432805294160077}`, hex.EncodeToString(buf.Bytes()))
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			block, err := bitcoindClient.GetBlockMsg(genesis.BlockHash().String())
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should decode the block", func() {
+				Expect(block.BlockHash()).To(Equal(genesis.BlockHash()))
+				Expect(block.Transactions).To(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("getblock verbose 3", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":{"hash":"0f91","height":102,"tx":[{"txid":"a1b2","vin":[{"txid":"c3d4","vout":0,"scriptSig":{"asm":"","hex":""},"txinwitness":["3044","02"],"prevout":{"generated":true,"height":1,"value":50.00000000,"scriptPubKey":{"asm":"","hex":"0014","type":"witness_v0_keyhash","address":"bcrt1qsrc"}},"sequence":4294967293}],"vout":[{"value":49.99999000,"n":0,"scriptPubKey":{"asm":"","hex":"0014","type":"witness_v0_keyhash","address":"bcrt1qdst"}}],"fee":0.00001000}]},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			block, err := bitcoindClient.GetBlockV3("0f91")
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return prevouts", func() {
+				prevout := block.Tx[0].Vin[0].Prevout
+				Expect(prevout).NotTo(BeNil())
+				Expect(prevout.Value).To(Equal(Amount(5000000000)))
+				Expect(prevout.ScriptPubKey.Address).To(Equal("bcrt1qsrc"))
+			})
+			It("should return the fee", func() {
+				Expect(*block.Tx[0].Fee).To(Equal(Amount(1000)))
+			})
+		})
+	})
+})
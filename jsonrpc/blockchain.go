@@ -1,5 +1,10 @@
 package jsonrpc
 
+import (
+	"encoding/hex"
+	"encoding/json"
+)
+
 // Represents a block
 type Block struct {
 	// The block hash
@@ -51,6 +56,13 @@ type BlockV2 struct {
 	Tx []RawTransaction `json:"tx"`
 }
 
+// Represents a verbose 3 block, inputs of its transactions include the
+// output they spend (Vin.Prevout)
+type BlockV3 struct {
+	Block
+	Tx []RawTransaction `json:"tx"`
+}
+
 // An Info represent a response to getmininginfo
 type Info struct {
 	// The server version
@@ -63,7 +75,7 @@ type Info struct {
 	Walletversion uint32 `json:"walletversion"`
 
 	// The total bitcoin balance of the wallet
-	Balance float64 `json:"balance"`
+	Balance Amount `json:"balance"`
 
 	// The current number of blocks processed in the server
 	Blocks uint32 `json:"blocks"`
@@ -93,10 +105,10 @@ type Info struct {
 	UnlockedUntil int64 `json:"unlocked_until,omitempty"`
 
 	// the transaction fee set in btc/kb
-	Paytxfee float64 `json:"paytxfee"`
+	Paytxfee Amount `json:"paytxfee"`
 
 	// Minimum relay fee for non-free transactions in btc/kb
-	Relayfee float64 `json:"relayfee"`
+	Relayfee Amount `json:"relayfee"`
 
 	//  Any error messages
 	Errors string `json:"errors"`
@@ -200,9 +212,27 @@ type Vin struct {
 	Txid      string    `json:"txid"`
 	Vout      int       `json:"vout"`
 	ScriptSig ScriptSig `json:"scriptSig"`
+	Witness   []string  `json:"txinwitness,omitempty"`
+	Prevout   *Prevout  `json:"prevout,omitempty"`
 	Sequence  uint32    `json:"sequence"`
 }
 
+// Prevout represents the output spent by an input, only returned by
+// verbose 3 getblock
+type Prevout struct {
+	// Coinbase or not
+	Generated bool `json:"generated"`
+
+	// The height of the prevout
+	Height int64 `json:"height"`
+
+	// The value of the prevout
+	Value Amount `json:"value"`
+
+	// The script of the prevout
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+}
+
 type ScriptPubKey struct {
 	Asm       string   `json:"asm"`
 	Hex       string   `json:"hex"`
@@ -214,7 +244,7 @@ type ScriptPubKey struct {
 
 // Vout represent an OUT value
 type Vout struct {
-	Value        float64      `json:"value"`
+	Value        Amount       `json:"value"`
 	N            int          `json:"n"`
 	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
 }
@@ -223,6 +253,10 @@ type Vout struct {
 type RawTransaction struct {
 	Hex           string `json:"hex"`
 	Txid          string `json:"txid"`
+	Hash          string `json:"hash,omitempty"`
+	Size          uint32 `json:"size,omitempty"`
+	Vsize         uint32 `json:"vsize,omitempty"`
+	Weight        uint32 `json:"weight,omitempty"`
 	Version       uint32 `json:"version"`
 	LockTime      uint32 `json:"locktime"`
 	Vin           []Vin  `json:"vin"`
@@ -231,25 +265,27 @@ type RawTransaction struct {
 	Confirmations uint64 `json:"confirmations,omitempty"`
 	Time          int64  `json:"time,omitempty"`
 	Blocktime     int64  `json:"blocktime,omitempty"`
+	// The transaction fee, only returned by verbose 2 and 3 getblock when undo data is available
+	Fee *Amount `json:"fee,omitempty"`
 }
 
 // TransactionDetails represents details about a transaction
 type TransactionDetails struct {
-	Account  string  `json:"account"`
-	Address  string  `json:"address,omitempty"`
-	Category string  `json:"category"`
-	Amount   float64 `json:"amount"`
-	Fee      float64 `json:"fee,omitempty"`
-	Label    string  `json:"label,omitempty"`
+	Account  string `json:"account"`
+	Address  string `json:"address,omitempty"`
+	Category string `json:"category"`
+	Amount   Amount `json:"amount"`
+	Fee      Amount `json:"fee,omitempty"`
+	Label    string `json:"label,omitempty"`
 }
 
 // Transaction represents a transaction
 type Transaction struct {
-	Amount          float64              `json:"amount"`
+	Amount          Amount               `json:"amount"`
 	Account         string               `json:"account,omitempty"`
 	Address         string               `json:"address,omitempty"`
 	Category        string               `json:"category,omitempty"`
-	Fee             float64              `json:"fee,omitempty"`
+	Fee             Amount               `json:"fee,omitempty"`
 	Confirmations   int64                `json:"confirmations"`
 	BlockHash       string               `json:"blockhash"`
 	BlockIndex      int64                `json:"blockindex"`
@@ -266,7 +302,7 @@ type Transaction struct {
 type UTransactionOut struct {
 	Bestblock     string       `json:"bestblock"`
 	Confirmations uint32       `json:"confirmations"`
-	Value         float64      `json:"value"`
+	Value         Amount       `json:"value"`
 	ScriptPubKey  ScriptPubKey `json:"scriptPubKey"`
 	Version       uint32       `json:"version"`
 	Coinbase      bool         `json:"coinbase"`
@@ -280,7 +316,7 @@ type TransactionOutSet struct {
 	TxOuts          float64 `json:"txouts"`
 	BytesSerialized float64 `json:"bytes_serialized"`
 	HashSerialized  string  `json:"hash_serialized"`
-	TotalAmount     float64 `json:"total_amount"`
+	TotalAmount     Amount  `json:"total_amount"`
 }
 
 // A Work represents a formatted hash data to work on
@@ -290,3 +326,34 @@ type Work struct {
 	Hash1    string `json:"hash1"`
 	Target   string `json:"target"`
 }
+
+// TxInput represents an input to spend, as given to RPCs funding or
+// creating transactions
+type TxInput struct {
+	// The transaction id
+	Txid string `json:"txid"`
+
+	// The output number
+	Vout uint32 `json:"vout"`
+
+	// The sequence number, nil lets the node choose
+	Sequence *uint32 `json:"sequence,omitempty"`
+}
+
+// Output represents a transaction output, as given to RPCs funding or
+// creating transactions.
+// It pays Amount to Address, or, when Address is empty, carries Data in an
+// OP_RETURN output.
+type Output struct {
+	Address string
+	Amount  Amount
+	Data    []byte
+}
+
+// MarshalJSON marshals the output as {"address": amount} or {"data": "hex"}
+func (o Output) MarshalJSON() ([]byte, error) {
+	if o.Address == "" {
+		return json.Marshal(map[string]string{"data": hex.EncodeToString(o.Data)})
+	}
+	return json.Marshal(map[string]Amount{o.Address: o.Amount})
+}
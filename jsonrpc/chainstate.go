@@ -0,0 +1,468 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// BlockStats represents a response to getblockstats call.
+// Fees are in satoshis and fee rates in sat/vB.
+// See https://bitcoincore.org/en/doc/26.0.0/rpc/blockchain/getblockstats/
+type BlockStats struct {
+	// Average fee in the block
+	AvgFee int64 `json:"avgfee"`
+
+	// Average feerate
+	AvgFeeRate int64 `json:"avgfeerate"`
+
+	// Average transaction size
+	AvgTxSize int64 `json:"avgtxsize"`
+
+	// The block hash (to check for potential reorgs)
+	BlockHash string `json:"blockhash"`
+
+	// Feerates at the 10th, 25th, 50th, 75th, and 90th percentile weight unit
+	FeeRatePercentiles []int64 `json:"feerate_percentiles"`
+
+	// The height of the block
+	Height int64 `json:"height"`
+
+	// The number of inputs (excluding coinbase)
+	Ins int64 `json:"ins"`
+
+	// Maximum fee in the block
+	MaxFee int64 `json:"maxfee"`
+
+	// Maximum feerate
+	MaxFeeRate int64 `json:"maxfeerate"`
+
+	// Maximum transaction size
+	MaxTxSize int64 `json:"maxtxsize"`
+
+	// Truncated median fee in the block
+	MedianFee int64 `json:"medianfee"`
+
+	// The block median time past
+	MedianTime int64 `json:"mediantime"`
+
+	// Truncated median transaction size
+	MedianTxSize int64 `json:"mediantxsize"`
+
+	// Minimum fee in the block
+	MinFee int64 `json:"minfee"`
+
+	// Minimum feerate
+	MinFeeRate int64 `json:"minfeerate"`
+
+	// Minimum transaction size
+	MinTxSize int64 `json:"mintxsize"`
+
+	// The number of outputs
+	Outs int64 `json:"outs"`
+
+	// The block subsidy
+	Subsidy int64 `json:"subsidy"`
+
+	// Total size of all segwit transactions
+	SwTotalSize int64 `json:"swtotal_size"`
+
+	// Total weight of all segwit transactions
+	SwTotalWeight int64 `json:"swtotal_weight"`
+
+	// The number of segwit transactions
+	SwTxs int64 `json:"swtxs"`
+
+	// The block time
+	Time int64 `json:"time"`
+
+	// Total amount in all outputs (excluding coinbase and thus reward [ie subsidy + totalfee])
+	TotalOut int64 `json:"total_out"`
+
+	// Total size of all non-coinbase transactions
+	TotalSize int64 `json:"total_size"`
+
+	// Total weight of all non-coinbase transactions
+	TotalWeight int64 `json:"total_weight"`
+
+	// The fee total
+	TotalFee int64 `json:"totalfee"`
+
+	// The number of transactions (including coinbase)
+	Txs int64 `json:"txs"`
+
+	// The increase/decrease in the number of unspent outputs (not discounting op_return and similar)
+	UtxoIncrease int64 `json:"utxo_increase"`
+
+	// The increase/decrease in size for the utxo index (not discounting op_return and similar)
+	UtxoSizeInc int64 `json:"utxo_size_inc"`
+
+	// The increase/decrease in the number of unspent outputs, not counting unspendables
+	UtxoIncreaseActual int64 `json:"utxo_increase_actual"`
+
+	// The increase/decrease in size for the utxo index, not counting unspendables
+	UtxoSizeIncActual int64 `json:"utxo_size_inc_actual"`
+}
+
+// GetBlockStats computes per block statistics for the block with the given
+// hash. stats selects the values to compute, none means all.
+func (b *Bitcoind) GetBlockStats(blockHash string, stats ...string) (BlockStats, error) {
+	return b.getBlockStats(blockHash, stats)
+}
+
+// GetBlockStatsByHeight computes per block statistics for the block at
+// height in the active chain. stats selects the values to compute, none
+// means all.
+func (b *Bitcoind) GetBlockStatsByHeight(height uint64, stats ...string) (BlockStats, error) {
+	return b.getBlockStats(height, stats)
+}
+
+func (b *Bitcoind) getBlockStats(hashOrHeight interface{}, stats []string) (blockStats BlockStats, err error) {
+	params := []interface{}{hashOrHeight}
+	if len(stats) > 0 {
+		params = append(params, stats)
+	}
+	r, err := b.client.call("getblockstats", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &blockStats)
+	return
+}
+
+// BlockFilter represents a BIP157 content filter
+type BlockFilter struct {
+	// The hex-encoded filter data
+	Filter string `json:"filter"`
+
+	// The hex-encoded filter header
+	Header string `json:"header"`
+}
+
+// GetBlockFilter returns the BIP157 content filter of type filterType for
+// the block with the given hash. filterType "" means "basic" (BIP158).
+// The node must run with -blockfilterindex.
+func (b *Bitcoind) GetBlockFilter(blockHash string, filterType string) (filter BlockFilter, err error) {
+	params := []string{blockHash}
+	if filterType != "" {
+		params = append(params, filterType)
+	}
+	r, err := b.client.call("getblockfilter", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &filter)
+	return
+}
+
+// ChainTxStats represents a response to getchaintxstats call
+type ChainTxStats struct {
+	// The timestamp for the final block in the window, expressed in UNIX epoch time
+	Time int64 `json:"time"`
+
+	// The total number of transactions in the chain up to that point
+	TxCount uint64 `json:"txcount"`
+
+	// The hash of the final block in the window
+	WindowFinalBlockHash string `json:"window_final_block_hash"`
+
+	// The height of the final block in the window
+	WindowFinalBlockHeight uint64 `json:"window_final_block_height"`
+
+	// Size of the window in number of blocks
+	WindowBlockCount uint64 `json:"window_block_count"`
+
+	// The number of transactions in the window. Only returned if WindowBlockCount is > 0
+	WindowTxCount uint64 `json:"window_tx_count,omitempty"`
+
+	// The elapsed time in the window in seconds. Only returned if WindowBlockCount is > 0
+	WindowInterval int64 `json:"window_interval,omitempty"`
+
+	// The average rate of transactions per second in the window. Only returned if WindowInterval is > 0
+	TxRate float64 `json:"txrate,omitempty"`
+}
+
+// GetChainTxStats computes statistics about the total number and rate of
+// transactions in the chain, over the nBlocks blocks ending at blockHash.
+// nBlocks 0 means one month, blockHash "" means the chain tip.
+func (b *Bitcoind) GetChainTxStats(nBlocks uint32, blockHash string) (stats ChainTxStats, err error) {
+	var params []interface{}
+	if nBlocks > 0 || blockHash != "" {
+		var n interface{}
+		if nBlocks > 0 {
+			n = nBlocks
+		}
+		params = append(params, n)
+	}
+	if blockHash != "" {
+		params = append(params, blockHash)
+	}
+	r, err := b.client.call("getchaintxstats", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &stats)
+	return
+}
+
+// GetTxOutProof returns a hex-encoded proof that txids were included in a
+// block. blockHash may be "" when the node can find the block (txindex or
+// unspent outputs).
+func (b *Bitcoind) GetTxOutProof(txids []string, blockHash string) (proof string, err error) {
+	params := []interface{}{txids}
+	if blockHash != "" {
+		params = append(params, blockHash)
+	}
+	r, err := b.client.call("gettxoutproof", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &proof)
+	return
+}
+
+// VerifyTxOutProof verifies that a proof points to transactions in a block
+// of the best chain and returns the ids of the transactions it commits to.
+func (b *Bitcoind) VerifyTxOutProof(proof string) (txids []string, err error) {
+	r, err := b.client.call("verifytxoutproof", []string{proof})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &txids)
+	return
+}
+
+// ScanObject represents an output descriptor to look for in the UTXO set.
+// Range is the [begin, end] child index range of ranged descriptors, nil
+// uses the node default (1000).
+type ScanObject struct {
+	Desc  string
+	Range *[2]uint32
+}
+
+// MarshalJSON marshals the scan object as "desc" or {"desc": desc, "range": range}
+func (o ScanObject) MarshalJSON() ([]byte, error) {
+	if o.Range == nil {
+		return json.Marshal(o.Desc)
+	}
+	return json.Marshal(struct {
+		Desc  string    `json:"desc"`
+		Range [2]uint32 `json:"range"`
+	}{o.Desc, *o.Range})
+}
+
+// ScanUnspent represents an unspent output found by scantxoutset
+type ScanUnspent struct {
+	// The transaction id
+	Txid string `json:"txid"`
+
+	// The vout value
+	Vout uint32 `json:"vout"`
+
+	// The script key
+	ScriptPubKey string `json:"scriptPubKey"`
+
+	// A specialized descriptor for the matched scriptPubKey
+	Desc string `json:"desc"`
+
+	// The total amount of the unspent output
+	Amount Amount `json:"amount"`
+
+	// Whether this is a coinbase output
+	Coinbase bool `json:"coinbase"`
+
+	// Height of the unspent transaction output
+	Height int64 `json:"height"`
+}
+
+// ScanTxOutSetResult represents the result of a scantxoutset scan
+type ScanTxOutSetResult struct {
+	// Whether the scan was completed
+	Success bool `json:"success"`
+
+	// The number of unspent transaction outputs scanned
+	TxOuts uint64 `json:"txouts"`
+
+	// The current block height (index)
+	Height int64 `json:"height"`
+
+	// The hash of the block at the tip of the chain
+	BestBlock string `json:"bestblock"`
+
+	// The unspent outputs found
+	Unspents []ScanUnspent `json:"unspents"`
+
+	// The total amount of all found unspent outputs
+	TotalAmount Amount `json:"total_amount"`
+}
+
+// ScanTxOutSet scans the UTXO set for outputs matching objects and blocks
+// until the scan is over. A scan may take minutes, it ignores the client
+// timeout: bound it with WithContext.
+func (b *Bitcoind) ScanTxOutSet(objects []ScanObject) (result ScanTxOutSetResult, err error) {
+	if objects == nil {
+		objects = []ScanObject{}
+	}
+	r, err := b.client.withTimeout(0).call("scantxoutset", []interface{}{"start", objects})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &result)
+	return
+}
+
+// ScanTxOutSetStatus returns the progress, in percent, of the scan in
+// progress. running is false when there is no scan in progress.
+func (b *Bitcoind) ScanTxOutSetStatus() (progress float64, running bool, err error) {
+	r, err := b.client.call("scantxoutset", []string{"status"})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	if string(r.Result) == "null" {
+		return
+	}
+	var res struct {
+		Progress float64 `json:"progress"`
+	}
+	err = json.Unmarshal(r.Result, &res)
+	return res.Progress, err == nil, err
+}
+
+// ScanTxOutSetAbort aborts the scan in progress. It returns false when
+// there was no scan to abort.
+func (b *Bitcoind) ScanTxOutSetAbort() (aborted bool, err error) {
+	r, err := b.client.call("scantxoutset", []string{"abort"})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &aborted)
+	return
+}
+
+// ScanTxOutSetWithProgress is ScanTxOutSet reporting the scan progress, in
+// percent, to progress every interval while the scan runs. interval must be
+// positive.
+// If the context b is bound to is canceled the scan is aborted on the node.
+func (b *Bitcoind) ScanTxOutSetWithProgress(objects []ScanObject, interval time.Duration, progress func(float64)) (result ScanTxOutSetResult, err error) {
+	if interval <= 0 {
+		err = errors.New("Bad progress interval " + interval.String())
+		return
+	}
+	ctx := b.Context()
+	done := make(chan struct{})
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if p, running, err := b.ScanTxOutSetStatus(); err == nil && running {
+					progress(p)
+				}
+			}
+		}
+	}()
+	result, err = b.ScanTxOutSet(objects)
+	close(done)
+	<-polled
+	if ctx.Err() != nil {
+		// The scan goes on without us, the abort call must outlive ctx.
+		b.WithContext(context.WithoutCancel(ctx)).ScanTxOutSetAbort()
+	}
+	return
+}
+
+// Bip9Statistics represents the signalling statistics of a BIP9 deployment
+type Bip9Statistics struct {
+	// The length in blocks of the signalling period
+	Period uint32 `json:"period"`
+
+	// The number of blocks with the version bit set required to activate the feature (only for "started" status)
+	Threshold uint32 `json:"threshold,omitempty"`
+
+	// The number of blocks elapsed since the beginning of the current period
+	Elapsed uint32 `json:"elapsed"`
+
+	// The number of blocks with the version bit set in the current period
+	Count uint32 `json:"count"`
+
+	// False if there are not enough blocks left in this period to pass activation threshold (only for "started" status)
+	Possible bool `json:"possible,omitempty"`
+}
+
+// Bip9Deployment represents the BIP9 state of a deployment
+type Bip9Deployment struct {
+	// The bit (0-28) in the block version field used to signal this softfork (only for "started" status)
+	Bit uint8 `json:"bit,omitempty"`
+
+	// The minimum median time past of a block at which the bit gains its meaning
+	StartTime int64 `json:"start_time"`
+
+	// The median time past of a block at which the deployment is considered failed if not yet locked in
+	Timeout int64 `json:"timeout"`
+
+	// Minimum height of blocks for which the rules may be enforced
+	MinActivationHeight int64 `json:"min_activation_height"`
+
+	// Status of deployment at specified block (one of "defined", "started", "locked_in", "active", "failed")
+	Status string `json:"status"`
+
+	// Height of the first block to which the status applies
+	Since int64 `json:"since"`
+
+	// Status of deployment at the next block
+	StatusNext string `json:"status_next"`
+
+	// Numeric statistics about signalling for a softfork (only for "started" and "locked_in" status)
+	Statistics *Bip9Statistics `json:"statistics,omitempty"`
+
+	// Indicates blocks that signalled with a # and blocks that did not with a - (only for "started" and "locked_in" status)
+	Signalling string `json:"signalling,omitempty"`
+}
+
+// Deployment represents a softfork deployment
+type Deployment struct {
+	// One of "buried", "bip9"
+	Type string `json:"type"`
+
+	// Height of the first block which the rules are or will be enforced (only for "buried" type, or "bip9" type with "active" status)
+	Height int64 `json:"height,omitempty"`
+
+	// True if the rules are enforced for the mempool and the next block
+	Active bool `json:"active"`
+
+	// Status of bip9 softforks (only for "bip9" type)
+	Bip9 *Bip9Deployment `json:"bip9,omitempty"`
+}
+
+// DeploymentInfo represents a response to getdeploymentinfo call
+type DeploymentInfo struct {
+	// Requested block hash (or tip)
+	Hash string `json:"hash"`
+
+	// Requested block height (or tip)
+	Height int64 `json:"height"`
+
+	// The deployments keyed by name
+	Deployments map[string]Deployment `json:"deployments"`
+}
+
+// GetDeploymentInfo returns the state of the softfork deployments at the
+// block with the given hash, "" for the chain tip.
+func (b *Bitcoind) GetDeploymentInfo(blockHash string) (info DeploymentInfo, err error) {
+	var params []string
+	if blockHash != "" {
+		params = []string{blockHash}
+	}
+	r, err := b.client.call("getdeploymentinfo", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &info)
+	return
+}
@@ -0,0 +1,137 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chain state", func() {
+	Describe("getblockstats", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":{"avgfee":1500,"feerate_percentiles":[1,2,3,4,5],"height":820000,"subsidy":625000000,"totalfee":3000000,"txs":2001},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			stats, err := bitcoindClient.GetBlockStatsByHeight(820000, "avgfee", "feerate_percentiles")
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return fees in satoshis", func() {
+				Expect(stats.AvgFee).To(Equal(int64(1500)))
+				Expect(stats.Subsidy).To(Equal(int64(625000000)))
+				Expect(stats.FeeRatePercentiles).To(Equal([]int64{1, 2, 3, 4, 5}))
+			})
+		})
+	})
+
+	Describe("scantxoutset", func() {
+		Context("when polling progress", func() {
+			var scanned []interface{}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				var req struct {
+					Params []interface{} `json:"params"`
+				}
+				json.Unmarshal(body, &req)
+				if req.Params[0] == "status" {
+					fmt.Fprintln(w, `{"result":{"progress":42},"error":null,"id":1400432805294160077}`)
+					return
+				}
+				scanned = req.Params[1].([]interface{})
+				time.Sleep(300 * time.Millisecond)
+				fmt.Fprintln(w, `{"result":{"success":true,"txouts":1000,"height":101,"bestblock":"0f91","unspents":[{"txid":"a1b2","vout":1,"scriptPubKey":"0014","desc":"addr(bcrt1q)#abcd","amount":0.5,"coinbase":false,"height":100}],"total_amount":0.5},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			var mu sync.Mutex
+			var progress []float64
+			result, err := bitcoindClient.ScanTxOutSetWithProgress([]ScanObject{
+				{Desc: "addr(bcrt1q)"},
+				{Desc: "wpkh(tpub/0/*)", Range: &[2]uint32{0, 100}},
+			}, 50*time.Millisecond, func(p float64) {
+				mu.Lock()
+				progress = append(progress, p)
+				mu.Unlock()
+			})
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should send scan objects", func() {
+				Expect(scanned).To(Equal([]interface{}{
+					"addr(bcrt1q)",
+					map[string]interface{}{"desc": "wpkh(tpub/0/*)", "range": []interface{}{0.0, 100.0}},
+				}))
+			})
+			It("should report progress", func() {
+				mu.Lock()
+				defer mu.Unlock()
+				Expect(progress).NotTo(BeEmpty())
+				Expect(progress[0]).To(Equal(42.0))
+			})
+			It("should return unspents", func() {
+				Expect(result.TotalAmount).To(Equal(Amount(50000000)))
+				Expect(result.Unspents).To(HaveLen(1))
+			})
+		})
+		Context("when the context is canceled", func() {
+			var mu sync.Mutex
+			var calls []interface{}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				var req struct {
+					Params []interface{} `json:"params"`
+				}
+				json.Unmarshal(body, &req)
+				mu.Lock()
+				calls = append(calls, req.Params[0])
+				mu.Unlock()
+				if req.Params[0] == "start" {
+					time.Sleep(300 * time.Millisecond)
+				}
+				fmt.Fprintln(w, `{"result":true,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err = bitcoindClient.WithContext(ctx).ScanTxOutSetWithProgress(nil, time.Hour, func(float64) {})
+			It("should error", func() {
+				Expect(err).To(HaveOccurred())
+			})
+			It("should abort the scan on the node", func() {
+				mu.Lock()
+				defer mu.Unlock()
+				Expect(calls).To(ContainElement("abort"))
+			})
+		})
+		Context("when the progress interval is not positive", func() {
+			bitcoindClient, _ := New("127.0.0.1", 1, "x", "fake", false)
+			_, err := bitcoindClient.ScanTxOutSetWithProgress(nil, 0, func(float64) {})
+			It("should error", func() {
+				Expect(err).To(MatchError("Bad progress interval 0s"))
+			})
+		})
+	})
+})
@@ -0,0 +1,51 @@
+package jsonrpc
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cookieFile holds the credentials read from a bitcoind .cookie file.
+// It is shared by all copies of a client and safe for concurrent use.
+type cookieFile struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	user    string
+	passwd  string
+}
+
+// credentials returns the cookie user & password, reading the file again if
+// it changed since the last read or if reload is set. The cookies bitcoind
+// writes all have the same size, and a restart may not change the mtime on
+// filesystems with coarse timestamps, so callers reload when the cookie is
+// rejected.
+func (cf *cookieFile) credentials(reload bool) (user, passwd string, err error) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	fi, err := os.Stat(cf.path)
+	if err != nil {
+		return
+	}
+	if !reload && cf.user != "" && fi.ModTime().Equal(cf.modTime) && fi.Size() == cf.size {
+		return cf.user, cf.passwd, nil
+	}
+	data, err := os.ReadFile(cf.path)
+	if err != nil {
+		return
+	}
+	user, passwd, ok := strings.Cut(strings.TrimSpace(string(data)), ":")
+	if !ok || user == "" {
+		err = errors.New("Bad cookie file " + cf.path)
+		return
+	}
+	cf.user, cf.passwd = user, passwd
+	cf.modTime, cf.size = fi.ModTime(), fi.Size()
+	return
+}
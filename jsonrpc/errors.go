@@ -0,0 +1,137 @@
+package jsonrpc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Bitcoin Core RPC error codes, see src/rpc/protocol.h.
+//
+// An RPCErrorCode is also an error so the codes can be used as sentinels:
+//
+//	if errors.Is(err, jsonrpc.ErrRPCInWarmup) {
+//		// retry later
+//	}
+const (
+	// Standard JSON-RPC 2.0 errors
+	ErrRPCInvalidRequest RPCErrorCode = -32600
+	ErrRPCMethodNotFound RPCErrorCode = -32601
+	ErrRPCInvalidParams  RPCErrorCode = -32602
+	ErrRPCInternal       RPCErrorCode = -32603
+	ErrRPCParse          RPCErrorCode = -32700
+
+	// General application defined errors
+	ErrRPCMisc                 RPCErrorCode = -1
+	ErrRPCType                 RPCErrorCode = -3
+	ErrRPCInvalidAddressOrKey  RPCErrorCode = -5
+	ErrRPCOutOfMemory          RPCErrorCode = -7
+	ErrRPCInvalidParameter     RPCErrorCode = -8
+	ErrRPCDatabase             RPCErrorCode = -20
+	ErrRPCDeserialization      RPCErrorCode = -22
+	ErrRPCVerify               RPCErrorCode = -25
+	ErrRPCVerifyRejected       RPCErrorCode = -26
+	ErrRPCVerifyAlreadyInChain RPCErrorCode = -27
+	ErrRPCInWarmup             RPCErrorCode = -28
+	ErrRPCMethodDeprecated     RPCErrorCode = -32
+
+	// P2P client errors
+	ErrRPCClientNotConnected        RPCErrorCode = -9
+	ErrRPCClientInInitialDownload   RPCErrorCode = -10
+	ErrRPCClientNodeAlreadyAdded    RPCErrorCode = -23
+	ErrRPCClientNodeNotAdded        RPCErrorCode = -24
+	ErrRPCClientNodeNotConnected    RPCErrorCode = -29
+	ErrRPCClientInvalidIPOrSubnet   RPCErrorCode = -30
+	ErrRPCClientP2PDisabled         RPCErrorCode = -31
+	ErrRPCClientMempoolDisabled     RPCErrorCode = -33
+	ErrRPCClientNodeCapacityReached RPCErrorCode = -34
+
+	// Wallet errors
+	ErrRPCWallet                    RPCErrorCode = -4
+	ErrRPCWalletInsufficientFunds   RPCErrorCode = -6
+	ErrRPCWalletInvalidLabelName    RPCErrorCode = -11
+	ErrRPCWalletKeypoolRanOut       RPCErrorCode = -12
+	ErrRPCWalletUnlockNeeded        RPCErrorCode = -13
+	ErrRPCWalletPassphraseIncorrect RPCErrorCode = -14
+	ErrRPCWalletWrongEncState       RPCErrorCode = -15
+	ErrRPCWalletEncryptionFailed    RPCErrorCode = -16
+	ErrRPCWalletAlreadyUnlocked     RPCErrorCode = -17
+	ErrRPCWalletNotFound            RPCErrorCode = -18
+	ErrRPCWalletNotSpecified        RPCErrorCode = -19
+	ErrRPCWalletAlreadyLoaded       RPCErrorCode = -35
+	ErrRPCWalletAlreadyExists       RPCErrorCode = -36
+)
+
+// Error returns a string describing the code. This satisfies the builtin
+// error interface.
+func (c RPCErrorCode) Error() string {
+	return fmt.Sprintf("RPC error %d", int(c))
+}
+
+// Is reports whether target is the code of e, so that
+// errors.Is(err, ErrRPCInWarmup) matches any RPCError with that code.
+func (e RPCError) Is(target error) bool {
+	code, ok := target.(RPCErrorCode)
+	return ok && code == e.Code
+}
+
+// ErrTimeout is returned when the client timeout expires before the server
+// replied.
+var ErrTimeout = errors.New("Timeout reading data from server")
+
+// TransportError reports a failure to reach the server or to read its reply.
+// The request may or may not have been executed, unless Dial is true.
+type TransportError struct {
+	// Dial is true when the connection could not be established, so the
+	// request never reached the server.
+	Dial bool
+	Err  error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// newTransportError wraps an http.Client error.
+func newTransportError(err error) *TransportError {
+	var opErr *net.OpError
+	return &TransportError{Dial: errors.As(err, &opErr) && opErr.Op == "dial", Err: err}
+}
+
+// HTTPError is returned when the server replies with an HTTP error status
+// and no JSON-RPC body, eg 401 on bad credentials.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return "Bad HTTP status from server: " + e.Status
+}
+
+// BlockRejectedError is returned by SubmitBlockMsg when bitcoind does not
+// accept a block. Reason is the BIP22 reason, eg "duplicate", "high-hash" or
+// "inconclusive" when the block does not extend the best chain.
+type BlockRejectedError struct {
+	Hash   string
+	Reason string
+}
+
+func (e *BlockRejectedError) Error() string {
+	return fmt.Sprintf("Block %s rejected: %s", e.Hash, e.Reason)
+}
+
+// IsRetryable reports whether err is worth retrying: the node is still
+// warming up or the connection to it could not be established. Both are
+// safe, the request was not executed.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrRPCInWarmup) {
+		return true
+	}
+	var tErr *TransportError
+	return errors.As(err, &tErr) && tErr.Dial
+}
@@ -0,0 +1,152 @@
+package jsonrpc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errors", func() {
+	Describe("RPC error codes", func() {
+		Context("when the transaction is rejected", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":null,"error":{"code":-26,"message":"min relay fee not met"},"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			_, err = bitcoindClient.GetBlockCount()
+			It("should match the code sentinel", func() {
+				Expect(errors.Is(err, ErrRPCVerifyRejected)).To(BeTrue())
+				Expect(errors.Is(err, ErrRPCVerifyAlreadyInChain)).To(BeFalse())
+			})
+			It("should be an RPCError", func() {
+				var rpcErr *RPCError
+				Expect(errors.As(err, &rpcErr)).To(BeTrue())
+				Expect(rpcErr.Message).To(Equal("min relay fee not met"))
+			})
+			It("should not be retryable", func() {
+				Expect(IsRetryable(err)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("transport errors", func() {
+		Context("when the connection is refused", func() {
+			ts, host, port, err := getNewTestServer(http.NotFoundHandler())
+			if err != nil {
+				log.Fatalln(err)
+			}
+			ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			_, err = bitcoindClient.GetBlockCount()
+			It("should be a dial TransportError", func() {
+				var tErr *TransportError
+				Expect(errors.As(err, &tErr)).To(BeTrue())
+				Expect(tErr.Dial).To(BeTrue())
+			})
+			It("should be retryable", func() {
+				Expect(IsRetryable(err)).To(BeTrue())
+			})
+		})
+
+		Context("when the credentials are wrong", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			_, err = bitcoindClient.GetBlockCount()
+			It("should be an HTTPError", func() {
+				var httpErr *HTTPError
+				Expect(errors.As(err, &httpErr)).To(BeTrue())
+				Expect(httpErr.StatusCode).To(Equal(http.StatusUnauthorized))
+			})
+		})
+	})
+
+	Describe("retry policy", func() {
+		policy := RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond}
+
+		Context("when the node is warming up", func() {
+			calls := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls < 3 {
+					w.WriteHeader(http.StatusInternalServerError)
+					fmt.Fprintln(w, `{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":1400432805294160077}`)
+					return
+				}
+				fmt.Fprintln(w, `{"result":42,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := NewWithOptions(host, port, WithRetry(policy))
+			count, err := bitcoindClient.GetBlockCount()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should retry until the node is ready", func() {
+				Expect(calls).To(Equal(3))
+				Expect(count).To(Equal(uint64(42)))
+			})
+		})
+
+		Context("when the error is not retryable", func() {
+			calls := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				fmt.Fprintln(w, `{"result":null,"error":{"code":-13,"message":"Please enter the wallet passphrase with walletpassphrase first."},"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			_, err = bitcoindClient.WithRetry(policy).DumpPrivKey("1KU5DX7jKECLxh1nYhmQ7CahY7GMNMVLP3")
+			It("should return the RPC error", func() {
+				Expect(errors.Is(err, ErrRPCWalletUnlockNeeded)).To(BeTrue())
+			})
+			It("should not retry", func() {
+				Expect(calls).To(Equal(1))
+			})
+		})
+
+		Context("when retries are exhausted", func() {
+			calls := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				fmt.Fprintln(w, `{"result":null,"error":{"code":-28,"message":"Verifying blocks..."},"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			_, err = bitcoindClient.WithRetry(policy).GetBlockCount()
+			It("should return the last error", func() {
+				Expect(errors.Is(err, ErrRPCInWarmup)).To(BeTrue())
+			})
+			It("should stop after MaxRetries", func() {
+				Expect(calls).To(Equal(4))
+			})
+		})
+	})
+})
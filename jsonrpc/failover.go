@@ -0,0 +1,307 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Endpoint is one bitcoind node of a client created with NewFailover.
+type Endpoint struct {
+	Host string
+	Port int
+	// Options specific to this node, eg credentials or TLS. Timeouts,
+	// retries and middlewares are taken from the options passed to
+	// NewFailover.
+	Options []Option
+}
+
+// FailoverConfig configures how NewFailover spreads calls over endpoints.
+type FailoverConfig struct {
+	// Primary is the index of the endpoint wallet, mempool and broadcast
+	// calls are pinned to.
+	Primary int
+	// HealthCheckInterval is the delay between getblockchaininfo health
+	// checks. Defaults to 10s.
+	HealthCheckInterval time.Duration
+	// MaxBlockLag is how many blocks an endpoint may be behind the most
+	// synced one and still serve reads. Reads are balanced round robin
+	// between these endpoints.
+	MaxBlockLag uint64
+}
+
+// EndpointStatus is the last known state of an endpoint.
+type EndpointStatus struct {
+	Addr    string
+	Primary bool
+	// Healthy is false when the last health check or call failed.
+	Healthy bool
+	Blocks  uint64
+	Headers uint64
+	// Err is the error that made the endpoint unhealthy
+	Err       error
+	CheckedAt time.Time
+}
+
+// readMethods are the calls answered from the chain, or computed from their
+// parameters, that any synced endpoint can serve. Everything else is pinned
+// to the primary, including the mempool calls: each node has its own
+// mempool, and mempool_sequence numbers only make sense for one node.
+// getrawtransaction (mempool only without txindex) and gettxout (mempool
+// included by default) see the mempool too, so they are pinned as well.
+var readMethods = map[string]bool{
+	"analyzepsbt":          true,
+	"combinepsbt":          true,
+	"converttopsbt":        true,
+	"createpsbt":           true,
+	"createrawtransaction": true,
+	"decodepsbt":           true,
+	"decoderawtransaction": true,
+	"decodescript":         true,
+	"estimatesmartfee":     true,
+	"finalizepsbt":         true,
+	"getbestblockhash":     true,
+	"getblock":             true,
+	"getblockchaininfo":    true,
+	"getblockcount":        true,
+	"getblockfilter":       true,
+	"getblockhash":         true,
+	"getblockheader":       true,
+	"getblockstats":        true,
+	"getchaintips":         true,
+	"getchaintxstats":      true,
+	"getdeploymentinfo":    true,
+	"getdifficulty":        true,
+	"gettxoutproof":        true,
+	"gettxoutsetinfo":      true,
+	"joinpsbts":            true,
+	"utxoupdatepsbt":       true,
+	"verifytxoutproof":     true,
+}
+
+// NewFailover returns a bitcoind client spread over several nodes.
+// Reads go to the most synced healthy endpoints and fail over to the next
+// one on connection errors and timeouts, wallet, mempool and broadcast calls
+// always go to the primary. opts apply to every endpoint.
+func NewFailover(endpoints []Endpoint, config FailoverConfig, opts ...Option) (*Bitcoind, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("Bad call missing endpoints")
+	}
+	if config.Primary < 0 || config.Primary >= len(endpoints) {
+		return nil, errors.New("Bad call primary endpoint out of range")
+	}
+	if config.HealthCheckInterval <= 0 {
+		config.HealthCheckInterval = 10 * time.Second
+	}
+	shared := clientOptions{timeout: RPCCLIENT_TIMEOUT}
+	for _, opt := range opts {
+		if err := opt(&shared); err != nil {
+			return nil, err
+		}
+	}
+	p := &endpointPool{config: config, timeout: shared.timeout}
+	for i, e := range endpoints {
+		o := shared
+		for _, opt := range e.Options {
+			if err := opt(&o); err != nil {
+				return nil, err
+			}
+		}
+		c, err := newClientWithOptions(e.Host, e.Port, &o)
+		if err != nil {
+			return nil, err
+		}
+		c.retry = nil
+		c.middlewares = nil
+		p.endpoints = append(p.endpoints, &endpoint{
+			client: c,
+			status: EndpointStatus{Addr: c.serverAddr, Primary: i == config.Primary},
+		})
+	}
+	return &Bitcoind{client: &rpcClient{pool: p, timeout: shared.timeout, retry: shared.retry, middlewares: shared.middlewares}}, nil
+}
+
+// Endpoints returns the status of the endpoints of a client created with
+// NewFailover, nil otherwise.
+func (b *Bitcoind) Endpoints() []EndpointStatus {
+	if b.client.pool == nil {
+		return nil
+	}
+	return b.client.pool.statuses()
+}
+
+type endpoint struct {
+	client *rpcClient
+	// status is guarded by endpointPool.mu
+	status EndpointStatus
+}
+
+// endpointPool routes requests between endpoints. Health checks are run
+// lazily by requests, so a pool needs no cleanup.
+type endpointPool struct {
+	endpoints []*endpoint
+	config    FailoverConfig
+	// timeout of health checks, in seconds
+	timeout int
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	checking  bool
+	// next is the round robin counter of reads
+	next int
+}
+
+// post sends payload through the endpoints selected for it, moving to the
+// next one when an endpoint can't be reached.
+func (p *endpointPool) post(c *rpcClient, payload interface{}) (data []byte, err error) {
+	p.maybeCheck()
+	for _, e := range p.route(c, payload) {
+		ec := *e.client
+		ec.ctx = c.ctx
+		ec.wallet = c.wallet
+		ec.timeout = c.timeout
+		data, err = ec.post(payload)
+		if err == nil || !isFailover(err) || c.context().Err() != nil {
+			return
+		}
+		p.markDown(e, err)
+	}
+	return
+}
+
+// isFailover reports whether err is specific to the endpoint, so that the
+// request may succeed on another one.
+func isFailover(err error) bool {
+	var tErr *TransportError
+	var httpErr *HTTPError
+	return errors.Is(err, ErrTimeout) || errors.As(err, &tErr) || errors.As(err, &httpErr)
+}
+
+// route returns the endpoints to try for payload, in order.
+func (p *endpointPool) route(c *rpcClient, payload interface{}) []*endpoint {
+	if c.wallet != "" || !isRead(payload) {
+		return []*endpoint{p.endpoints[p.config.Primary]}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var best uint64
+	for _, e := range p.endpoints {
+		if e.status.Healthy && e.status.Blocks > best {
+			best = e.status.Blocks
+		}
+	}
+	var synced, others []*endpoint
+	for _, e := range p.endpoints {
+		if e.status.Healthy && e.status.Blocks+p.config.MaxBlockLag >= best {
+			synced = append(synced, e)
+		} else {
+			others = append(others, e)
+		}
+	}
+	// unhealthy and never checked endpoints are kept as a last resort
+	sort.SliceStable(others, func(i, j int) bool {
+		si, sj := others[i].status, others[j].status
+		if si.Healthy != sj.Healthy {
+			return si.Healthy
+		}
+		return si.Blocks > sj.Blocks
+	})
+	if len(synced) > 0 {
+		n := p.next % len(synced)
+		p.next++
+		synced = append(synced[n:len(synced):len(synced)], synced[:n]...)
+	}
+	return append(synced, others...)
+}
+
+// isRead reports whether every request of payload is a read.
+func isRead(payload interface{}) bool {
+	switch r := payload.(type) {
+	case rpcRequest:
+		return readMethods[r.Method]
+	case []rpcRequest:
+		for _, req := range r {
+			if !readMethods[req.Method] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (p *endpointPool) markDown(e *endpoint, err error) {
+	p.mu.Lock()
+	e.status.Healthy = false
+	e.status.Err = err
+	p.mu.Unlock()
+}
+
+func (p *endpointPool) statuses() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := make([]EndpointStatus, len(p.endpoints))
+	for i, e := range p.endpoints {
+		s[i] = e.status
+	}
+	return s
+}
+
+// maybeCheck runs health checks when they are due. The first check is
+// waited for so that the first request is routed with fresh status, later
+// ones run in the background.
+func (p *endpointPool) maybeCheck() {
+	p.mu.Lock()
+	first := p.checkedAt.IsZero()
+	due := !p.checking && time.Since(p.checkedAt) >= p.config.HealthCheckInterval
+	if due {
+		p.checking = true
+	}
+	p.mu.Unlock()
+	if !due {
+		return
+	}
+	if first {
+		p.check()
+	} else {
+		go p.check()
+	}
+}
+
+// check health checks all endpoints concurrently.
+func (p *endpointPool) check() {
+	timeout := time.Duration(p.timeout) * time.Second
+	if timeout == 0 {
+		timeout = RPCCLIENT_TIMEOUT * time.Second
+	}
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			var info BlockChainInfo
+			r, err := e.client.withContext(ctx).call("getblockchaininfo", nil)
+			if err = handleError(err, &r); err == nil {
+				err = json.Unmarshal(r.Result, &info)
+			}
+			p.mu.Lock()
+			e.status.Healthy = err == nil
+			e.status.Err = err
+			e.status.Blocks = info.Blocks
+			e.status.Headers = info.Headers
+			e.status.CheckedAt = time.Now()
+			p.mu.Unlock()
+		}(e)
+	}
+	wg.Wait()
+	p.mu.Lock()
+	p.checkedAt = time.Now()
+	p.checking = false
+	p.mu.Unlock()
+}
@@ -0,0 +1,116 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeNode answers getblockchaininfo with blocks and records the other
+// methods it is called with.
+type fakeNode struct {
+	mu      sync.Mutex
+	blocks  int
+	methods []string
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req rpcRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.Method == "getblockchaininfo" {
+		fmt.Fprintf(w, `{"result":{"chain":"regtest","blocks":%d,"headers":%d},"error":null,"id":%d}`+"\n", n.blocks, n.blocks, req.Id)
+		return
+	}
+	n.mu.Lock()
+	n.methods = append(n.methods, req.Method)
+	n.mu.Unlock()
+	fmt.Fprintf(w, `{"result":"%s","error":null,"id":%d}`+"\n", req.Method, req.Id)
+}
+
+func (n *fakeNode) calls() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.methods...)
+}
+
+var _ = Describe("Failover", func() {
+	Describe("routing", func() {
+		Context("when replicas are ahead of the primary", func() {
+			primary, replica1, replica2 := &fakeNode{blocks: 100}, &fakeNode{blocks: 105}, &fakeNode{blocks: 105}
+			var endpoints []Endpoint
+			for _, n := range []*fakeNode{primary, replica1, replica2} {
+				ts, host, port, err := getNewTestServer(n)
+				if err != nil {
+					log.Fatalln(err)
+				}
+				defer ts.Close()
+				endpoints = append(endpoints, Endpoint{Host: host, Port: port})
+			}
+			bitcoindClient, err := NewFailover(endpoints, FailoverConfig{}, WithBasicAuth("x", "fake"))
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			for i := 0; i < 4; i++ {
+				bitcoindClient.GetBestBlockhash()
+			}
+			bitcoindClient.GetNewAddress()
+			bitcoindClient.Wallet("w").GetBestBlockhash()
+			bitcoindClient.GetRawMempool()
+			bitcoindClient.GetRawTransaction("txid", false)
+			bitcoindClient.GetTxOut("txid", 0, true)
+			It("should balance reads between the most synced endpoints", func() {
+				Expect(replica1.calls()).To(Equal([]string{"getbestblockhash", "getbestblockhash"}))
+				Expect(replica2.calls()).To(Equal([]string{"getbestblockhash", "getbestblockhash"}))
+			})
+			It("should pin wallet and mempool calls to the primary", func() {
+				Expect(primary.calls()).To(Equal([]string{"getnewaddress", "getbestblockhash", "getrawmempool", "getrawtransaction", "gettxout"}))
+			})
+			It("should report the endpoints status", func() {
+				statuses := bitcoindClient.Endpoints()
+				Expect(statuses).To(HaveLen(3))
+				Expect(statuses[0].Primary).To(BeTrue())
+				Expect(statuses[1].Blocks).To(Equal(uint64(105)))
+				Expect(statuses[1].Healthy).To(BeTrue())
+			})
+		})
+
+		Context("when an endpoint goes down", func() {
+			primary, replica := &fakeNode{blocks: 100}, &fakeNode{blocks: 105}
+			ts1, host1, port1, err := getNewTestServer(primary)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts1.Close()
+			ts2, host2, port2, err := getNewTestServer(replica)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			bitcoindClient, _ := NewFailover([]Endpoint{{Host: host1, Port: port1}, {Host: host2, Port: port2}}, FailoverConfig{})
+			bitcoindClient.GetBestBlockhash()
+			ts2.Close()
+			hash, err := bitcoindClient.GetBestBlockhash()
+			It("should fail over to the next endpoint", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(hash).To(Equal("getbestblockhash"))
+				Expect(primary.calls()).To(Equal([]string{"getbestblockhash"}))
+			})
+			It("should mark the endpoint unhealthy", func() {
+				status := bitcoindClient.Endpoints()[1]
+				Expect(status.Healthy).To(BeFalse())
+				Expect(status.Err).To(HaveOccurred())
+			})
+		})
+
+		Context("when the primary is out of range", func() {
+			_, err := NewFailover([]Endpoint{{Host: "127.0.0.1", Port: 8332}}, FailoverConfig{Primary: 1})
+			It("error should occured", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
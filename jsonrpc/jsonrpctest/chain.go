@@ -0,0 +1,298 @@
+package jsonrpctest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satshub/go-bitcoind/jsonrpc"
+)
+
+type block struct {
+	msg    *wire.MsgBlock
+	hash   chainhash.Hash
+	height int64
+}
+
+// txEntry is a confirmed transaction
+type txEntry struct {
+	tx    *wire.MsgTx
+	block *block
+}
+
+type mempoolTx struct {
+	tx     *wire.MsgTx
+	hash   chainhash.Hash
+	time   int64
+	height int64
+	// fee is only known when every input spends a known transaction
+	fee jsonrpc.Amount
+}
+
+// Tip returns the hash and height of the best block.
+func (s *Server) Tip() (chainhash.Hash, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tip := s.tip()
+	return tip.hash, tip.height
+}
+
+// AddBlock connects b on top of the best block. Transactions of b are
+// removed from the mempool. The block is not validated.
+func (s *Server) AddBlock(b *wire.MsgBlock) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tip := s.tip(); b.Header.PrevBlock != tip.hash {
+		return fmt.Errorf("jsonrpctest: block %s does not extend the tip %s", b.BlockHash(), tip.hash)
+	}
+	s.connect(b)
+	return nil
+}
+
+// MineBlock builds a block on top of the best block with a coinbase paying
+// the subsidy to an OP_TRUE script followed by txs, and connects it. The
+// block has no proof of work.
+func (s *Server) MineBlock(txs ...*wire.MsgTx) *wire.MsgBlock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mine(txs)
+}
+
+// MineMempool mines a block with all the mempool transactions, see
+// MineBlock.
+func (s *Server) MineMempool() *wire.MsgBlock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	txs := make([]*wire.MsgTx, len(s.mempool))
+	for i, mtx := range s.mempool {
+		txs[i] = mtx.tx
+	}
+	return s.mine(txs)
+}
+
+// MineBlocks mines n empty blocks and returns their hashes.
+func (s *Server) MineBlocks(n int) []chainhash.Hash {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hashes := make([]chainhash.Hash, n)
+	for i := range hashes {
+		hashes[i] = s.mine(nil).BlockHash()
+	}
+	return hashes
+}
+
+// DisconnectTip disconnects the best block, eg to simulate a reorg, and
+// returns it. Its transactions go back to the mempool.
+func (s *Server) DisconnectTip() (*wire.MsgBlock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.blocks) == 1 {
+		return nil, errors.New("jsonrpctest: can't disconnect the genesis block")
+	}
+	tip := s.tip()
+	s.blocks = s.blocks[:len(s.blocks)-1]
+	delete(s.byHash, tip.hash)
+	for i, tx := range tip.msg.Transactions {
+		delete(s.txs, tx.TxHash())
+		if i > 0 {
+			s.accept(tx)
+		}
+	}
+	s.notify()
+	return tip.msg, nil
+}
+
+// AddMempoolTx adds tx to the mempool as if it was received from a peer.
+func (s *Server) AddMempoolTx(tx *wire.MsgTx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accept(tx)
+}
+
+// RemoveMempoolTx removes the transaction txid from the mempool, eg to
+// simulate an eviction.
+func (s *Server) RemoveMempoolTx(txid chainhash.Hash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeMempool(map[chainhash.Hash]bool{txid: true})
+}
+
+// MempoolSequence returns the mempool sequence number, incremented on every
+// mempool addition and removal like bitcoind's.
+func (s *Server) MempoolSequence() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mempoolSeq
+}
+
+func (s *Server) tip() *block {
+	return s.blocks[len(s.blocks)-1]
+}
+
+func (s *Server) connect(msg *wire.MsgBlock) {
+	b := &block{msg: msg, hash: msg.BlockHash(), height: int64(len(s.blocks))}
+	s.blocks = append(s.blocks, b)
+	s.byHash[b.hash] = b
+	mined := make(map[chainhash.Hash]bool, len(msg.Transactions))
+	for _, tx := range msg.Transactions {
+		hash := tx.TxHash()
+		s.txs[hash] = txEntry{tx: tx, block: b}
+		mined[hash] = true
+	}
+	s.removeMempool(mined)
+	s.notify()
+}
+
+func (s *Server) mine(txs []*wire.MsgTx) *wire.MsgBlock {
+	tip := s.tip()
+	height := tip.height + 1
+	sigScript, _ := txscript.NewScriptBuilder().AddInt64(height).AddOp(txscript.OP_0).Script()
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: math.MaxUint32},
+		SignatureScript:  sigScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	coinbase.AddTxOut(wire.NewTxOut(s.subsidy(height), []byte{txscript.OP_TRUE}))
+
+	msg := &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:   0x20000000,
+			PrevBlock: tip.hash,
+			Timestamp: tip.msg.Header.Timestamp.Add(time.Second),
+			Bits:      s.params.PowLimitBits,
+		},
+		Transactions: append([]*wire.MsgTx{coinbase}, txs...),
+	}
+	msg.Header.MerkleRoot = merkleRoot(msg.Transactions)
+	s.connect(msg)
+	return msg
+}
+
+func (s *Server) subsidy(height int64) int64 {
+	if s.params.SubsidyReductionInterval == 0 {
+		return 50 * jsonrpc.SatoshiPerBitcoin
+	}
+	halvings := height / int64(s.params.SubsidyReductionInterval)
+	if halvings >= 64 {
+		return 0
+	}
+	return (50 * jsonrpc.SatoshiPerBitcoin) >> uint(halvings)
+}
+
+// accept adds tx to the mempool.
+func (s *Server) accept(tx *wire.MsgTx) error {
+	hash := tx.TxHash()
+	if _, ok := s.txs[hash]; ok {
+		return rpcError(jsonrpc.ErrRPCVerifyAlreadyInChain, "Transaction already in block chain")
+	}
+	for _, mtx := range s.mempool {
+		if mtx.hash == hash {
+			return nil
+		}
+	}
+	mtx := &mempoolTx{tx: tx, hash: hash, time: time.Now().Unix(), height: s.tip().height}
+	if in, ok := s.inputValue(tx); ok {
+		var out int64
+		for _, txOut := range tx.TxOut {
+			out += txOut.Value
+		}
+		mtx.fee = jsonrpc.Amount(in - out)
+	}
+	s.mempool = append(s.mempool, mtx)
+	s.mempoolSeq++
+	s.notify()
+	return nil
+}
+
+// inputValue returns the value spent by tx when all its prevouts are known.
+func (s *Server) inputValue(tx *wire.MsgTx) (value int64, ok bool) {
+	for _, in := range tx.TxIn {
+		prev, _, found := s.findTx(in.PreviousOutPoint.Hash)
+		if !found || int(in.PreviousOutPoint.Index) >= len(prev.TxOut) {
+			return 0, false
+		}
+		value += prev.TxOut[in.PreviousOutPoint.Index].Value
+	}
+	return value, true
+}
+
+func (s *Server) removeMempool(hashes map[chainhash.Hash]bool) {
+	kept := s.mempool[:0]
+	for _, mtx := range s.mempool {
+		if hashes[mtx.hash] {
+			s.mempoolSeq++
+			continue
+		}
+		kept = append(kept, mtx)
+	}
+	if len(kept) < len(s.mempool) {
+		s.notify()
+	}
+	s.mempool = kept
+}
+
+// notify wakes up the goroutines waiting for a chain or mempool change.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// findTx looks for txid in the chain then in the mempool. b is nil for
+// mempool transactions.
+func (s *Server) findTx(txid chainhash.Hash) (tx *wire.MsgTx, b *block, ok bool) {
+	if e, ok := s.txs[txid]; ok {
+		return e.tx, e.block, true
+	}
+	if mtx := s.findMempool(txid); mtx != nil {
+		return mtx.tx, nil, true
+	}
+	return nil, nil, false
+}
+
+func (s *Server) findMempool(txid chainhash.Hash) *mempoolTx {
+	for _, mtx := range s.mempool {
+		if mtx.hash == txid {
+			return mtx
+		}
+	}
+	return nil
+}
+
+// medianTime returns the median time of the 11 blocks ending at b.
+func (s *Server) medianTime(b *block) int64 {
+	var times []int64
+	for h := b.height; h >= 0 && h > b.height-11; h-- {
+		times = append(times, s.blocks[h].msg.Header.Timestamp.Unix())
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
+
+// difficulty returns the difficulty of bits relative to the network proof
+// of work limit.
+func (s *Server) difficulty(bits uint32) float64 {
+	target := blockchain.CompactToBig(bits)
+	if target.Sign() <= 0 {
+		return 0
+	}
+	d, _ := new(big.Rat).SetFrac(s.params.PowLimit, target).Float64()
+	return d
+}
+
+// merkleRoot computes the merkle root of txs.
+func merkleRoot(txs []*wire.MsgTx) chainhash.Hash {
+	utxs := make([]*btcutil.Tx, len(txs))
+	for i, tx := range txs {
+		utxs[i] = btcutil.NewTx(tx)
+	}
+	return blockchain.CalcMerkleRoot(utxs, false)
+}
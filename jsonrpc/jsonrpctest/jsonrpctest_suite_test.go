@@ -0,0 +1,13 @@
+package jsonrpctest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestJsonrpctest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Jsonrpctest Suite")
+}
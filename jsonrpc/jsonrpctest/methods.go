@@ -0,0 +1,605 @@
+package jsonrpctest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satshub/go-bitcoind/jsonrpc"
+)
+
+// builtinFunc implements a method, it is called with the server locked.
+type builtinFunc func(s *Server, params []json.RawMessage) (interface{}, error)
+
+var builtins = map[string]builtinFunc{
+	"decoderawtransaction": (*Server).decodeRawTransaction,
+	"getbalance":           (*Server).getBalance,
+	"getbalances":          (*Server).getBalances,
+	"getbestblockhash":     (*Server).getBestBlockHash,
+	"getblock":             (*Server).getBlock,
+	"getblockchaininfo":    (*Server).getBlockchainInfo,
+	"getblockcount":        (*Server).getBlockCount,
+	"getblockhash":         (*Server).getBlockHash,
+	"getblockheader":       (*Server).getBlockHeader,
+	"getblocktemplate":     (*Server).getBlockTemplate,
+	"getmempoolentry":      (*Server).getMempoolEntry,
+	"getmempoolinfo":       (*Server).getMempoolInfo,
+	"getnewaddress":        (*Server).getNewAddress,
+	"getrawchangeaddress":  (*Server).getNewAddress,
+	"getrawmempool":        (*Server).getRawMempool,
+	"getrawtransaction":    (*Server).getRawTransaction,
+	"listlockunspent":      (*Server).listLockUnspent,
+	"listunspent":          (*Server).listUnspent,
+	"lockunspent":          (*Server).lockUnspent,
+	"ping":                 (*Server).ping,
+	"sendrawtransaction":   (*Server).sendRawTransaction,
+	"submitblock":          (*Server).submitBlock,
+}
+
+func (s *Server) builtin(method string, raw json.RawMessage) (interface{}, error) {
+	f, ok := builtins[method]
+	if !ok {
+		return nil, rpcError(jsonrpc.ErrRPCMethodNotFound, "Method not found")
+	}
+	var params []json.RawMessage
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && string(trimmed) != "null" {
+		if trimmed[0] != '[' {
+			return nil, rpcError(jsonrpc.ErrRPCInvalidParams, "jsonrpctest only supports positional params for built-in methods, use Handle")
+		}
+		if err := json.Unmarshal(trimmed, &params); err != nil {
+			return nil, rpcError(jsonrpc.ErrRPCParse, "Parse error")
+		}
+	}
+	if method == "getblocktemplate" {
+		s.waitLongPoll(params)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return f(s, params)
+}
+
+// arg unmarshals params[i] into v, leaving v untouched when the param is
+// missing or null.
+func arg(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) || string(params[i]) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return rpcError(jsonrpc.ErrRPCType, "JSON value of param %d is not of expected type", i)
+	}
+	return nil
+}
+
+// verbosity unmarshals a verbosity param that clients send either as a
+// number or as a bool.
+func verbosity(params []json.RawMessage, i int, def int) (int, error) {
+	var b bool
+	if i < len(params) && json.Unmarshal(params[i], &b) == nil {
+		if b {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	v := def
+	err := arg(params, i, &v)
+	return v, err
+}
+
+func hashArg(params []json.RawMessage, i int) (hash chainhash.Hash, err error) {
+	var str string
+	if err = arg(params, i, &str); err != nil {
+		return
+	}
+	h, err := chainhash.NewHashFromStr(str)
+	if err != nil || len(str) != 2*chainhash.HashSize {
+		err = rpcError(jsonrpc.ErrRPCInvalidParameter, "param %d must be of length 64 (not %d, for '%s')", i, len(str), str)
+		return
+	}
+	return *h, nil
+}
+
+func txArg(params []json.RawMessage, i int) (*wire.MsgTx, error) {
+	var str string
+	if err := arg(params, i, &str); err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(str)
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err == nil {
+		err = tx.Deserialize(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, rpcError(jsonrpc.ErrRPCDeserialization, "TX decode failed")
+	}
+	return tx, nil
+}
+
+func (s *Server) ping(params []json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+func (s *Server) getBlockCount(params []json.RawMessage) (interface{}, error) {
+	return s.tip().height, nil
+}
+
+func (s *Server) getBestBlockHash(params []json.RawMessage) (interface{}, error) {
+	return s.tip().hash.String(), nil
+}
+
+func (s *Server) getBlockHash(params []json.RawMessage) (interface{}, error) {
+	height := int64(-1)
+	if err := arg(params, 0, &height); err != nil {
+		return nil, err
+	}
+	if height < 0 || height >= int64(len(s.blocks)) {
+		return nil, rpcError(jsonrpc.ErrRPCInvalidParameter, "Block height out of range")
+	}
+	return s.blocks[height].hash.String(), nil
+}
+
+func (s *Server) blockArg(params []json.RawMessage) (*block, error) {
+	hash, err := hashArg(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := s.byHash[hash]
+	if !ok {
+		return nil, rpcError(jsonrpc.ErrRPCInvalidAddressOrKey, "Block not found")
+	}
+	return b, nil
+}
+
+// blockHeader is the verbose getblockheader result
+type blockHeader struct {
+	Hash              string  `json:"hash"`
+	Confirmations     int64   `json:"confirmations"`
+	Height            int64   `json:"height"`
+	Version           int32   `json:"version"`
+	VersionHex        string  `json:"versionHex"`
+	Merkleroot        string  `json:"merkleroot"`
+	Time              int64   `json:"time"`
+	Mediantime        int64   `json:"mediantime"`
+	Nonce             uint32  `json:"nonce"`
+	Bits              string  `json:"bits"`
+	Difficulty        float64 `json:"difficulty"`
+	NTx               int     `json:"nTx"`
+	Previousblockhash string  `json:"previousblockhash,omitempty"`
+	Nextblockhash     string  `json:"nextblockhash,omitempty"`
+}
+
+func (s *Server) getBlockHeader(params []json.RawMessage) (interface{}, error) {
+	b, err := s.blockArg(params)
+	if err != nil {
+		return nil, err
+	}
+	verbose := true
+	if err = arg(params, 1, &verbose); err != nil {
+		return nil, err
+	}
+	if !verbose {
+		var buf bytes.Buffer
+		b.msg.Header.Serialize(&buf)
+		return hex.EncodeToString(buf.Bytes()), nil
+	}
+	h := b.msg.Header
+	header := blockHeader{
+		Hash:          b.hash.String(),
+		Confirmations: s.tip().height - b.height + 1,
+		Height:        b.height,
+		Version:       h.Version,
+		VersionHex:    fmt.Sprintf("%08x", uint32(h.Version)),
+		Merkleroot:    h.MerkleRoot.String(),
+		Time:          h.Timestamp.Unix(),
+		Mediantime:    s.medianTime(b),
+		Nonce:         h.Nonce,
+		Bits:          fmt.Sprintf("%08x", h.Bits),
+		Difficulty:    s.difficulty(h.Bits),
+		NTx:           len(b.msg.Transactions),
+	}
+	if b.height > 0 {
+		header.Previousblockhash = h.PrevBlock.String()
+	}
+	if b.height < s.tip().height {
+		header.Nextblockhash = s.blocks[b.height+1].hash.String()
+	}
+	return header, nil
+}
+
+func (s *Server) getBlock(params []json.RawMessage) (interface{}, error) {
+	b, err := s.blockArg(params)
+	if err != nil {
+		return nil, err
+	}
+	v, err := verbosity(params, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if v == 0 {
+		var buf bytes.Buffer
+		b.msg.Serialize(&buf)
+		return hex.EncodeToString(buf.Bytes()), nil
+	}
+	h := b.msg.Header
+	res := jsonrpc.Block{
+		Hash:          b.hash.String(),
+		Confirmations: uint64(s.tip().height - b.height + 1),
+		Size:          uint64(b.msg.SerializeSize()),
+		Height:        uint64(b.height),
+		Version:       uint32(h.Version),
+		Merkleroot:    h.MerkleRoot.String(),
+		Time:          h.Timestamp.Unix(),
+		Nonce:         uint64(h.Nonce),
+		Bits:          fmt.Sprintf("%08x", h.Bits),
+		Difficulty:    s.difficulty(h.Bits),
+	}
+	if b.height > 0 {
+		res.Previousblockhash = h.PrevBlock.String()
+	}
+	if b.height < s.tip().height {
+		res.Nextblockhash = s.blocks[b.height+1].hash.String()
+	}
+	if v == 1 {
+		for _, tx := range b.msg.Transactions {
+			res.Tx = append(res.Tx, tx.TxHash().String())
+		}
+		return res, nil
+	}
+	res2 := jsonrpc.BlockV2{Block: res}
+	for _, tx := range b.msg.Transactions {
+		rt := s.rawTransaction(tx, nil)
+		res2.Tx = append(res2.Tx, rt)
+	}
+	return res2, nil
+}
+
+// chainNames maps btcd network names to bitcoind's
+var chainNames = map[string]string{
+	"mainnet":  "main",
+	"testnet3": "test",
+}
+
+func (s *Server) getBlockchainInfo(params []json.RawMessage) (interface{}, error) {
+	chain := s.params.Name
+	if name, ok := chainNames[chain]; ok {
+		chain = name
+	}
+	tip := s.tip()
+	return map[string]interface{}{
+		"chain":                chain,
+		"blocks":               tip.height,
+		"headers":              tip.height,
+		"bestblockhash":        tip.hash.String(),
+		"difficulty":           s.difficulty(tip.msg.Header.Bits),
+		"time":                 tip.msg.Header.Timestamp.Unix(),
+		"mediantime":           s.medianTime(tip),
+		"verificationprogress": 1,
+		"initialblockdownload": false,
+		"pruned":               false,
+		"warnings":             "",
+	}, nil
+}
+
+// rawTransaction returns the verbose representation of tx, with the block
+// fields set when b is not nil.
+func (s *Server) rawTransaction(tx *wire.MsgTx, b *block) jsonrpc.RawTransaction {
+	var buf bytes.Buffer
+	tx.Serialize(&buf)
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+	rt := jsonrpc.RawTransaction{
+		Hex:      hex.EncodeToString(buf.Bytes()),
+		Txid:     tx.TxHash().String(),
+		Hash:     tx.WitnessHash().String(),
+		Size:     uint32(tx.SerializeSize()),
+		Vsize:    uint32((weight + 3) / 4),
+		Weight:   uint32(weight),
+		Version:  uint32(tx.Version),
+		LockTime: tx.LockTime,
+		Vin:      []jsonrpc.Vin{},
+		Vout:     []jsonrpc.Vout{},
+	}
+	coinbase := isCoinbase(tx)
+	for _, in := range tx.TxIn {
+		vin := jsonrpc.Vin{Sequence: in.Sequence}
+		for _, w := range in.Witness {
+			vin.Witness = append(vin.Witness, hex.EncodeToString(w))
+		}
+		if coinbase {
+			vin.Coinbase = hex.EncodeToString(in.SignatureScript)
+		} else {
+			asm, _ := txscript.DisasmString(in.SignatureScript)
+			vin.Txid = in.PreviousOutPoint.Hash.String()
+			vin.Vout = int(in.PreviousOutPoint.Index)
+			vin.ScriptSig = jsonrpc.ScriptSig{Asm: asm, Hex: hex.EncodeToString(in.SignatureScript)}
+		}
+		rt.Vin = append(rt.Vin, vin)
+	}
+	for i, out := range tx.TxOut {
+		rt.Vout = append(rt.Vout, jsonrpc.Vout{
+			Value:        jsonrpc.Amount(out.Value),
+			N:            i,
+			ScriptPubKey: s.scriptPubKey(out.PkScript),
+		})
+	}
+	if b != nil {
+		rt.BlockHash = b.hash.String()
+		rt.Confirmations = uint64(s.tip().height - b.height + 1)
+		rt.Time = b.msg.Header.Timestamp.Unix()
+		rt.Blocktime = rt.Time
+	}
+	return rt
+}
+
+func (s *Server) scriptPubKey(pkScript []byte) jsonrpc.ScriptPubKey {
+	asm, _ := txscript.DisasmString(pkScript)
+	class, addrs, _, _ := txscript.ExtractPkScriptAddrs(pkScript, s.params)
+	spk := jsonrpc.ScriptPubKey{Asm: asm, Hex: hex.EncodeToString(pkScript), Type: class.String()}
+	if len(addrs) == 1 && class != txscript.MultiSigTy && class != txscript.PubKeyTy {
+		spk.Address = addrs[0].EncodeAddress()
+	}
+	return spk
+}
+
+func isCoinbase(tx *wire.MsgTx) bool {
+	if len(tx.TxIn) != 1 {
+		return false
+	}
+	prev := tx.TxIn[0].PreviousOutPoint
+	return prev.Index == math.MaxUint32 && prev.Hash == chainhash.Hash{}
+}
+
+func (s *Server) getRawTransaction(params []json.RawMessage) (interface{}, error) {
+	txid, err := hashArg(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	v, err := verbosity(params, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	tx, b, ok := s.findTx(txid)
+	if !ok {
+		return nil, rpcError(jsonrpc.ErrRPCInvalidAddressOrKey, "No such mempool or blockchain transaction. Use gettransaction for wallet transactions.")
+	}
+	if v == 0 {
+		var buf bytes.Buffer
+		tx.Serialize(&buf)
+		return hex.EncodeToString(buf.Bytes()), nil
+	}
+	return s.rawTransaction(tx, b), nil
+}
+
+func (s *Server) decodeRawTransaction(params []json.RawMessage) (interface{}, error) {
+	tx, err := txArg(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	rt := s.rawTransaction(tx, nil)
+	rt.Hex = ""
+	return rt, nil
+}
+
+func (s *Server) sendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	tx, err := txArg(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.accept(tx); err != nil {
+		return nil, err
+	}
+	return tx.TxHash().String(), nil
+}
+
+func (s *Server) mempoolEntry(mtx *mempoolTx) jsonrpc.MempoolEntry {
+	weight := mtx.tx.SerializeSizeStripped()*3 + mtx.tx.SerializeSize()
+	vsize := uint32((weight + 3) / 4)
+	entry := jsonrpc.MempoolEntry{
+		Vsize:           vsize,
+		Weight:          uint32(weight),
+		Time:            mtx.time,
+		Height:          mtx.height,
+		DescendantCount: 1,
+		DescendantSize:  vsize,
+		AncestorCount:   1,
+		AncestorSize:    vsize,
+		Wtxid:           mtx.tx.WitnessHash().String(),
+		Fees:            jsonrpc.MempoolFees{Base: mtx.fee, Modified: mtx.fee, Ancestor: mtx.fee, Descendant: mtx.fee},
+		Depends:         []string{},
+		SpentBy:         []string{},
+	}
+	for _, in := range mtx.tx.TxIn {
+		if s.findMempool(in.PreviousOutPoint.Hash) != nil {
+			entry.Depends = append(entry.Depends, in.PreviousOutPoint.Hash.String())
+		}
+		if in.Sequence < wire.MaxTxInSequenceNum-1 {
+			entry.Bip125Replaceable = true
+		}
+	}
+	for _, other := range s.mempool {
+		for _, in := range other.tx.TxIn {
+			if in.PreviousOutPoint.Hash == mtx.hash {
+				entry.SpentBy = append(entry.SpentBy, other.hash.String())
+				break
+			}
+		}
+	}
+	return entry
+}
+
+func (s *Server) getRawMempool(params []json.RawMessage) (interface{}, error) {
+	var verbose, sequence bool
+	if err := arg(params, 0, &verbose); err != nil {
+		return nil, err
+	}
+	if err := arg(params, 1, &sequence); err != nil {
+		return nil, err
+	}
+	if verbose && sequence {
+		return nil, rpcError(jsonrpc.ErrRPCInvalidParameter, "Verbose results cannot contain mempool sequence values.")
+	}
+	if verbose {
+		entries := make(map[string]jsonrpc.MempoolEntry, len(s.mempool))
+		for _, mtx := range s.mempool {
+			entries[mtx.hash.String()] = s.mempoolEntry(mtx)
+		}
+		return entries, nil
+	}
+	txids := make([]string, len(s.mempool))
+	for i, mtx := range s.mempool {
+		txids[i] = mtx.hash.String()
+	}
+	if sequence {
+		return jsonrpc.MempoolSequence{Txids: txids, MempoolSequence: s.mempoolSeq}, nil
+	}
+	return txids, nil
+}
+
+func (s *Server) getMempoolEntry(params []json.RawMessage) (interface{}, error) {
+	txid, err := hashArg(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	mtx := s.findMempool(txid)
+	if mtx == nil {
+		return nil, rpcError(jsonrpc.ErrRPCInvalidAddressOrKey, "Transaction not in mempool")
+	}
+	return s.mempoolEntry(mtx), nil
+}
+
+func (s *Server) getMempoolInfo(params []json.RawMessage) (interface{}, error) {
+	var size int
+	var fees jsonrpc.Amount
+	for _, mtx := range s.mempool {
+		size += mtx.tx.SerializeSize()
+		fees += mtx.fee
+	}
+	return map[string]interface{}{
+		"loaded":           true,
+		"size":             len(s.mempool),
+		"bytes":            size,
+		"usage":            size,
+		"total_fee":        fees,
+		"maxmempool":       300000000,
+		"mempoolminfee":    jsonrpc.Amount(1000),
+		"minrelaytxfee":    jsonrpc.Amount(1000),
+		"unbroadcastcount": 0,
+	}, nil
+}
+
+func (s *Server) listUnspent(params []json.RawMessage) (interface{}, error) {
+	minconf, maxconf := int64(1), int64(9999999)
+	var addresses []string
+	if err := arg(params, 0, &minconf); err != nil {
+		return nil, err
+	}
+	if err := arg(params, 1, &maxconf); err != nil {
+		return nil, err
+	}
+	if err := arg(params, 2, &addresses); err != nil {
+		return nil, err
+	}
+	filter := make(map[string]bool, len(addresses))
+	for _, addr := range addresses {
+		filter[addr] = true
+	}
+	utxos := []UTXO{}
+	for _, u := range s.utxos {
+		if u.Confirmations < minconf || u.Confirmations > maxconf || s.isLocked(u) {
+			continue
+		}
+		if len(filter) > 0 && !filter[u.Address] {
+			continue
+		}
+		utxos = append(utxos, u)
+	}
+	return utxos, nil
+}
+
+func (s *Server) isLocked(u UTXO) bool {
+	hash, err := chainhash.NewHashFromStr(u.TxID)
+	return err == nil && s.locked[wire.OutPoint{Hash: *hash, Index: u.Vout}]
+}
+
+func (s *Server) getBalance(params []json.RawMessage) (interface{}, error) {
+	var minconf int64
+	if err := arg(params, 1, &minconf); err != nil {
+		return nil, err
+	}
+	var balance jsonrpc.Amount
+	for _, u := range s.utxos {
+		if u.Confirmations >= minconf {
+			balance += u.Amount
+		}
+	}
+	return balance, nil
+}
+
+func (s *Server) getBalances(params []json.RawMessage) (interface{}, error) {
+	var trusted, pending jsonrpc.Amount
+	for _, u := range s.utxos {
+		if u.Confirmations > 0 {
+			trusted += u.Amount
+		} else {
+			pending += u.Amount
+		}
+	}
+	tip := s.tip()
+	return jsonrpc.Balances{
+		Mine:               jsonrpc.BalanceDetails{Trusted: trusted, UntrustedPending: pending},
+		LastProcessedBlock: &jsonrpc.BlockRef{Hash: tip.hash.String(), Height: tip.height},
+	}, nil
+}
+
+func (s *Server) getNewAddress(params []json.RawMessage) (interface{}, error) {
+	if len(s.addresses) == 0 {
+		return nil, rpcError(jsonrpc.ErrRPCWalletKeypoolRanOut, "Error: This wallet has no available keys")
+	}
+	addr := s.addresses[0]
+	s.addresses = s.addresses[1:]
+	return addr, nil
+}
+
+type outPoint struct {
+	TxID string `json:"txid"`
+	Vout uint32 `json:"vout"`
+}
+
+func (s *Server) lockUnspent(params []json.RawMessage) (interface{}, error) {
+	var unlock bool
+	var outpoints []outPoint
+	if err := arg(params, 0, &unlock); err != nil {
+		return nil, err
+	}
+	if err := arg(params, 1, &outpoints); err != nil {
+		return nil, err
+	}
+	if unlock && len(outpoints) == 0 {
+		s.locked = make(map[wire.OutPoint]bool)
+		return true, nil
+	}
+	for _, op := range outpoints {
+		hash, err := chainhash.NewHashFromStr(op.TxID)
+		if err != nil {
+			return nil, rpcError(jsonrpc.ErrRPCInvalidParameter, "txid must be hexadecimal string (not '%s')", strings.TrimSpace(op.TxID))
+		}
+		if unlock {
+			delete(s.locked, wire.OutPoint{Hash: *hash, Index: op.Vout})
+		} else {
+			s.locked[wire.OutPoint{Hash: *hash, Index: op.Vout}] = true
+		}
+	}
+	return true, nil
+}
+
+func (s *Server) listLockUnspent(params []json.RawMessage) (interface{}, error) {
+	locked := []outPoint{}
+	for op := range s.locked {
+		locked = append(locked, outPoint{TxID: op.Hash.String(), Vout: op.Index})
+	}
+	return locked, nil
+}
@@ -0,0 +1,220 @@
+package jsonrpctest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satshub/go-bitcoind/jsonrpc"
+)
+
+// templateRequest is the template_request param of getblocktemplate.
+type templateRequest struct {
+	Mode       string   `json:"mode"`
+	Rules      []string `json:"rules"`
+	LongPollID string   `json:"longpollid"`
+}
+
+// longPollID identifies the current template, like bitcoind's it changes
+// with the tip and the mempool.
+func (s *Server) longPollID() string {
+	return s.tip().hash.String() + strconv.FormatUint(s.mempoolSeq, 10)
+}
+
+// waitLongPoll blocks, without the server lock, while the long poll id of
+// a getblocktemplate request is the current one.
+func (s *Server) waitLongPoll(params []json.RawMessage) {
+	var req templateRequest
+	if arg(params, 0, &req) != nil || req.LongPollID == "" {
+		return
+	}
+	for {
+		s.mu.Lock()
+		current, changed := s.longPollID(), s.changed
+		s.mu.Unlock()
+		if req.LongPollID != current {
+			return
+		}
+		select {
+		case <-changed:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Server) getBlockTemplate(params []json.RawMessage) (interface{}, error) {
+	var req templateRequest
+	if err := arg(params, 0, &req); err != nil {
+		return nil, err
+	}
+	if req.Mode != "" && req.Mode != "template" {
+		return nil, rpcError(jsonrpc.ErrRPCInvalidParameter, "Invalid mode")
+	}
+	segwit := false
+	for _, rule := range req.Rules {
+		segwit = segwit || rule == "segwit"
+	}
+	if !segwit {
+		return nil, rpcError(jsonrpc.ErrRPCInvalidParameter, "getblocktemplate must be called with the segwit rule set (call with {\"rules\": [\"segwit\"]})")
+	}
+
+	tip := s.tip()
+	txs := []*btcutil.Tx{btcutil.NewTx(wire.NewMsgTx(wire.TxVersion))}
+	index := make(map[chainhash.Hash]int64, len(s.mempool))
+	value := s.subsidy(tip.height + 1)
+	var entries []btcjson.GetBlockTemplateResultTx
+	for i, mtx := range s.mempool {
+		index[mtx.hash] = int64(i + 1)
+		var buf bytes.Buffer
+		mtx.tx.Serialize(&buf)
+		var depends []int64
+		for _, in := range mtx.tx.TxIn {
+			if n, ok := index[in.PreviousOutPoint.Hash]; ok && n != int64(i+1) {
+				depends = append(depends, n)
+			}
+		}
+		if depends == nil {
+			depends = []int64{}
+		}
+		tx := btcutil.NewTx(mtx.tx)
+		txs = append(txs, tx)
+		entries = append(entries, btcjson.GetBlockTemplateResultTx{
+			Data:    hex.EncodeToString(buf.Bytes()),
+			TxID:    mtx.hash.String(),
+			Hash:    mtx.tx.WitnessHash().String(),
+			Depends: depends,
+			Fee:     int64(mtx.fee),
+			Weight:  blockchain.GetTransactionWeight(tx),
+		})
+		value += int64(mtx.fee)
+	}
+	if entries == nil {
+		entries = []btcjson.GetBlockTemplateResultTx{}
+	}
+
+	minTime := s.medianTime(tip) + 1
+	curTime := time.Now().Unix()
+	if curTime < minTime {
+		curTime = minTime
+	}
+	target := blockchain.CompactToBig(s.params.PowLimitBits)
+	return btcjson.GetBlockTemplateResult{
+		Capabilities:             []string{"proposal"},
+		Version:                  0x20000000,
+		PreviousHash:             tip.hash.String(),
+		Transactions:             entries,
+		CoinbaseValue:            &value,
+		LongPollID:               s.longPollID(),
+		Target:                   fmt.Sprintf("%064x", target),
+		MinTime:                  minTime,
+		Mutable:                  []string{"time", "transactions", "prevblock"},
+		NonceRange:               "00000000ffffffff",
+		SigOpLimit:               blockchain.MaxBlockSigOpsCost,
+		SizeLimit:                blockchain.MaxBlockBaseSize * blockchain.WitnessScaleFactor,
+		WeightLimit:              blockchain.MaxBlockWeight,
+		CurTime:                  curTime,
+		Bits:                     fmt.Sprintf("%08x", s.params.PowLimitBits),
+		Height:                   tip.height + 1,
+		DefaultWitnessCommitment: hex.EncodeToString(witnessCommitmentScript(txs)),
+	}, nil
+}
+
+// witnessCommitmentScript returns the BIP141 commitment output script of
+// txs, whose first transaction is the coinbase.
+func witnessCommitmentScript(txs []*btcutil.Tx) []byte {
+	root := blockchain.CalcMerkleRoot(txs, true)
+	var reserved [blockchain.CoinbaseWitnessDataLen]byte
+	commitment := chainhash.DoubleHashB(append(root[:], reserved[:]...))
+	return append(append([]byte(nil), blockchain.WitnessMagicBytes...), commitment...)
+}
+
+// submitBlock checks the header, coinbase and commitments of a block then
+// connects it. Scripts are not verified. Rejections are replied with BIP22
+// reasons.
+func (s *Server) submitBlock(params []json.RawMessage) (interface{}, error) {
+	var data string
+	if err := arg(params, 0, &data); err != nil {
+		return nil, err
+	}
+	serialized, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, rpcError(jsonrpc.ErrRPCDeserialization, "Block decode failed")
+	}
+	msg := &wire.MsgBlock{}
+	if err = msg.Deserialize(bytes.NewReader(serialized)); err != nil {
+		return nil, rpcError(jsonrpc.ErrRPCDeserialization, "Block decode failed")
+	}
+	if reason := s.checkBlock(msg); reason != "" {
+		return reason, nil
+	}
+	s.connect(msg)
+	return nil, nil
+}
+
+// checkBlock returns the reason msg can't be connected on top of the tip,
+// or "" when it can.
+func (s *Server) checkBlock(msg *wire.MsgBlock) string {
+	hash := msg.BlockHash()
+	if _, ok := s.byHash[hash]; ok {
+		return "duplicate"
+	}
+	tip := s.tip()
+	if msg.Header.PrevBlock != tip.hash {
+		if _, ok := s.byHash[msg.Header.PrevBlock]; ok {
+			return "inconclusive"
+		}
+		return "prev-blk-not-found"
+	}
+	if msg.Header.Bits != s.params.PowLimitBits {
+		return "bad-diffbits"
+	}
+	if blockchain.HashToBig(&hash).Cmp(blockchain.CompactToBig(msg.Header.Bits)) > 0 {
+		return "high-hash"
+	}
+	if msg.Header.Timestamp.Unix() <= s.medianTime(tip) {
+		return "time-too-old"
+	}
+	if len(msg.Transactions) == 0 || !isCoinbase(msg.Transactions[0]) {
+		return "bad-cb-missing"
+	}
+	if merkleRoot(msg.Transactions) != msg.Header.MerkleRoot {
+		return "bad-txnmrklroot"
+	}
+	block := btcutil.NewBlock(msg)
+	if height, err := blockchain.ExtractCoinbaseHeight(block.Transactions()[0]); err != nil || int64(height) != tip.height+1 {
+		return "bad-cb-height"
+	}
+	if err := blockchain.ValidateWitnessCommitment(block); err != nil {
+		return "bad-witness-merkle-match"
+	}
+	value := s.subsidy(tip.height + 1)
+	for _, tx := range msg.Transactions[1:] {
+		if isCoinbase(tx) {
+			return "bad-cb-multiple"
+		}
+		in, ok := s.inputValue(tx)
+		if !ok {
+			return "bad-txns-inputs-missingorspent"
+		}
+		for _, out := range tx.TxOut {
+			in -= out.Value
+		}
+		value += in
+	}
+	for _, out := range msg.Transactions[0].TxOut {
+		value -= out.Value
+	}
+	if value < 0 {
+		return "bad-cb-amount"
+	}
+	return ""
+}
@@ -0,0 +1,334 @@
+// Package jsonrpctest provides a scriptable fake bitcoind JSON-RPC server
+// for tests of code built on a bitcoind node.
+//
+// A Server starts with the genesis block of its network, an empty mempool
+// and an empty wallet. Tests register blocks, mempool transactions and
+// wallet UTXOs, script any method with Handle and make methods fail with
+// SetError:
+//
+//	s := jsonrpctest.NewServer(nil)
+//	defer s.Close()
+//	s.MineBlock(tx)
+//	s.SetError("sendrawtransaction", jsonrpc.ErrRPCVerifyRejected, "min relay fee not met")
+//	client := s.Client()
+//
+// getblocktemplate, with long polling, and submitblock are built in, so
+// miners can be tested on regtest with a CPU nonce search.
+//
+// Requests are accepted on any URL path, /wallet/<name> paths are recorded
+// as the wallet of the call but all wallets share the same UTXOs.
+package jsonrpctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satshub/go-bitcoind/jsonrpc"
+)
+
+// A HandlerFunc scripts a method. params are the raw JSON params of the
+// request (an array, an object or null). The result is marshalled to JSON.
+// Return a *jsonrpc.RPCError to reply with a specific error code, other
+// errors are replied as jsonrpc.ErrRPCMisc.
+type HandlerFunc func(params json.RawMessage) (result interface{}, err error)
+
+// Call is a request received by the server.
+type Call struct {
+	Method string
+	Params json.RawMessage
+	// Wallet is the wallet name of /wallet/<name> requests
+	Wallet string
+}
+
+// UTXO is a wallet unspent output returned by listunspent.
+type UTXO struct {
+	TxID          string         `json:"txid"`
+	Vout          uint32         `json:"vout"`
+	Address       string         `json:"address,omitempty"`
+	Label         string         `json:"label,omitempty"`
+	ScriptPubKey  string         `json:"scriptPubKey"`
+	Amount        jsonrpc.Amount `json:"amount"`
+	Confirmations int64          `json:"confirmations"`
+	Spendable     bool           `json:"spendable"`
+	Solvable      bool           `json:"solvable"`
+	Safe          bool           `json:"safe"`
+}
+
+// Server is a fake bitcoind served over httptest.
+type Server struct {
+	// URL is the base URL of the server, eg http://127.0.0.1:12345
+	URL string
+	// Host and Port of the server, as expected by jsonrpc.New
+	Host string
+	Port int
+
+	ts     *httptest.Server
+	params *chaincfg.Params
+
+	mu      sync.Mutex
+	user    string
+	passwd  string
+	blocks  []*block
+	byHash  map[chainhash.Hash]*block
+	txs     map[chainhash.Hash]txEntry
+	mempool []*mempoolTx
+	// mempoolSeq counts mempool additions and removals
+	mempoolSeq uint64
+	utxos      []UTXO
+	locked     map[wire.OutPoint]bool
+	addresses  []string
+	handlers   map[string]HandlerFunc
+	errors     map[string]*jsonrpc.RPCError
+	calls      []Call
+	// changed is closed and replaced when the tip or the mempool change,
+	// to wake up getblocktemplate long polls
+	changed   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewServer starts a fake bitcoind for the network params. A nil params
+// defaults to regtest. Stop it with Close.
+func NewServer(params *chaincfg.Params) *Server {
+	if params == nil {
+		params = &chaincfg.RegressionNetParams
+	}
+	s := &Server{
+		params:   params,
+		byHash:   make(map[chainhash.Hash]*block),
+		txs:      make(map[chainhash.Hash]txEntry),
+		locked:   make(map[wire.OutPoint]bool),
+		handlers: make(map[string]HandlerFunc),
+		errors:   make(map[string]*jsonrpc.RPCError),
+		changed:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	s.connect(params.GenesisBlock)
+	s.ts = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.ts.URL
+	u, _ := url.Parse(s.ts.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+	s.Host = host
+	s.Port, _ = strconv.Atoi(port)
+	return s
+}
+
+// Close shuts down the server. Pending long polls return first. Closing
+// again is a no-op.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.ts.Close()
+	})
+}
+
+// Client returns a jsonrpc client of the server.
+func (s *Server) Client() *jsonrpc.Bitcoind {
+	s.mu.Lock()
+	user, passwd := s.user, s.passwd
+	s.mu.Unlock()
+	client, err := jsonrpc.New(s.Host, s.Port, user, passwd, false)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// Params returns the network params of the server.
+func (s *Server) Params() *chaincfg.Params {
+	return s.params
+}
+
+// SetAuth makes the server reply 401 to requests without these basic auth
+// credentials.
+func (s *Server) SetAuth(user, passwd string) {
+	s.mu.Lock()
+	s.user, s.passwd = user, passwd
+	s.mu.Unlock()
+}
+
+// Handle scripts method with h, overriding the built-in implementation if
+// any. A nil h restores it.
+func (s *Server) Handle(method string, h HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if h == nil {
+		delete(s.handlers, method)
+		return
+	}
+	s.handlers[method] = h
+}
+
+// SetError makes every call of method fail with code and message until
+// ClearError is called.
+func (s *Server) SetError(method string, code jsonrpc.RPCErrorCode, message string) {
+	s.mu.Lock()
+	s.errors[method] = &jsonrpc.RPCError{Code: code, Message: message}
+	s.mu.Unlock()
+}
+
+// ClearError removes the error set on method.
+func (s *Server) ClearError(method string) {
+	s.mu.Lock()
+	delete(s.errors, method)
+	s.mu.Unlock()
+}
+
+// Calls returns the requests received so far, in order.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsOf returns the requests of method received so far, in order.
+func (s *Server) CallsOf(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var calls []Call
+	for _, c := range s.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// AddUTXO adds u to the wallet.
+func (s *Server) AddUTXO(u UTXO) {
+	s.mu.Lock()
+	s.utxos = append(s.utxos, u)
+	s.mu.Unlock()
+}
+
+// SetNewAddresses sets the addresses getnewaddress and getrawchangeaddress
+// return, in order. Once they are all used the keypool runs out.
+func (s *Server) SetNewAddresses(addresses ...string) {
+	s.mu.Lock()
+	s.addresses = append([]string(nil), addresses...)
+	s.mu.Unlock()
+}
+
+type request struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Id     json.RawMessage `json:"id"`
+}
+
+type response struct {
+	Result interface{}       `json:"result"`
+	Err    *jsonrpc.RPCError `json:"error"`
+	Id     json.RawMessage   `json:"id"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	user, passwd := s.user, s.passwd
+	s.mu.Unlock()
+	if user != "" || passwd != "" {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != user || p != passwd {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	wallet := ""
+	if strings.HasPrefix(r.URL.Path, "/wallet/") {
+		wallet, _ = url.PathUnescape(strings.TrimPrefix(r.URL.Path, "/wallet/"))
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var reqs []request
+		if err := json.Unmarshal(trimmed, &reqs); err != nil {
+			s.reply(w, http.StatusInternalServerError, response{Err: &jsonrpc.RPCError{Code: jsonrpc.ErrRPCParse, Message: "Parse error"}})
+			return
+		}
+		resps := make([]response, len(reqs))
+		for i, req := range reqs {
+			resps[i] = s.serveRequest(req, wallet)
+		}
+		s.reply(w, http.StatusOK, resps)
+		return
+	}
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.reply(w, http.StatusInternalServerError, response{Err: &jsonrpc.RPCError{Code: jsonrpc.ErrRPCParse, Message: "Parse error"}})
+		return
+	}
+	resp := s.serveRequest(req, wallet)
+	status := http.StatusOK
+	if resp.Err != nil {
+		// like bitcoind with JSON-RPC 1.0 requests
+		status = http.StatusInternalServerError
+		if resp.Err.Code == jsonrpc.ErrRPCMethodNotFound {
+			status = http.StatusNotFound
+		}
+	}
+	s.reply(w, status, resp)
+}
+
+func (s *Server) reply(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(response{Err: &jsonrpc.RPCError{Code: jsonrpc.ErrRPCInternal, Message: err.Error()}})
+		status = http.StatusInternalServerError
+	}
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+// serveRequest records req and dispatches it to, in order, the error set
+// on its method, its handler and its built-in implementation.
+func (s *Server) serveRequest(req request, wallet string) (resp response) {
+	resp.Id = req.Id
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: req.Method, Params: req.Params, Wallet: wallet})
+	rpcErr := s.errors[req.Method]
+	h := s.handlers[req.Method]
+	s.mu.Unlock()
+
+	if rpcErr != nil {
+		resp.Err = rpcErr
+		return
+	}
+	var result interface{}
+	var err error
+	if h != nil {
+		result, err = h(req.Params)
+	} else {
+		result, err = s.builtin(req.Method, req.Params)
+	}
+	if err != nil {
+		if e, ok := err.(*jsonrpc.RPCError); ok {
+			resp.Err = e
+		} else {
+			resp.Err = &jsonrpc.RPCError{Code: jsonrpc.ErrRPCMisc, Message: err.Error()}
+		}
+		return
+	}
+	resp.Result = result
+	return
+}
+
+// rpcError returns an RPC error with a formatted message.
+func rpcError(code jsonrpc.RPCErrorCode, format string, args ...interface{}) *jsonrpc.RPCError {
+	return &jsonrpc.RPCError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
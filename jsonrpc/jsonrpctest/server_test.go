@@ -0,0 +1,218 @@
+package jsonrpctest_test
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/satshub/go-bitcoind/jsonrpc"
+	"github.com/satshub/go-bitcoind/jsonrpc/jsonrpctest"
+)
+
+// spend returns a transaction spending output n of prev to an OP_TRUE
+// script, leaving fee.
+func spend(prev *wire.MsgTx, n uint32, fee int64) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: prev.TxHash(), Index: n}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(prev.TxOut[n].Value-fee, []byte{txscript.OP_TRUE}))
+	return tx
+}
+
+var _ = Describe("Server", func() {
+	Describe("chain", func() {
+		Context("when the server starts", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			client := s.Client()
+			count, err := client.GetBlockCount()
+			best, _ := client.GetBestBlockhash()
+			It("should only have the genesis block", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(uint64(0)))
+				Expect(best).To(Equal(chaincfg.RegressionNetParams.GenesisHash.String()))
+			})
+		})
+
+		Context("when blocks are mined", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			client := s.Client()
+			b1 := s.MineBlock()
+			tx := spend(b1.Transactions[0], 0, 1000)
+			b2 := s.MineBlock(tx)
+			count, _ := client.GetBlockCount()
+			block, err := client.GetBlock(b2.BlockHash().String())
+			msg, _ := client.GetBlockMsg(b2.BlockHash().String())
+			header, _ := client.GetBlockheader(b1.BlockHash().String())
+			rawTx, _ := client.GetRawTransactionMsg(tx.TxHash().String())
+			It("should serve the blocks", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(uint64(2)))
+				Expect(block.Height).To(Equal(uint64(2)))
+				Expect(block.Tx).To(Equal([]string{b2.Transactions[0].TxHash().String(), tx.TxHash().String()}))
+				Expect(block.Previousblockhash).To(Equal(b1.BlockHash().String()))
+				Expect(msg.BlockHash()).To(Equal(b2.BlockHash()))
+				Expect(msg.Header.MerkleRoot).To(Equal(b2.Header.MerkleRoot))
+			})
+			It("should serve the headers", func() {
+				Expect(header.Height).To(Equal(1))
+				Expect(header.Nextblockhash).To(Equal(b2.BlockHash().String()))
+			})
+			It("should serve the transactions", func() {
+				Expect(rawTx.TxHash()).To(Equal(tx.TxHash()))
+			})
+		})
+
+		Context("when the tip is disconnected", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			client := s.Client()
+			b1 := s.MineBlock()
+			tx := spend(b1.Transactions[0], 0, 1000)
+			s.MineBlock(tx)
+			_, err := s.DisconnectTip()
+			count, _ := client.GetBlockCount()
+			mempool, _ := client.GetRawMempool()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return the transactions to the mempool", func() {
+				Expect(count).To(Equal(uint64(1)))
+				Expect(mempool).To(Equal([]string{tx.TxHash().String()}))
+			})
+		})
+	})
+
+	Describe("mempool", func() {
+		Context("when a transaction is broadcast", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			client := s.Client()
+			b1 := s.MineBlock()
+			tx := spend(b1.Transactions[0], 0, 1000)
+			txid, err := client.SendRawTransaction(tx, nil)
+			entry, _ := client.GetMempoolEntry(txid)
+			seq, _ := client.GetRawMempoolSequence()
+			s.MineMempool()
+			_, errAgain := client.SendRawTransaction(tx, nil)
+			mempool, _ := client.GetRawMempool()
+			It("should accept it", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(txid).To(Equal(tx.TxHash().String()))
+			})
+			It("should compute its fee", func() {
+				Expect(entry.Fees.Base).To(Equal(jsonrpc.Amount(1000)))
+				Expect(entry.Vsize).To(BeNumerically(">", 0))
+			})
+			It("should count mempool changes", func() {
+				Expect(seq.Txids).To(Equal([]string{txid}))
+				Expect(seq.MempoolSequence).To(Equal(uint64(1)))
+			})
+			It("should remove it once mined", func() {
+				Expect(mempool).To(BeEmpty())
+				Expect(errors.Is(errAgain, jsonrpc.ErrRPCVerifyAlreadyInChain)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("wallet", func() {
+		Context("when UTXOs are registered", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			client := s.Client()
+			s.AddUTXO(jsonrpctest.UTXO{TxID: "6f5a8b9c2f2d4a3e1c0b9a8f7e6d5c4b3a29180706f5e4d3c2b1a09f8e7d6c5b", Vout: 1, Address: "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080", Amount: 12345, Confirmations: 6, Spendable: true})
+			s.AddUTXO(jsonrpctest.UTXO{TxID: "0f5a8b9c2f2d4a3e1c0b9a8f7e6d5c4b3a29180706f5e4d3c2b1a09f8e7d6c5b", Vout: 0, Amount: 100, Confirmations: 0})
+			s.SetNewAddresses("bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080")
+			unspent, err := client.Wallet("hot").ListUnspent(1, 999999)
+			balances, _ := client.GetBalances()
+			addr, _ := client.GetNewAddress()
+			_, errKeypool := client.GetNewAddress()
+			It("should list the confirmed ones", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(unspent).To(HaveLen(1))
+				Expect(unspent[0].Amount).To(Equal(jsonrpc.Amount(12345)))
+			})
+			It("should compute the balances", func() {
+				Expect(balances.Mine.Trusted).To(Equal(jsonrpc.Amount(12345)))
+				Expect(balances.Mine.UntrustedPending).To(Equal(jsonrpc.Amount(100)))
+			})
+			It("should hand out the new addresses", func() {
+				Expect(addr).To(Equal("bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"))
+				Expect(errors.Is(errKeypool, jsonrpc.ErrRPCWalletKeypoolRanOut)).To(BeTrue())
+			})
+			It("should record the wallet of calls", func() {
+				calls := s.CallsOf("listunspent")
+				Expect(calls).To(HaveLen(1))
+				Expect(calls[0].Wallet).To(Equal("hot"))
+				Expect(string(calls[0].Params)).To(Equal("[1,999999]"))
+			})
+		})
+	})
+
+	Describe("scripting", func() {
+		Context("when an error is set", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			client := s.Client()
+			s.SetError("getblockcount", jsonrpc.ErrRPCInWarmup, "Loading block index...")
+			_, err := client.GetBlockCount()
+			s.ClearError("getblockcount")
+			_, errCleared := client.GetBlockCount()
+			It("should fail the method until cleared", func() {
+				Expect(errors.Is(err, jsonrpc.ErrRPCInWarmup)).To(BeTrue())
+				Expect(errCleared).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when a method is handled", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			client := s.Client()
+			s.Handle("getconnectioncount", func(params json.RawMessage) (interface{}, error) {
+				return 8, nil
+			})
+			s.Handle("getdifficulty", func(params json.RawMessage) (interface{}, error) {
+				return nil, &jsonrpc.RPCError{Code: jsonrpc.ErrRPCMisc, Message: "scripted"}
+			})
+			count, err := client.GetConnectionCount()
+			_, errScripted := client.GetDifficulty()
+			_, errUnknown := client.GetMiningInfo()
+			It("should reply with the handler result", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(uint64(8)))
+				Expect(errScripted).To(MatchError("-1: scripted"))
+			})
+			It("should reject unknown methods", func() {
+				Expect(errors.Is(errUnknown, jsonrpc.ErrRPCMethodNotFound)).To(BeTrue())
+			})
+		})
+
+		Context("when credentials are required", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			s.SetAuth("user", "pass")
+			_, err := s.Client().GetBlockCount()
+			bad, _ := jsonrpc.New(s.Host, s.Port, "user", "wrong", false)
+			_, errBad := bad.GetBlockCount()
+			It("should check them", func() {
+				Expect(err).NotTo(HaveOccurred())
+				var httpErr *jsonrpc.HTTPError
+				Expect(errors.As(errBad, &httpErr)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("close", func() {
+		Context("when the server is closed twice", func() {
+			s := jsonrpctest.NewServer(nil)
+			s.Close()
+			It("should not panic", func() {
+				Expect(s.Close).NotTo(Panic())
+			})
+		})
+	})
+})
@@ -0,0 +1,183 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+)
+
+// MempoolFees represents the fees of a mempool entry
+type MempoolFees struct {
+	// Transaction fee
+	Base Amount `json:"base"`
+
+	// Transaction fee with fee deltas used for mining priority
+	Modified Amount `json:"modified"`
+
+	// Transaction fees of in-mempool ancestors (including this one) with fee deltas used for mining priority
+	Ancestor Amount `json:"ancestor"`
+
+	// Transaction fees of in-mempool descendants (including this one) with fee deltas used for mining priority
+	Descendant Amount `json:"descendant"`
+}
+
+// MempoolEntry represents a transaction in the mempool
+// See https://bitcoincore.org/en/doc/26.0.0/rpc/blockchain/getmempoolentry/
+type MempoolEntry struct {
+	// Virtual transaction size as defined in BIP 141
+	Vsize uint32 `json:"vsize"`
+
+	// Transaction weight as defined in BIP 141
+	Weight uint32 `json:"weight"`
+
+	// Local time transaction entered pool in seconds since 1 Jan 1970 GMT
+	Time int64 `json:"time"`
+
+	// Block height when transaction entered pool
+	Height int64 `json:"height"`
+
+	// Number of in-mempool descendant transactions (including this one)
+	DescendantCount uint32 `json:"descendantcount"`
+
+	// Virtual transaction size of in-mempool descendants (including this one)
+	DescendantSize uint32 `json:"descendantsize"`
+
+	// Number of in-mempool ancestor transactions (including this one)
+	AncestorCount uint32 `json:"ancestorcount"`
+
+	// Virtual transaction size of in-mempool ancestors (including this one)
+	AncestorSize uint32 `json:"ancestorsize"`
+
+	// Hash of serialized transaction, including witness data
+	Wtxid string `json:"wtxid"`
+
+	// The fees
+	Fees MempoolFees `json:"fees"`
+
+	// Unconfirmed transactions used as inputs for this transaction
+	Depends []string `json:"depends"`
+
+	// Unconfirmed transactions spending outputs from this transaction
+	SpentBy []string `json:"spentby"`
+
+	// Whether this transaction signals BIP125 replaceability
+	Bip125Replaceable bool `json:"bip125-replaceable"`
+
+	// Whether this transaction is currently unbroadcast (initial broadcast not yet acknowledged by any peers)
+	Unbroadcast bool `json:"unbroadcast"`
+}
+
+// FeeRate returns the fee rate of the entry alone in sat/vB
+func (e MempoolEntry) FeeRate() float64 {
+	if e.Vsize == 0 {
+		return 0
+	}
+	return float64(e.Fees.Modified) / float64(e.Vsize)
+}
+
+// AncestorFeeRate returns the fee rate of the entry with its in-mempool
+// ancestors (the package a miner would include) in sat/vB
+func (e MempoolEntry) AncestorFeeRate() float64 {
+	if e.AncestorSize == 0 {
+		return 0
+	}
+	return float64(e.Fees.Ancestor) / float64(e.AncestorSize)
+}
+
+// DescendantFeeRate returns the fee rate of the entry with its in-mempool
+// descendants in sat/vB
+func (e MempoolEntry) DescendantFeeRate() float64 {
+	if e.DescendantSize == 0 {
+		return 0
+	}
+	return float64(e.Fees.Descendant) / float64(e.DescendantSize)
+}
+
+// GetMempoolEntry returns mempool data for the given transaction
+func (b *Bitcoind) GetMempoolEntry(txid string) (entry MempoolEntry, err error) {
+	r, err := b.client.call("getmempoolentry", []string{txid})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &entry)
+	return
+}
+
+// GetMempoolAncestors returns the ids of all in-mempool ancestors of txid
+func (b *Bitcoind) GetMempoolAncestors(txid string) (txids []string, err error) {
+	r, err := b.client.call("getmempoolancestors", []interface{}{txid, false})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &txids)
+	return
+}
+
+// GetMempoolAncestorsVerbose returns all in-mempool ancestors of txid
+// map [TxId] => MempoolEntry
+func (b *Bitcoind) GetMempoolAncestorsVerbose(txid string) (entries map[string]MempoolEntry, err error) {
+	r, err := b.client.call("getmempoolancestors", []interface{}{txid, true})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &entries)
+	return
+}
+
+// GetMempoolDescendants returns the ids of all in-mempool descendants of txid
+func (b *Bitcoind) GetMempoolDescendants(txid string) (txids []string, err error) {
+	r, err := b.client.call("getmempooldescendants", []interface{}{txid, false})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &txids)
+	return
+}
+
+// GetMempoolDescendantsVerbose returns all in-mempool descendants of txid
+// map [TxId] => MempoolEntry
+func (b *Bitcoind) GetMempoolDescendantsVerbose(txid string) (entries map[string]MempoolEntry, err error) {
+	r, err := b.client.call("getmempooldescendants", []interface{}{txid, true})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &entries)
+	return
+}
+
+// MempoolSequence represents a response to getrawmempool with mempool_sequence
+type MempoolSequence struct {
+	// The transaction ids in the mempool
+	Txids []string `json:"txids"`
+
+	// The mempool sequence value, matching the ZMQ "sequence" topic
+	MempoolSequence uint64 `json:"mempool_sequence"`
+}
+
+// GetRawMempoolSequence returns all transaction ids in memory pool along
+// with the mempool sequence number they are valid at, to synchronize with
+// the ZMQ "sequence" notifications.
+func (b *Bitcoind) GetRawMempoolSequence() (seq MempoolSequence, err error) {
+	r, err := b.client.call("getrawmempool", []bool{false, true})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &seq)
+	return
+}
+
+// SaveMempool dumps the mempool to disk and returns the dump file path
+// (empty for nodes older than v23).
+func (b *Bitcoind) SaveMempool() (filename string, err error) {
+	r, err := b.client.call("savemempool", nil)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	if string(r.Result) == "null" {
+		return
+	}
+	var res struct {
+		Filename string `json:"filename"`
+	}
+	err = json.Unmarshal(r.Result, &res)
+	filename = res.Filename
+	return
+}
@@ -0,0 +1,84 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mempool", func() {
+	Describe("getmempoolentry", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":{"vsize":141,"weight":561,"time":1700000000,"height":820000,"descendantcount":2,"descendantsize":282,"ancestorcount":1,"ancestorsize":141,"wtxid":"e1d3b5b1","fees":{"base":0.00001410,"modified":0.00001410,"ancestor":0.00001410,"descendant":0.00005640},"depends":[],"spentby":["b2c4"],"bip125-replaceable":true,"unbroadcast":false},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			entry, err := bitcoindClient.GetMempoolEntry("a1b2")
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return fees in satoshis", func() {
+				Expect(entry.Fees.Base).To(Equal(Amount(1410)))
+				Expect(entry.Fees.Descendant).To(Equal(Amount(5640)))
+			})
+			It("should compute fee rates", func() {
+				Expect(entry.FeeRate()).To(Equal(10.0))
+				Expect(entry.DescendantFeeRate()).To(Equal(20.0))
+			})
+		})
+	})
+
+	Describe("getrawmempool verbose", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":{"a1b2":{"size":141,"fee":0.00001410,"modifiedfee":0.00001410,"time":1700000000,"height":820000,"descendantcount":2,"descendantsize":282,"descendantfees":5640,"ancestorcount":1,"ancestorsize":141,"ancestorfees":1410,"wtxid":"e1d3b5b1","depends":[]}},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			txs, err := bitcoindClient.GetRawMempoolVerbose()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return fees in satoshis", func() {
+				Expect(txs["a1b2"].Fee).To(Equal(Amount(1410)))
+				Expect(txs["a1b2"].DescendantFees).To(Equal(Amount(5640)))
+				Expect(txs["a1b2"].AncestorFees).To(Equal(Amount(1410)))
+				Expect(txs["a1b2"].DescendantCount).To(Equal(uint32(2)))
+			})
+		})
+	})
+
+	Describe("getrawmempool with sequence", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":{"txids":["a1b2","c3d4"],"mempool_sequence":1234},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			seq, err := bitcoindClient.GetRawMempoolSequence()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return txids and sequence", func() {
+				Expect(seq.Txids).To(Equal([]string{"a1b2", "c3d4"}))
+				Expect(seq.MempoolSequence).To(Equal(uint64(1234)))
+			})
+		})
+	})
+})
@@ -0,0 +1,121 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMetricsWindow is the number of latencies per method Metrics keeps
+// to compute percentiles.
+const DefaultMetricsWindow = 1024
+
+// Metrics is an in-memory collector of per method call counts and
+// latencies. Install it with WithMiddleware(m.Middleware()).
+type Metrics struct {
+	window  int
+	mu      sync.Mutex
+	methods map[string]*methodMetrics
+}
+
+// MethodStats are the statistics of one method.
+type MethodStats struct {
+	Calls  uint64
+	Errors uint64
+	// Latency percentiles over the last calls
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// methodMetrics keeps the last latencies of a method in a ring buffer.
+type methodMetrics struct {
+	calls     uint64
+	errors    uint64
+	latencies []time.Duration
+	next      int
+}
+
+// NewMetrics returns a collector computing percentiles over the last window
+// calls of each method. A window <= 0 uses DefaultMetricsWindow.
+func NewMetrics(window int) *Metrics {
+	if window <= 0 {
+		window = DefaultMetricsWindow
+	}
+	return &Metrics{window: window, methods: make(map[string]*methodMetrics)}
+}
+
+// Middleware returns the middleware recording calls into m.
+func (m *Metrics) Middleware() Middleware {
+	return func(next Invoker) Invoker {
+		return func(call *RPCCall) (json.RawMessage, error) {
+			start := time.Now()
+			result, err := next(call)
+			m.Observe(call.Method, time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// Observe records a call of method.
+func (m *Metrics) Observe(method string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mm, ok := m.methods[method]
+	if !ok {
+		mm = &methodMetrics{latencies: make([]time.Duration, 0, m.window)}
+		m.methods[method] = mm
+	}
+	mm.calls++
+	if err != nil {
+		mm.errors++
+	}
+	if len(mm.latencies) < m.window {
+		mm.latencies = append(mm.latencies, latency)
+	} else {
+		mm.latencies[mm.next] = latency
+		mm.next = (mm.next + 1) % m.window
+	}
+}
+
+// Snapshot returns the statistics of every method called so far.
+func (m *Metrics) Snapshot() map[string]MethodStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make(map[string]MethodStats, len(m.methods))
+	for method, mm := range m.methods {
+		sorted := append([]time.Duration(nil), mm.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats[method] = MethodStats{
+			Calls:  mm.calls,
+			Errors: mm.errors,
+			P50:    percentile(sorted, 50),
+			P90:    percentile(sorted, 90),
+			P99:    percentile(sorted, 99),
+			Max:    percentile(sorted, 100),
+		}
+	}
+	return stats
+}
+
+// Reset forgets all recorded calls.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	m.methods = make(map[string]*methodMetrics)
+	m.mu.Unlock()
+}
+
+// percentile returns the p-th percentile of sorted using the nearest rank
+// method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,100 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// RPCCall describes a call going through middlewares.
+type RPCCall struct {
+	Context context.Context
+	Method  string
+	// Params of the call. For a batch Method is "batch" and Params are the
+	// batched method names ([]string).
+	Params interface{}
+	// Wallet the call is scoped to, if any
+	Wallet string
+}
+
+// An Invoker performs call and returns its JSON result. RPC errors are
+// returned as *RPCError.
+type Invoker func(call *RPCCall) (json.RawMessage, error)
+
+// A Middleware wraps an Invoker, eg to log, trace, rate limit or measure
+// calls. It may change the call before invoking next, or not invoke it at
+// all and return an error.
+type Middleware func(next Invoker) Invoker
+
+// WithMiddleware wraps every call in mws. The first middleware is the
+// outermost one, it sees the call first and the result last.
+func WithMiddleware(mws ...Middleware) Option {
+	return func(o *clientOptions) error {
+		for _, mw := range mws {
+			if mw == nil {
+				return errors.New("Bad option: nil middleware")
+			}
+		}
+		o.middlewares = append(o.middlewares, mws...)
+		return nil
+	}
+}
+
+// WithMiddleware returns a copy of b whose calls are also wrapped in mws,
+// inside the middlewares b already has.
+// The copy shares the underlying HTTP connections with b.
+func (b *Bitcoind) WithMiddleware(mws ...Middleware) *Bitcoind {
+	return &Bitcoind{client: b.client.withMiddleware(mws)}
+}
+
+// withMiddleware returns a shallow copy of c with mws appended to its
+// middlewares.
+func (c *rpcClient) withMiddleware(mws []Middleware) *rpcClient {
+	c2 := *c
+	c2.middlewares = append(c.middlewares[:len(c.middlewares):len(c.middlewares)], mws...)
+	return &c2
+}
+
+// chain wraps invoke in the middlewares of c.
+func (c *rpcClient) chain(invoke Invoker) Invoker {
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		invoke = c.middlewares[i](invoke)
+	}
+	return invoke
+}
+
+// callThrough runs method through the middlewares of c.
+func (c *rpcClient) callThrough(method string, params interface{}) (rr rpcResponse, err error) {
+	invoke := c.chain(func(call *RPCCall) (json.RawMessage, error) {
+		r, err := c.withContext(call.Context).withWallet(call.Wallet).send(call.Method, call.Params)
+		if err != nil {
+			return nil, err
+		}
+		return r.Result, handleError(nil, &r)
+	})
+	result, err := invoke(&RPCCall{Context: c.context(), Method: method, Params: params, Wallet: c.wallet})
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcResponse{Result: result, Err: rpcErr}, nil
+	}
+	return rpcResponse{Result: result}, err
+}
+
+// callBatchThrough runs requests through the middlewares of c as a single
+// "batch" call.
+func (c *rpcClient) callBatchThrough(requests []rpcRequest) (rrs []rpcResponse, err error) {
+	methods := make([]string, len(requests))
+	for i, req := range requests {
+		methods[i] = req.Method
+	}
+	invoke := c.chain(func(call *RPCCall) (json.RawMessage, error) {
+		var err error
+		rrs, err = c.withContext(call.Context).withWallet(call.Wallet).sendBatch(requests)
+		return nil, err
+	})
+	_, err = invoke(&RPCCall{Context: c.context(), Method: "batch", Params: methods, Wallet: c.wallet})
+	if err == nil && len(rrs) != len(requests) {
+		err = errors.New("Bad batch response from middleware")
+	}
+	return
+}
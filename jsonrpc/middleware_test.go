@@ -0,0 +1,174 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Middleware", func() {
+	Describe("chain", func() {
+		Context("when calls succeed or fail", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req rpcRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				if req.Method == "getblockhash" {
+					fmt.Fprintln(w, `{"result":null,"error":{"code":-8,"message":"Block height out of range"},"id":1400432805294160077}`)
+					return
+				}
+				fmt.Fprintln(w, `{"result":42,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			var trace []string
+			tracer := func(name string) Middleware {
+				return func(next Invoker) Invoker {
+					return func(call *RPCCall) (json.RawMessage, error) {
+						trace = append(trace, name+" "+call.Method)
+						result, err := next(call)
+						trace = append(trace, fmt.Sprintf("%s %s %v", name, result, err))
+						return result, err
+					}
+				}
+			}
+			bitcoindClient, _ := NewWithOptions(host, port, WithMiddleware(tracer("a")), WithMiddleware(tracer("b")))
+			count, err := bitcoindClient.GetBlockCount()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(uint64(42)))
+			})
+			_, rpcErr := bitcoindClient.GetBlockHash(1000000)
+			It("should return RPC errors", func() {
+				Expect(errors.Is(rpcErr, ErrRPCInvalidParameter)).To(BeTrue())
+			})
+			It("should run middlewares in order and show them results and errors", func() {
+				Expect(trace).To(Equal([]string{
+					"a getblockcount", "b getblockcount", "b 42 <nil>", "a 42 <nil>",
+					"a getblockhash", "b getblockhash", "b null -8: Block height out of range", "a null -8: Block height out of range",
+				}))
+			})
+		})
+
+		Context("when a middleware rejects the call", func() {
+			calls := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				fmt.Fprintln(w, `{"result":42,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			errLimited := errors.New("rate limited")
+			limiter := func(next Invoker) Invoker {
+				return func(call *RPCCall) (json.RawMessage, error) {
+					return nil, errLimited
+				}
+			}
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			_, err = bitcoindClient.WithMiddleware(limiter).GetBlockCount()
+			It("should return the middleware error", func() {
+				Expect(err).To(Equal(errLimited))
+			})
+			It("should not reach the server", func() {
+				Expect(calls).To(Equal(0))
+			})
+		})
+
+		Context("when sending a batch", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var reqs []rpcRequest
+				json.NewDecoder(r.Body).Decode(&reqs)
+				fmt.Fprintf(w, `[{"result":1,"error":null,"id":%d},{"result":2,"error":null,"id":%d}]`+"\n", reqs[0].Id, reqs[1].Id)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			var seen *RPCCall
+			spy := func(next Invoker) Invoker {
+				return func(call *RPCCall) (json.RawMessage, error) {
+					seen = call
+					return next(call)
+				}
+			}
+			bitcoindClient, _ := NewWithOptions(host, port, WithMiddleware(spy))
+			var a, b int
+			batch := bitcoindClient.NewBatch()
+			batch.Queue("getblockcount", nil, &a)
+			batch.Queue("getconnectioncount", nil, &b)
+			err = batch.Send()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect([]int{a, b}).To(Equal([]int{1, 2}))
+			})
+			It("should pass the batch as one call", func() {
+				Expect(seen.Method).To(Equal("batch"))
+				Expect(seen.Params).To(Equal([]string{"getblockcount", "getconnectioncount"}))
+			})
+		})
+	})
+
+	Describe("metrics", func() {
+		Context("when recording latencies", func() {
+			metrics := NewMetrics(0)
+			for i := 1; i <= 100; i++ {
+				metrics.Observe("getblock", time.Duration(i)*time.Millisecond, nil)
+			}
+			metrics.Observe("sendrawtransaction", time.Millisecond, ErrRPCVerifyRejected)
+			stats := metrics.Snapshot()
+			It("should count calls and errors", func() {
+				Expect(stats["getblock"].Calls).To(Equal(uint64(100)))
+				Expect(stats["getblock"].Errors).To(Equal(uint64(0)))
+				Expect(stats["sendrawtransaction"].Errors).To(Equal(uint64(1)))
+			})
+			It("should compute percentiles", func() {
+				Expect(stats["getblock"].P50).To(Equal(50 * time.Millisecond))
+				Expect(stats["getblock"].P90).To(Equal(90 * time.Millisecond))
+				Expect(stats["getblock"].P99).To(Equal(99 * time.Millisecond))
+				Expect(stats["getblock"].Max).To(Equal(100 * time.Millisecond))
+			})
+		})
+
+		Context("when the window is full", func() {
+			metrics := NewMetrics(10)
+			for i := 1; i <= 20; i++ {
+				metrics.Observe("getblock", time.Duration(i)*time.Millisecond, nil)
+			}
+			stats := metrics.Snapshot()["getblock"]
+			It("should only keep the last calls", func() {
+				Expect(stats.Calls).To(Equal(uint64(20)))
+				Expect(stats.P50).To(Equal(15 * time.Millisecond))
+			})
+		})
+
+		Context("when installed as a middleware", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":42,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			metrics := NewMetrics(0)
+			bitcoindClient, _ := NewWithOptions(host, port, WithMiddleware(metrics.Middleware()))
+			bitcoindClient.GetBlockCount()
+			bitcoindClient.GetBlockCount()
+			It("should record calls", func() {
+				Expect(metrics.Snapshot()["getblockcount"].Calls).To(Equal(uint64(2)))
+			})
+		})
+	})
+})
@@ -0,0 +1,290 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AddNodeCommand is the command of AddNode
+type AddNodeCommand string
+
+const (
+	// AddNodeAdd adds the node to the added nodes list and keeps a
+	// connection to it
+	AddNodeAdd AddNodeCommand = "add"
+	// AddNodeRemove removes the node from the added nodes list
+	AddNodeRemove AddNodeCommand = "remove"
+	// AddNodeOneTry connects to the node once
+	AddNodeOneTry AddNodeCommand = "onetry"
+)
+
+// BannedSubnet represents an entry of listbanned
+type BannedSubnet struct {
+	// The banned IP address or subnet, eg 192.168.0.0/24
+	Address string `json:"address"`
+
+	// The time the ban was created, in seconds since epoch
+	BanCreated int64 `json:"ban_created"`
+
+	// The time the ban expires, in seconds since epoch
+	BannedUntil int64 `json:"banned_until"`
+
+	// The ban duration, in seconds (Bitcoin Core 24+)
+	BanDuration int64 `json:"ban_duration"`
+
+	// The time remaining until the ban expires, in seconds (Bitcoin Core 24+)
+	TimeRemaining int64 `json:"time_remaining"`
+}
+
+// AddedNodeAddress represents a connection to an added node
+type AddedNodeAddress struct {
+	// The address and port of the connection
+	Address string `json:"address"`
+
+	// "inbound" or "outbound"
+	Connected string `json:"connected"`
+}
+
+// AddedNodeInfo represents an entry of getaddednodeinfo
+type AddedNodeInfo struct {
+	// The node address as given to addnode
+	AddedNode string `json:"addednode"`
+
+	// Whether the node is connected
+	Connected bool `json:"connected"`
+
+	// The connections to the node, only when connected
+	Addresses []AddedNodeAddress `json:"addresses"`
+}
+
+// UploadTarget represents the -maxuploadtarget state
+type UploadTarget struct {
+	// Length of the measuring timeframe in seconds
+	Timeframe int64 `json:"timeframe"`
+
+	// Target in bytes, 0 when unlimited
+	Target uint64 `json:"target"`
+
+	// True if the target is reached
+	TargetReached bool `json:"target_reached"`
+
+	// True if serving historical blocks
+	ServeHistoricalBlocks bool `json:"serve_historical_blocks"`
+
+	// Bytes left in the current time cycle
+	BytesLeftInCycle uint64 `json:"bytes_left_in_cycle"`
+
+	// Seconds left in the current time cycle
+	TimeLeftInCycle int64 `json:"time_left_in_cycle"`
+}
+
+// NetTotals represents the response to getnettotals
+type NetTotals struct {
+	// Total bytes received
+	TotalBytesRecv uint64 `json:"totalbytesrecv"`
+
+	// Total bytes sent
+	TotalBytesSent uint64 `json:"totalbytessent"`
+
+	// Current time in milliseconds since epoch
+	TimeMillis int64 `json:"timemillis"`
+
+	UploadTarget UploadTarget `json:"uploadtarget"`
+}
+
+// NodeAddress represents a known address of the node's address manager,
+// returned by getnodeaddresses
+type NodeAddress struct {
+	// The time the address was last seen, in seconds since epoch
+	Time int64 `json:"time"`
+
+	// The services offered by the node
+	Services uint64 `json:"services"`
+
+	// The address of the node
+	Address string `json:"address"`
+
+	// The port number of the node
+	Port uint16 `json:"port"`
+
+	// The network the node is on: ipv4, ipv6, onion, i2p or cjdns
+	Network string `json:"network"`
+}
+
+// RPCCommand represents a command being executed, see GetRPCInfo
+type RPCCommand struct {
+	// The name of the RPC command
+	Method string `json:"method"`
+
+	// The running time in microseconds
+	Duration int64 `json:"duration"`
+}
+
+// RPCInfo represents the response to getrpcinfo
+type RPCInfo struct {
+	// The commands being executed, including getrpcinfo itself
+	ActiveCommands []RPCCommand `json:"active_commands"`
+
+	// The complete file path to the debug log
+	LogPath string `json:"logpath"`
+}
+
+// AddNode adds a node to or removes it from the added nodes list, or
+// connects to it once. node is a host, host:port or IP:port.
+func (b *Bitcoind) AddNode(node string, command AddNodeCommand) error {
+	r, err := b.client.call("addnode", []interface{}{node, command})
+	return handleError(err, &r)
+}
+
+// DisconnectNode disconnects from the peer with the given address, as
+// reported by GetPeerInfo.
+func (b *Bitcoind) DisconnectNode(address string) error {
+	r, err := b.client.call("disconnectnode", []interface{}{address})
+	return handleError(err, &r)
+}
+
+// DisconnectNodeID disconnects from the peer with the given id, as reported
+// by GetPeerInfo.
+func (b *Bitcoind) DisconnectNodeID(id int64) error {
+	r, err := b.client.call("disconnectnode", []interface{}{"", id})
+	return handleError(err, &r)
+}
+
+// SetBan bans subnet, an IP or IP/netmask, for banTime. A zero banTime
+// uses the node's -bantime, 24 hours by default.
+func (b *Bitcoind) SetBan(subnet string, banTime time.Duration) error {
+	r, err := b.client.call("setban", []interface{}{subnet, "add", int64(banTime / time.Second)})
+	return handleError(err, &r)
+}
+
+// SetBanUntil bans subnet, an IP or IP/netmask, until the given time.
+func (b *Bitcoind) SetBanUntil(subnet string, until time.Time) error {
+	r, err := b.client.call("setban", []interface{}{subnet, "add", until.Unix(), true})
+	return handleError(err, &r)
+}
+
+// RemoveBan lifts the ban of subnet.
+func (b *Bitcoind) RemoveBan(subnet string) error {
+	r, err := b.client.call("setban", []interface{}{subnet, "remove"})
+	return handleError(err, &r)
+}
+
+// ListBanned returns the banned IPs and subnets.
+func (b *Bitcoind) ListBanned() (banned []BannedSubnet, err error) {
+	r, err := b.client.call("listbanned", nil)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &banned)
+	return
+}
+
+// ClearBanned lifts all the bans.
+func (b *Bitcoind) ClearBanned() error {
+	r, err := b.client.call("clearbanned", nil)
+	return handleError(err, &r)
+}
+
+// GetAddedNodeInfo returns information about the added nodes, or only
+// about node if not empty. Nodes connected with AddNodeOneTry are not
+// listed.
+func (b *Bitcoind) GetAddedNodeInfo(node string) (info []AddedNodeInfo, err error) {
+	var params []interface{}
+	if node != "" {
+		params = append(params, node)
+	}
+	r, err := b.client.call("getaddednodeinfo", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &info)
+	return
+}
+
+// GetNetTotals returns information about network traffic.
+func (b *Bitcoind) GetNetTotals() (totals NetTotals, err error) {
+	r, err := b.client.call("getnettotals", nil)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &totals)
+	return
+}
+
+// GetNodeAddresses returns up to count addresses known by the node, 0
+// returns all of them. network restricts them to a network (ipv4, ipv6,
+// onion, i2p or cjdns) if not empty.
+func (b *Bitcoind) GetNodeAddresses(count uint32, network string) (addresses []NodeAddress, err error) {
+	params := []interface{}{count}
+	if network != "" {
+		params = append(params, network)
+	}
+	r, err := b.client.call("getnodeaddresses", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &addresses)
+	return
+}
+
+// SetNetworkActive enables or disables all P2P network activity and returns
+// the new state.
+func (b *Bitcoind) SetNetworkActive(state bool) (active bool, err error) {
+	r, err := b.client.call("setnetworkactive", []bool{state})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &active)
+	return
+}
+
+// Ping requests that a ping is sent to all peers, to measure ping time.
+// Results are the pingtime and pingwait fields of GetPeerInfo.
+func (b *Bitcoind) Ping() error {
+	r, err := b.client.call("ping", nil)
+	return handleError(err, &r)
+}
+
+// Uptime returns how long the server has been running.
+func (b *Bitcoind) Uptime() (uptime time.Duration, err error) {
+	r, err := b.client.call("uptime", nil)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	var seconds int64
+	err = json.Unmarshal(r.Result, &seconds)
+	uptime = time.Duration(seconds) * time.Second
+	return
+}
+
+// GetRPCInfo returns details of the RPC server.
+func (b *Bitcoind) GetRPCInfo() (info RPCInfo, err error) {
+	r, err := b.client.call("getrpcinfo", nil)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &info)
+	return
+}
+
+// Logging enables the debug log categories of include and disables those of
+// exclude ("all" and "none" are accepted), and returns the state of every
+// category. Call it with no categories to only get the state.
+func (b *Bitcoind) Logging(include, exclude []string) (categories map[string]bool, err error) {
+	var params []interface{}
+	if len(include) > 0 || len(exclude) > 0 {
+		if include == nil {
+			include = []string{}
+		}
+		if exclude == nil {
+			exclude = []string{}
+		}
+		params = []interface{}{include, exclude}
+	}
+	r, err := b.client.call("logging", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &categories)
+	return
+}
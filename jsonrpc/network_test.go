@@ -0,0 +1,170 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Network", func() {
+	Describe("setban", func() {
+		Context("when success", func() {
+			var params [][]interface{}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				var req struct {
+					Params []interface{} `json:"params"`
+				}
+				json.Unmarshal(body, &req)
+				params = append(params, req.Params)
+				fmt.Fprintln(w, `{"result":null,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			errBan := bitcoindClient.SetBan("192.168.0.0/24", time.Hour)
+			errUntil := bitcoindClient.SetBanUntil("10.0.0.1", time.Unix(1800000000, 0))
+			errRemove := bitcoindClient.RemoveBan("10.0.0.1")
+			It("should not error", func() {
+				Expect(errBan).NotTo(HaveOccurred())
+				Expect(errUntil).NotTo(HaveOccurred())
+				Expect(errRemove).NotTo(HaveOccurred())
+			})
+			It("should send the ban commands", func() {
+				Expect(params).To(Equal([][]interface{}{
+					{"192.168.0.0/24", "add", 3600.0},
+					{"10.0.0.1", "add", 1800000000.0, true},
+					{"10.0.0.1", "remove"},
+				}))
+			})
+		})
+	})
+
+	Describe("listbanned", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":[{"address":"192.168.0.0/24","ban_created":1700000000,"banned_until":1700003600,"ban_duration":3600,"time_remaining":1200}],"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			banned, err := bitcoindClient.ListBanned()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return the bans", func() {
+				Expect(banned).To(Equal([]BannedSubnet{{Address: "192.168.0.0/24", BanCreated: 1700000000, BannedUntil: 1700003600, BanDuration: 3600, TimeRemaining: 1200}}))
+			})
+		})
+	})
+
+	Describe("getaddednodeinfo", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":[{"addednode":"node.example.com:8333","connected":true,"addresses":[{"address":"203.0.113.7:8333","connected":"outbound"}]}],"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			info, err := bitcoindClient.GetAddedNodeInfo("")
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return the added nodes", func() {
+				Expect(info).To(HaveLen(1))
+				Expect(info[0].AddedNode).To(Equal("node.example.com:8333"))
+				Expect(info[0].Addresses).To(Equal([]AddedNodeAddress{{Address: "203.0.113.7:8333", Connected: "outbound"}}))
+			})
+		})
+	})
+
+	Describe("getnettotals", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":{"totalbytesrecv":7841239,"totalbytessent":1238470,"timemillis":1700000000123,"uploadtarget":{"timeframe":86400,"target":0,"target_reached":false,"serve_historical_blocks":true,"bytes_left_in_cycle":0,"time_left_in_cycle":0}},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			totals, err := bitcoindClient.GetNetTotals()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return the totals", func() {
+				Expect(totals.TotalBytesRecv).To(Equal(uint64(7841239)))
+				Expect(totals.TimeMillis).To(Equal(int64(1700000000123)))
+				Expect(totals.UploadTarget.ServeHistoricalBlocks).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("uptime", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":93784,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			uptime, err := bitcoindClient.Uptime()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return a duration", func() {
+				Expect(uptime).To(Equal(93784 * time.Second))
+			})
+		})
+	})
+
+	Describe("logging", func() {
+		Context("when changing categories", func() {
+			var params []interface{}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				var req struct {
+					Params []interface{} `json:"params"`
+				}
+				json.Unmarshal(body, &req)
+				params = req.Params
+				fmt.Fprintln(w, `{"result":{"net":true,"mempool":false},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			categories, err := bitcoindClient.Logging([]string{"net"}, nil)
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should send both lists", func() {
+				Expect(params).To(Equal([]interface{}{[]interface{}{"net"}, []interface{}{}}))
+			})
+			It("should return the categories", func() {
+				Expect(categories).To(Equal(map[string]bool{"net": true, "mempool": false}))
+			})
+		})
+	})
+})
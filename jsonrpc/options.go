@@ -0,0 +1,199 @@
+package jsonrpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// An Option configures a client created with NewWithOptions.
+type Option func(*clientOptions) error
+
+// clientOptions represents the settings collected from Options
+type clientOptions struct {
+	user        string
+	passwd      string
+	cookiePath  string
+	path        string
+	useTLS      bool
+	tlsConfig   *tls.Config
+	httpClient  *http.Client
+	transport   http.RoundTripper
+	timeout     int
+	retry       *RetryPolicy
+	middlewares []Middleware
+}
+
+// WithBasicAuth authenticates with the rpcuser/rpcpassword (or rpcauth)
+// credentials bitcoind was configured with.
+func WithBasicAuth(user, passwd string) Option {
+	return func(o *clientOptions) error {
+		o.user = user
+		o.passwd = passwd
+		return nil
+	}
+}
+
+// WithCookieFile authenticates with the .cookie file bitcoind writes in its
+// data directory when no rpcpassword is set.
+// The file is read lazily and read again whenever it changes, so the client
+// keeps working after bitcoind restarts and rotates the cookie.
+func WithCookieFile(path string) Option {
+	return func(o *clientOptions) error {
+		if path == "" {
+			return errors.New("Bad option: empty cookie file path")
+		}
+		o.cookiePath = path
+		return nil
+	}
+}
+
+// WithPath posts requests to path on the server, eg "wallet/<name>" or the
+// path a reverse proxy serves bitcoind on. Wallet scopes the client below
+// that path.
+func WithPath(path string) Option {
+	return func(o *clientOptions) error {
+		o.path = strings.Trim(path, "/")
+		return nil
+	}
+}
+
+// WithTLS connects over HTTPS, verifying the server certificate with config.
+// A nil config verifies against the system roots.
+// It can't be combined with WithHTTPClient or WithTransport, configure
+// TLS on the supplied client instead.
+func WithTLS(config *tls.Config) Option {
+	return func(o *clientOptions) error {
+		o.useTLS = true
+		o.tlsConfig = config
+		return nil
+	}
+}
+
+// WithRootCAs connects over HTTPS, verifying the server certificate against
+// pool. Use it for nodes behind a TLS proxy with a private CA.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return WithTLS(&tls.Config{RootCAs: pool})
+}
+
+// WithHTTPClient sends requests with httpClient. Combine it with
+// WithTLS(nil) to use the https scheme.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *clientOptions) error {
+		if httpClient == nil {
+			return errors.New("Bad option: nil http client")
+		}
+		o.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithTransport sends requests through transport, eg to share a connection
+// pool or add instrumentation. Combine it with WithTLS(nil) to use the https
+// scheme.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *clientOptions) error {
+		if transport == nil {
+			return errors.New("Bad option: nil transport")
+		}
+		o.transport = transport
+		return nil
+	}
+}
+
+// WithTimeout sets the per request timeout in seconds. Zero disables it.
+// Defaults to RPCCLIENT_TIMEOUT.
+func WithTimeout(timeout int) Option {
+	return func(o *clientOptions) error {
+		if timeout < 0 {
+			return errors.New("Bad option: negative timeout")
+		}
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithRetry retries calls failing with a retryable error (see IsRetryable),
+// eg while bitcoind is warming up after a restart.
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *clientOptions) error {
+		if policy.MaxRetries < 0 || policy.MinBackoff < 0 || policy.MaxBackoff < 0 {
+			return errors.New("Bad option: negative retry policy")
+		}
+		o.retry = &policy
+		return nil
+	}
+}
+
+// NewWithOptions return a new bitcoind configured with opts.
+// Unlike New, TLS connections verify the server certificate.
+func NewWithOptions(host string, port int, opts ...Option) (*Bitcoind, error) {
+	o := clientOptions{timeout: RPCCLIENT_TIMEOUT}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+	rpcClient, err := newClientWithOptions(host, port, &o)
+	if err != nil {
+		return nil, err
+	}
+	return &Bitcoind{client: rpcClient}, nil
+}
+
+func newClientWithOptions(host string, port int, o *clientOptions) (c *rpcClient, err error) {
+	if len(host) == 0 {
+		err = errors.New("Bad call missing argument host")
+		return
+	}
+	if o.httpClient != nil && o.transport != nil {
+		err = errors.New("Bad options: WithHTTPClient and WithTransport are exclusive")
+		return
+	}
+	if o.tlsConfig != nil && (o.httpClient != nil || o.transport != nil) {
+		err = errors.New("Bad options: WithTLS config can't be applied to a supplied http client or transport")
+		return
+	}
+	if o.cookiePath != "" && (o.user != "" || o.passwd != "") {
+		err = errors.New("Bad options: WithBasicAuth and WithCookieFile are exclusive")
+		return
+	}
+
+	scheme := "http://"
+	if o.useTLS {
+		scheme = "https://"
+	}
+	httpClient := o.httpClient
+	switch {
+	case httpClient != nil:
+	case o.transport != nil:
+		httpClient = &http.Client{Transport: o.transport}
+	case o.tlsConfig != nil:
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = o.tlsConfig.Clone()
+		httpClient = &http.Client{Transport: t}
+	default:
+		httpClient = &http.Client{}
+	}
+
+	serverAddr := fmt.Sprintf("%s%s:%d", scheme, host, port)
+	if o.path != "" {
+		serverAddr += "/" + o.path
+	}
+	c = &rpcClient{
+		serverAddr:  serverAddr,
+		user:        o.user,
+		passwd:      o.passwd,
+		httpClient:  httpClient,
+		timeout:     o.timeout,
+		retry:       o.retry,
+		middlewares: o.middlewares,
+	}
+	if o.cookiePath != "" {
+		c.cookie = &cookieFile{path: o.cookiePath}
+	}
+	return
+}
@@ -0,0 +1,160 @@
+package jsonrpc
+
+import (
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Options", func() {
+	Describe("cookie file authentication", func() {
+		Context("when bitcoind rotates the cookie", func() {
+			var passwords []string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				user, passwd, _ := r.BasicAuth()
+				if user != "__cookie__" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				passwords = append(passwords, passwd)
+				fmt.Fprintln(w, `{"result":42,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			dir, err := os.MkdirTemp("", "bitcoind")
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer os.RemoveAll(dir)
+			cookiePath := filepath.Join(dir, ".cookie")
+			os.WriteFile(cookiePath, []byte("__cookie__:first"), 0600)
+
+			bitcoindClient, err := NewWithOptions(host, port, WithCookieFile(cookiePath))
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			_, err1 := bitcoindClient.GetBlockCount()
+			os.WriteFile(cookiePath, []byte("__cookie__:second\n"), 0600)
+			os.Chtimes(cookiePath, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+			_, err2 := bitcoindClient.GetBlockCount()
+			It("calls should not error", func() {
+				Expect(err1).NotTo(HaveOccurred())
+				Expect(err2).NotTo(HaveOccurred())
+			})
+			It("should use the new cookie", func() {
+				Expect(passwords).To(Equal([]string{"first", "second"}))
+			})
+		})
+
+		Context("when bitcoind rotates the cookie keeping its size and mtime", func() {
+			var passwords []string
+			var cookiePath string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, passwd, _ := r.BasicAuth()
+				passwords = append(passwords, passwd)
+				cookie, _ := os.ReadFile(cookiePath)
+				if "__cookie__:"+passwd != string(cookie) {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				fmt.Fprintln(w, `{"result":42,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			dir, err := os.MkdirTemp("", "bitcoind")
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer os.RemoveAll(dir)
+			cookiePath = filepath.Join(dir, ".cookie")
+			os.WriteFile(cookiePath, []byte("__cookie__:first"), 0600)
+			fi, _ := os.Stat(cookiePath)
+
+			bitcoindClient, _ := NewWithOptions(host, port, WithCookieFile(cookiePath))
+			_, err1 := bitcoindClient.GetBlockCount()
+			os.WriteFile(cookiePath, []byte("__cookie__:other"), 0600)
+			os.Chtimes(cookiePath, fi.ModTime(), fi.ModTime())
+			_, err2 := bitcoindClient.GetBlockCount()
+			It("calls should not error", func() {
+				Expect(err1).NotTo(HaveOccurred())
+				Expect(err2).NotTo(HaveOccurred())
+			})
+			It("should retry with the new cookie once rejected", func() {
+				Expect(passwords).To(Equal([]string{"first", "first", "other"}))
+			})
+		})
+
+		Context("when combined with basic auth", func() {
+			_, err := NewWithOptions("127.0.0.1", 8332, WithCookieFile("/tmp/.cookie"), WithBasicAuth("user", "passwd"))
+			It("error should occured", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("TLS", func() {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"result":42,"error":null,"id":1400432805294160077}`)
+		}))
+		defer ts.Close()
+		p := strings.Split(ts.URL, ":")
+		host := p[1][2:]
+		port, _ := strconv.ParseInt(p[2], 10, 64)
+
+		Context("when the CA is trusted", func() {
+			pool := x509.NewCertPool()
+			pool.AddCert(ts.Certificate())
+			bitcoindClient, _ := NewWithOptions(host, int(port), WithRootCAs(pool))
+			count, err := bitcoindClient.GetBlockCount()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(count).To(Equal(uint64(42)))
+			})
+		})
+
+		Context("when the CA is unknown", func() {
+			bitcoindClient, _ := NewWithOptions(host, int(port), WithTLS(nil))
+			_, err := bitcoindClient.GetBlockCount()
+			It("error should occured", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("path", func() {
+		var paths []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			fmt.Fprintln(w, `{"result":42,"error":null,"id":1400432805294160077}`)
+		}))
+		defer ts.Close()
+		p := strings.Split(ts.URL, ":")
+		host := p[1][2:]
+		port, _ := strconv.ParseInt(p[2], 10, 64)
+
+		bitcoindClient, _ := NewWithOptions(host, int(port), WithPath("/bitcoind/"))
+		_, err := bitcoindClient.GetBlockCount()
+		_, errWallet := bitcoindClient.Wallet("test").GetBalance("", 1)
+		It("should post to the path", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errWallet).NotTo(HaveOccurred())
+			Expect(paths).To(Equal([]string{"/bitcoind", "/bitcoind/wallet/test"}))
+		})
+	})
+})
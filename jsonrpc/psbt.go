@@ -0,0 +1,360 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// encodePsbt returns the base64 serialization of p
+func encodePsbt(p *psbt.Packet) (string, error) {
+	if p == nil {
+		return "", errors.New("Bad call nil psbt")
+	}
+	return p.B64Encode()
+}
+
+// encodePsbts returns the base64 serialization of packets
+func encodePsbts(packets []*psbt.Packet) (b64s []string, err error) {
+	b64s = make([]string, len(packets))
+	for i, p := range packets {
+		if b64s[i], err = encodePsbt(p); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// decodePsbt decodes a base64 serialized PSBT
+func decodePsbt(b64 string) (*psbt.Packet, error) {
+	return psbt.NewFromRawBytes(strings.NewReader(b64), true)
+}
+
+// unmarshalPsbt unmarshals a JSON base64 string into a PSBT
+func unmarshalPsbt(data json.RawMessage) (*psbt.Packet, error) {
+	var b64 string
+	if err := json.Unmarshal(data, &b64); err != nil {
+		return nil, err
+	}
+	return decodePsbt(b64)
+}
+
+// CreatePsbt creates an unsigned PSBT spending inputs and paying outputs.
+// When replaceable is set the inputs signal BIP125 (ignored for inputs with
+// an explicit sequence).
+func (b *Bitcoind) CreatePsbt(inputs []TxInput, outputs []Output, locktime uint32, replaceable bool) (*psbt.Packet, error) {
+	if inputs == nil {
+		inputs = []TxInput{}
+	}
+	r, err := b.client.call("createpsbt", []interface{}{inputs, outputs, locktime, replaceable})
+	if err = handleError(err, &r); err != nil {
+		return nil, err
+	}
+	return unmarshalPsbt(r.Result)
+}
+
+// ConvertToPsbt converts tx to a PSBT. Signatures of tx are discarded when
+// permitSigData is set, otherwise the node fails on signed transactions.
+func (b *Bitcoind) ConvertToPsbt(tx *wire.MsgTx, permitSigData bool) (*psbt.Packet, error) {
+	txHex, err := EncodeTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	r, err := b.client.call("converttopsbt", []interface{}{txHex, permitSigData})
+	if err = handleError(err, &r); err != nil {
+		return nil, err
+	}
+	return unmarshalPsbt(r.Result)
+}
+
+// PsbtWitnessUtxo represents the output spent by a segwit PSBT input
+type PsbtWitnessUtxo struct {
+	// The value
+	Amount Amount `json:"amount"`
+
+	// The script key
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+}
+
+// PsbtBip32Deriv represents a public key derivation path
+type PsbtBip32Deriv struct {
+	// The public key with the derivation path as the value
+	Pubkey string `json:"pubkey"`
+
+	// The fingerprint of the master key
+	MasterFingerprint string `json:"master_fingerprint"`
+
+	// The path
+	Path string `json:"path"`
+}
+
+// DecodedPsbtInput represents an input of a decoded PSBT
+type DecodedPsbtInput struct {
+	// Decoded network transaction for non-witness UTXOs
+	NonWitnessUtxo *RawTransaction `json:"non_witness_utxo,omitempty"`
+
+	// Transaction output for witness UTXOs
+	WitnessUtxo *PsbtWitnessUtxo `json:"witness_utxo,omitempty"`
+
+	// Signatures keyed by public key
+	PartialSignatures map[string]string `json:"partial_signatures,omitempty"`
+
+	// The sighash type to be used
+	Sighash string `json:"sighash,omitempty"`
+
+	// The redeem script
+	RedeemScript *ScriptPubKey `json:"redeem_script,omitempty"`
+
+	// The witness script
+	WitnessScript *ScriptPubKey `json:"witness_script,omitempty"`
+
+	// Public key derivation paths
+	Bip32Derivs []PsbtBip32Deriv `json:"bip32_derivs,omitempty"`
+
+	// The final scriptSig
+	FinalScriptSig *ScriptSig `json:"final_scriptSig,omitempty"`
+
+	// The final witness, hex encoded items
+	FinalScriptWitness []string `json:"final_scriptwitness,omitempty"`
+
+	// Hex-encoded signature for the Taproot key path spend
+	TaprootKeyPathSig string `json:"taproot_key_path_sig,omitempty"`
+
+	// The hex-encoded Taproot x-only internal key
+	TaprootInternalKey string `json:"taproot_internal_key,omitempty"`
+
+	// The hex-encoded Taproot merkle root
+	TaprootMerkleRoot string `json:"taproot_merkle_root,omitempty"`
+
+	// The unknown input fields
+	Unknown map[string]string `json:"unknown,omitempty"`
+}
+
+// DecodedPsbtOutput represents an output of a decoded PSBT
+type DecodedPsbtOutput struct {
+	// The redeem script
+	RedeemScript *ScriptPubKey `json:"redeem_script,omitempty"`
+
+	// The witness script
+	WitnessScript *ScriptPubKey `json:"witness_script,omitempty"`
+
+	// Public key derivation paths
+	Bip32Derivs []PsbtBip32Deriv `json:"bip32_derivs,omitempty"`
+
+	// The hex-encoded Taproot x-only internal key
+	TaprootInternalKey string `json:"taproot_internal_key,omitempty"`
+
+	// The unknown output fields
+	Unknown map[string]string `json:"unknown,omitempty"`
+}
+
+// DecodedPsbt represents a response to decodepsbt call
+type DecodedPsbt struct {
+	// The decoded network-serialized unsigned transaction
+	Tx RawTransaction `json:"tx"`
+
+	// The PSBT version number
+	PsbtVersion uint32 `json:"psbt_version"`
+
+	// The unknown global fields
+	Unknown map[string]string `json:"unknown,omitempty"`
+
+	// The inputs
+	Inputs []DecodedPsbtInput `json:"inputs"`
+
+	// The outputs
+	Outputs []DecodedPsbtOutput `json:"outputs"`
+
+	// The transaction fee paid if all UTXOs slots in the PSBT have been filled
+	Fee *Amount `json:"fee,omitempty"`
+}
+
+// DecodePsbt returns bitcoind's JSON representation of p.
+func (b *Bitcoind) DecodePsbt(p *psbt.Packet) (decoded DecodedPsbt, err error) {
+	b64, err := encodePsbt(p)
+	if err != nil {
+		return
+	}
+	r, err := b.client.call("decodepsbt", []string{b64})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &decoded)
+	return
+}
+
+// PsbtMissing represents what is missing to finalize a PSBT input
+type PsbtMissing struct {
+	// Public key IDs of public keys whose BIP 32 derivation paths are missing
+	Pubkeys []string `json:"pubkeys,omitempty"`
+
+	// Public key IDs of public keys whose signatures are missing
+	Signatures []string `json:"signatures,omitempty"`
+
+	// Hash160 of the redeemScript that is missing
+	RedeemScript string `json:"redeemscript,omitempty"`
+
+	// SHA256 of the witnessScript that is missing
+	WitnessScript string `json:"witnessscript,omitempty"`
+}
+
+// AnalyzedPsbtInput represents the analysis of a PSBT input
+type AnalyzedPsbtInput struct {
+	// Whether a UTXO is provided
+	HasUtxo bool `json:"has_utxo"`
+
+	// Whether the input is finalized
+	IsFinal bool `json:"is_final"`
+
+	// Things that are missing that are required to complete this input
+	Missing *PsbtMissing `json:"missing,omitempty"`
+
+	// Role of the next person that this input needs to go to
+	Next string `json:"next,omitempty"`
+}
+
+// AnalyzedPsbt represents a response to analyzepsbt call
+type AnalyzedPsbt struct {
+	// The inputs
+	Inputs []AnalyzedPsbtInput `json:"inputs"`
+
+	// Estimated vsize of the final signed transaction
+	EstimatedVsize uint32 `json:"estimated_vsize,omitempty"`
+
+	// Estimated feerate of the final signed transaction per kvB
+	EstimatedFeeRate *Amount `json:"estimated_feerate,omitempty"`
+
+	// The transaction fee paid, shown only if all UTXO slots in the PSBT have been filled
+	Fee *Amount `json:"fee,omitempty"`
+
+	// Role of the next person that this psbt needs to go to
+	Next string `json:"next"`
+
+	// Error message (if there is one)
+	Error string `json:"error,omitempty"`
+}
+
+// AnalyzePsbt analyzes p and reports what is missing and who should act next.
+func (b *Bitcoind) AnalyzePsbt(p *psbt.Packet) (analyzed AnalyzedPsbt, err error) {
+	b64, err := encodePsbt(p)
+	if err != nil {
+		return
+	}
+	r, err := b.client.call("analyzepsbt", []string{b64})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &analyzed)
+	return
+}
+
+// CombinePsbt combines multiple partially signed versions of the same
+// transaction into one.
+func (b *Bitcoind) CombinePsbt(packets []*psbt.Packet) (*psbt.Packet, error) {
+	b64s, err := encodePsbts(packets)
+	if err != nil {
+		return nil, err
+	}
+	r, err := b.client.call("combinepsbt", []interface{}{b64s})
+	if err = handleError(err, &r); err != nil {
+		return nil, err
+	}
+	return unmarshalPsbt(r.Result)
+}
+
+// FinalizePsbtResult represents a response to finalizepsbt call
+type FinalizePsbtResult struct {
+	// The PSBT, only set when it was not extracted
+	Psbt *psbt.Packet
+
+	// The extracted network transaction, only set when it was extracted
+	Tx *wire.MsgTx
+
+	// If the transaction has a complete set of signatures
+	Complete bool
+}
+
+// FinalizePsbt finalizes the inputs of p. When extract is set and p is
+// complete, the network transaction is extracted into the result Tx.
+func (b *Bitcoind) FinalizePsbt(p *psbt.Packet, extract bool) (result FinalizePsbtResult, err error) {
+	b64, err := encodePsbt(p)
+	if err != nil {
+		return
+	}
+	r, err := b.client.call("finalizepsbt", []interface{}{b64, extract})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	var res struct {
+		Psbt     string `json:"psbt"`
+		Hex      string `json:"hex"`
+		Complete bool   `json:"complete"`
+	}
+	if err = json.Unmarshal(r.Result, &res); err != nil {
+		return
+	}
+	result.Complete = res.Complete
+	if res.Psbt != "" {
+		if result.Psbt, err = decodePsbt(res.Psbt); err != nil {
+			return
+		}
+	}
+	if res.Hex != "" {
+		result.Tx, err = DecodeTx(res.Hex)
+	}
+	return
+}
+
+// JoinPsbts joins the inputs and outputs of distinct PSBTs into one.
+func (b *Bitcoind) JoinPsbts(packets []*psbt.Packet) (*psbt.Packet, error) {
+	b64s, err := encodePsbts(packets)
+	if err != nil {
+		return nil, err
+	}
+	r, err := b.client.call("joinpsbts", []interface{}{b64s})
+	if err = handleError(err, &r); err != nil {
+		return nil, err
+	}
+	return unmarshalPsbt(r.Result)
+}
+
+// UtxoUpdatePsbt updates p with the UTXOs found in the UTXO set, txindex or
+// mempool, and with the derivation data of descriptors (may be nil).
+func (b *Bitcoind) UtxoUpdatePsbt(p *psbt.Packet, descriptors []string) (*psbt.Packet, error) {
+	b64, err := encodePsbt(p)
+	if err != nil {
+		return nil, err
+	}
+	params := []interface{}{b64}
+	if len(descriptors) > 0 {
+		params = append(params, descriptors)
+	}
+	r, err := b.client.call("utxoupdatepsbt", params)
+	if err = handleError(err, &r); err != nil {
+		return nil, err
+	}
+	return unmarshalPsbt(r.Result)
+}
+
+// WalletProcessPsbtPacket is WalletProcessPsbt working on psbt.Packet: it
+// updates p with wallet data, signs it when sign is set and finalizes it
+// when finalize is set. It reports whether the transaction is complete.
+func (b *Bitcoind) WalletProcessPsbtPacket(p *psbt.Packet, sign bool, sigHashType string, bip32derivs bool, finalize bool) (processed *psbt.Packet, complete bool, err error) {
+	b64, err := encodePsbt(p)
+	if err != nil {
+		return
+	}
+	if sigHashType == "" {
+		sigHashType = "DEFAULT"
+	}
+	res, err := b.WalletProcessPsbt(b64, sign, sigHashType, bip32derivs, finalize)
+	if err != nil {
+		return
+	}
+	processed, err = decodePsbt(res.Psbt)
+	complete = res.Complete
+	return
+}
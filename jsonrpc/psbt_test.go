@@ -0,0 +1,100 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PSBT", func() {
+	unsigned := wire.NewMsgTx(2)
+	unsigned.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	unsigned.AddTxOut(wire.NewTxOut(12345, []byte{0x6a}))
+	packet, _ := psbt.NewFromUnsignedTx(unsigned)
+	b64, _ := packet.B64Encode()
+
+	Describe("combinepsbt", func() {
+		Context("when success", func() {
+			var params [][]string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				var req struct {
+					Params [][]string `json:"params"`
+				}
+				json.Unmarshal(body, &req)
+				params = req.Params
+				fmt.Fprintf(w, `{"result":"%s","error":null,"id":1400432805294160077}`, b64)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			combined, err := bitcoindClient.CombinePsbt([]*psbt.Packet{packet, packet})
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should send base64 PSBTs", func() {
+				Expect(params).To(Equal([][]string{{b64, b64}}))
+			})
+			It("should decode the PSBT", func() {
+				Expect(combined.UnsignedTx.TxHash()).To(Equal(unsigned.TxHash()))
+			})
+		})
+	})
+
+	Describe("finalizepsbt", func() {
+		Context("when extracted", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"result":{"hex":"%s","complete":true},"error":null,"id":1400432805294160077}`, serializeTx(unsigned))
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			result, err := bitcoindClient.FinalizePsbt(packet, true)
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return the network transaction", func() {
+				Expect(result.Complete).To(BeTrue())
+				Expect(result.Psbt).To(BeNil())
+				Expect(result.Tx.TxHash()).To(Equal(unsigned.TxHash()))
+			})
+		})
+	})
+
+	Describe("analyzepsbt", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":{"inputs":[{"has_utxo":true,"is_final":false,"missing":{"signatures":["a0b1c2d3e4f5a0b1c2d3e4f5a0b1c2d3e4f5a0b1"]},"next":"signer"}],"estimated_vsize":110,"estimated_feerate":0.00010000,"fee":0.00001100,"next":"signer"},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			analyzed, err := bitcoindClient.AnalyzePsbt(packet)
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return the analysis", func() {
+				Expect(analyzed.Next).To(Equal("signer"))
+				Expect(*analyzed.Fee).To(Equal(Amount(1100)))
+				Expect(analyzed.Inputs[0].Missing.Signatures).To(HaveLen(1))
+			})
+		})
+	})
+})
@@ -0,0 +1,410 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// EncodeTx returns the hex serialization of tx, with witness data if any.
+func EncodeTx(tx *wire.MsgTx) (string, error) {
+	if tx == nil {
+		return "", errors.New("Bad call nil transaction")
+	}
+	var buf bytes.Buffer
+	buf.Grow(tx.SerializeSize())
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeTx decodes a hex serialized transaction.
+// Transactions without inputs (eg from createrawtransaction) are ambiguous
+// with the segwit marker, so, like bitcoind, the legacy serialization is
+// tried when the witness one doesn't consume the whole input.
+func DecodeTx(txHex string) (*wire.MsgTx, error) {
+	serialized, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, err
+	}
+	tx := &wire.MsgTx{}
+	r := bytes.NewReader(serialized)
+	if err = tx.Deserialize(r); err == nil && r.Len() == 0 {
+		return tx, nil
+	}
+	tx = &wire.MsgTx{}
+	r = bytes.NewReader(serialized)
+	if errNoWitness := tx.DeserializeNoWitness(r); errNoWitness == nil && r.Len() == 0 {
+		return tx, nil
+	}
+	if err == nil {
+		err = errors.New("Bad transaction: trailing data")
+	}
+	return nil, err
+}
+
+// unmarshalTx unmarshals a JSON hex string into a transaction
+func unmarshalTx(data json.RawMessage) (*wire.MsgTx, error) {
+	var txHex string
+	if err := json.Unmarshal(data, &txHex); err != nil {
+		return nil, err
+	}
+	return DecodeTx(txHex)
+}
+
+// GetRawTransactionMsg returns the transaction txId, decoded.
+func (b *Bitcoind) GetRawTransactionMsg(txId string) (*wire.MsgTx, error) {
+	r, err := b.client.call("getrawtransaction", []interface{}{txId, 0})
+	if err = handleError(err, &r); err != nil {
+		return nil, err
+	}
+	return unmarshalTx(r.Result)
+}
+
+// CreateRawTransaction creates an unsigned transaction spending inputs and
+// paying outputs. When replaceable is set the inputs signal BIP125 (ignored
+// for inputs with an explicit sequence).
+func (b *Bitcoind) CreateRawTransaction(inputs []TxInput, outputs []Output, locktime uint32, replaceable bool) (*wire.MsgTx, error) {
+	if inputs == nil {
+		inputs = []TxInput{}
+	}
+	r, err := b.client.call("createrawtransaction", []interface{}{inputs, outputs, locktime, replaceable})
+	if err = handleError(err, &r); err != nil {
+		return nil, err
+	}
+	return unmarshalTx(r.Result)
+}
+
+// DecodeRawTransaction returns bitcoind's JSON representation of tx.
+func (b *Bitcoind) DecodeRawTransaction(tx *wire.MsgTx) (decoded RawTransaction, err error) {
+	txHex, err := EncodeTx(tx)
+	if err != nil {
+		return
+	}
+	r, err := b.client.call("decoderawtransaction", []string{txHex})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &decoded)
+	return
+}
+
+// SegwitScript represents the P2WSH wrapping of a decoded script
+type SegwitScript struct {
+	Asm        string   `json:"asm"`
+	Hex        string   `json:"hex"`
+	Type       string   `json:"type"`
+	Address    string   `json:"address,omitempty"`
+	Desc       string   `json:"desc,omitempty"`
+	P2shSegwit string   `json:"p2sh-segwit,omitempty"`
+	ReqSigs    int      `json:"reqSigs,omitempty"`
+	Addresses  []string `json:"addresses,omitempty"`
+}
+
+// DecodedScript represents a response to decodescript call
+type DecodedScript struct {
+	// Script public key
+	Asm string `json:"asm"`
+
+	// Inferred descriptor for the script
+	Desc string `json:"desc,omitempty"`
+
+	// The output type (e.g. nonstandard, pubkey, pubkeyhash, scripthash, multisig, witness_v0_keyhash, witness_v1_taproot...)
+	Type string `json:"type"`
+
+	// The Bitcoin address (only if a well-defined address exists)
+	Address string `json:"address,omitempty"`
+
+	// Address of P2SH script wrapping this redeem script (not returned for types that should not be wrapped)
+	P2sh string `json:"p2sh,omitempty"`
+
+	// Result of a witness script public key wrapping this redeem script (not returned for types that should not be wrapped)
+	Segwit *SegwitScript `json:"segwit,omitempty"`
+}
+
+// DecodeScript decodes a script.
+func (b *Bitcoind) DecodeScript(script []byte) (decoded DecodedScript, err error) {
+	r, err := b.client.call("decodescript", []string{hex.EncodeToString(script)})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &decoded)
+	return
+}
+
+// FundRawTransactionOptions represents the optional fundrawtransaction
+// arguments, they are the same as the walletcreatefundedpsbt ones.
+// https://bitcoincore.org/en/doc/26.0.0/rpc/rawtransactions/fundrawtransaction/
+type FundRawTransactionOptions = FundedPsbtOptions
+
+// FundRawTransactionResult represents a response to fundrawtransaction call
+type FundRawTransactionResult struct {
+	// The funded transaction
+	Tx *wire.MsgTx
+
+	// Fee the resulting transaction pays
+	Fee Amount
+
+	// The position of the added change output, or -1
+	ChangePos int
+}
+
+// FundRawTransaction adds inputs from the wallet to tx until it pays its
+// outputs, adding at most one change output.
+// opts may be nil to use the node defaults.
+func (b *Bitcoind) FundRawTransaction(tx *wire.MsgTx, opts *FundRawTransactionOptions) (result FundRawTransactionResult, err error) {
+	txHex, err := EncodeTx(tx)
+	if err != nil {
+		return
+	}
+	params := []interface{}{txHex}
+	if opts != nil {
+		params = append(params, opts)
+	}
+	r, err := b.client.call("fundrawtransaction", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	var res struct {
+		Hex       string `json:"hex"`
+		Fee       Amount `json:"fee"`
+		ChangePos int    `json:"changepos"`
+	}
+	if err = json.Unmarshal(r.Result, &res); err != nil {
+		return
+	}
+	result.Fee, result.ChangePos = res.Fee, res.ChangePos
+	result.Tx, err = DecodeTx(res.Hex)
+	return
+}
+
+// PrevTx represents a previous output spent by a transaction being signed,
+// for outputs unknown to the node
+type PrevTx struct {
+	// The transaction id
+	Txid string `json:"txid"`
+
+	// The output number
+	Vout uint32 `json:"vout"`
+
+	// Script key, hex encoded
+	ScriptPubKey string `json:"scriptPubKey"`
+
+	// Redeem script, hex encoded (required for P2SH)
+	RedeemScript string `json:"redeemScript,omitempty"`
+
+	// Witness script, hex encoded (required for P2WSH or P2SH-P2WSH)
+	WitnessScript string `json:"witnessScript,omitempty"`
+
+	// The amount spent (required for segwit inputs)
+	Amount Amount `json:"amount,omitempty"`
+}
+
+// SignError represents a script verification error of a signed input
+type SignError struct {
+	// The hash of the referenced, previous transaction
+	Txid string `json:"txid"`
+
+	// The index of the output to spent and used as input
+	Vout uint32 `json:"vout"`
+
+	// The hex-encoded signature script
+	ScriptSig string `json:"scriptSig"`
+
+	// The input sequence number
+	Sequence uint32 `json:"sequence"`
+
+	// Verification or signing error related to the input
+	Error string `json:"error"`
+}
+
+// SignRawTransactionResult represents a response to signrawtransactionwith* calls
+type SignRawTransactionResult struct {
+	// The signed transaction
+	Tx *wire.MsgTx
+
+	// If the transaction has a complete set of signatures
+	Complete bool
+
+	// Script verification errors (if there are any)
+	Errors []SignError
+}
+
+// SignRawTransactionWithKey signs the inputs of tx with privKeys (WIF).
+// prevTxs may be nil when the node knows the spent outputs, sigHashType may
+// be "" for the default.
+func (b *Bitcoind) SignRawTransactionWithKey(tx *wire.MsgTx, privKeys []string, prevTxs []PrevTx, sigHashType string) (SignRawTransactionResult, error) {
+	return b.signRawTransaction("signrawtransactionwithkey", tx, privKeys, prevTxs, sigHashType)
+}
+
+// SignRawTransactionWithWallet signs the inputs of tx with the wallet keys.
+// prevTxs may be nil when the node knows the spent outputs, sigHashType may
+// be "" for the default.
+func (b *Bitcoind) SignRawTransactionWithWallet(tx *wire.MsgTx, prevTxs []PrevTx, sigHashType string) (SignRawTransactionResult, error) {
+	return b.signRawTransaction("signrawtransactionwithwallet", tx, nil, prevTxs, sigHashType)
+}
+
+func (b *Bitcoind) signRawTransaction(method string, tx *wire.MsgTx, privKeys []string, prevTxs []PrevTx, sigHashType string) (result SignRawTransactionResult, err error) {
+	txHex, err := EncodeTx(tx)
+	if err != nil {
+		return
+	}
+	params := []interface{}{txHex}
+	if method == "signrawtransactionwithkey" {
+		if privKeys == nil {
+			privKeys = []string{}
+		}
+		params = append(params, privKeys)
+	}
+	if prevTxs == nil {
+		prevTxs = []PrevTx{}
+	}
+	params = append(params, prevTxs)
+	if sigHashType != "" {
+		params = append(params, sigHashType)
+	}
+	r, err := b.client.call(method, params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	var res struct {
+		Hex      string      `json:"hex"`
+		Complete bool        `json:"complete"`
+		Errors   []SignError `json:"errors"`
+	}
+	if err = json.Unmarshal(r.Result, &res); err != nil {
+		return
+	}
+	result.Complete, result.Errors = res.Complete, res.Errors
+	result.Tx, err = DecodeTx(res.Hex)
+	return
+}
+
+// SendRawTransaction submits tx to the local node and network and returns
+// its id. maxFeeRate (per kvB) rejects transactions paying a higher fee
+// rate, nil uses the node default and 0 accepts any fee rate.
+func (b *Bitcoind) SendRawTransaction(tx *wire.MsgTx, maxFeeRate *Amount) (txID string, err error) {
+	txHex, err := EncodeTx(tx)
+	if err != nil {
+		return
+	}
+	params := []interface{}{txHex}
+	if maxFeeRate != nil {
+		params = append(params, *maxFeeRate)
+	}
+	r, err := b.client.call("sendrawtransaction", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &txID)
+	return
+}
+
+// MempoolAcceptFees represents the fees of a transaction accepted in the mempool
+type MempoolAcceptFees struct {
+	// Transaction fee
+	Base Amount `json:"base"`
+
+	// The effective feerate per kvB, may differ from the base feerate if the transaction was part of a package
+	EffectiveFeeRate Amount `json:"effective-feerate,omitempty"`
+
+	// The wtxids of the transactions whose fees and vsizes are included in the effective feerate
+	EffectiveIncludes []string `json:"effective-includes,omitempty"`
+}
+
+// MempoolAcceptResult represents the result of testmempoolaccept for a transaction
+type MempoolAcceptResult struct {
+	// The transaction id
+	Txid string `json:"txid"`
+
+	// The transaction witness hash
+	Wtxid string `json:"wtxid"`
+
+	// Package validation error, if any (only possible if the transactions were a package)
+	PackageError string `json:"package-error,omitempty"`
+
+	// Whether this tx would be accepted to the mempool and pass client-specified maxfeerate
+	Allowed bool `json:"allowed"`
+
+	// Virtual transaction size as defined in BIP 141 (only present when allowed)
+	Vsize uint32 `json:"vsize,omitempty"`
+
+	// Transaction fees (only present when allowed)
+	Fees *MempoolAcceptFees `json:"fees,omitempty"`
+
+	// Rejection string (only present when not allowed)
+	RejectReason string `json:"reject-reason,omitempty"`
+}
+
+// TestMempoolAccept returns whether txs would be accepted by the mempool,
+// without submitting them. txs may be a package of dependent transactions,
+// sorted topologically. maxFeeRate is the same as in SendRawTransaction.
+func (b *Bitcoind) TestMempoolAccept(txs []*wire.MsgTx, maxFeeRate *Amount) (results []MempoolAcceptResult, err error) {
+	rawTxs := make([]string, len(txs))
+	for i, tx := range txs {
+		if rawTxs[i], err = EncodeTx(tx); err != nil {
+			return
+		}
+	}
+	params := []interface{}{rawTxs}
+	if maxFeeRate != nil {
+		params = append(params, *maxFeeRate)
+	}
+	r, err := b.client.call("testmempoolaccept", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &results)
+	return
+}
+
+// SubmitPackageTxResult represents the result of submitpackage for a transaction
+type SubmitPackageTxResult struct {
+	// The transaction hash in hex
+	Txid string `json:"txid"`
+
+	// The wtxid of a different transaction with the same txid but different witness found in the mempool
+	OtherWtxid string `json:"other-wtxid,omitempty"`
+
+	// Sigops-adjusted virtual transaction size
+	Vsize uint32 `json:"vsize,omitempty"`
+
+	// Transaction fees
+	Fees *MempoolAcceptFees `json:"fees,omitempty"`
+
+	// The transaction error string, if it was rejected by the mempool
+	Error string `json:"error,omitempty"`
+}
+
+// SubmitPackageResult represents a response to submitpackage call
+type SubmitPackageResult struct {
+	// The transaction package result message, "success" indicates all transactions were accepted into or are already in the mempool
+	PackageMsg string `json:"package_msg,omitempty"`
+
+	// Transaction results keyed by wtxid
+	TxResults map[string]SubmitPackageTxResult `json:"tx-results"`
+
+	// List of txids of replaced transactions
+	ReplacedTransactions []string `json:"replaced-transactions,omitempty"`
+}
+
+// SubmitPackage submits a package of raw transactions (a child with its
+// unconfirmed parents, sorted topologically) to the local node.
+func (b *Bitcoind) SubmitPackage(txs []*wire.MsgTx) (result SubmitPackageResult, err error) {
+	rawTxs := make([]string, len(txs))
+	for i, tx := range txs {
+		if rawTxs[i], err = EncodeTx(tx); err != nil {
+			return
+		}
+	}
+	r, err := b.client.call("submitpackage", []interface{}{rawTxs})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &result)
+	return
+}
@@ -0,0 +1,108 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func serializeTx(tx *wire.MsgTx) string {
+	var buf bytes.Buffer
+	tx.Serialize(&buf)
+	return hex.EncodeToString(buf.Bytes())
+}
+
+var _ = Describe("Raw transactions", func() {
+	unfunded := wire.NewMsgTx(2)
+	unfunded.AddTxOut(wire.NewTxOut(12345, []byte{0x00, 0x14, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}))
+
+	signed := wire.NewMsgTx(2)
+	signed.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, [][]byte{{0x30, 0x44}, {0x02, 0x03}}))
+	signed.AddTxOut(wire.NewTxOut(12345, []byte{0x6a}))
+
+	Describe("createrawtransaction", func() {
+		Context("when the transaction has no inputs", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"result":"%s","error":null,"id":1400432805294160077}`, serializeTx(unfunded))
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			tx, err := bitcoindClient.CreateRawTransaction(nil, []Output{{Address: "bcrt1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc5c8e6dm", Amount: 12345}}, 0, false)
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should decode the legacy serialization", func() {
+				Expect(tx.TxIn).To(BeEmpty())
+				Expect(tx.TxOut).To(HaveLen(1))
+				Expect(tx.TxOut[0].Value).To(Equal(int64(12345)))
+			})
+		})
+	})
+
+	Describe("signrawtransactionwithwallet", func() {
+		Context("when success", func() {
+			var body []byte
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = ioutil.ReadAll(r.Body)
+				fmt.Fprintf(w, `{"result":{"hex":"%s","complete":true},"error":null,"id":1400432805294160077}`, serializeTx(signed))
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			result, err := bitcoindClient.SignRawTransactionWithWallet(unfunded, nil, "")
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should send the serialized transaction", func() {
+				Expect(string(body)).To(ContainSubstring(fmt.Sprintf(`"params":["%s",[]]`, serializeTx(unfunded))))
+			})
+			It("should decode the witness", func() {
+				Expect(result.Complete).To(BeTrue())
+				Expect(result.Tx.TxHash()).To(Equal(signed.TxHash()))
+				Expect(result.Tx.TxIn[0].Witness).To(HaveLen(2))
+			})
+		})
+	})
+
+	Describe("sendrawtransaction", func() {
+		Context("when success", func() {
+			var body []byte
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = ioutil.ReadAll(r.Body)
+				fmt.Fprintf(w, `{"result":"%s","error":null,"id":1400432805294160077}`, signed.TxHash())
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			maxFeeRate := Amount(10000)
+			txID, err := bitcoindClient.SendRawTransaction(signed, &maxFeeRate)
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should send the max fee rate in BTC/kvB", func() {
+				Expect(string(body)).To(ContainSubstring(fmt.Sprintf(`"params":["%s",0.00010000]`, serializeTx(signed))))
+			})
+			It("should return the txid", func() {
+				Expect(txID).To(Equal(signed.TxHash().String()))
+			})
+		})
+	})
+})
@@ -0,0 +1,69 @@
+package jsonrpc
+
+import (
+	"time"
+)
+
+// RetryPolicy configures how calls are retried, see WithRetry.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry. It doubles after
+	// each retry.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between retries, zero means no cap.
+	MaxBackoff time.Duration
+	// Retryable decides whether an error is retried. It defaults to
+	// IsRetryable.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy retries for about a minute, long enough for a node
+// restart but not for a full reindex.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 8,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 15 * time.Second,
+}
+
+// backoff returns the delay before retry n (0 based).
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	d := p.MinBackoff
+	for i := 0; i < n; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return d
+}
+
+// retryable reports whether err should be retried under p.
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// retryLoop runs attempt until it succeeds, returns a non retryable error
+// or the client retry policy is exhausted. Waiting between attempts is
+// aborted when the client context is done.
+func (c *rpcClient) retryLoop(attempt func() error) error {
+	err := attempt()
+	if c.retry == nil {
+		return err
+	}
+	ctx := c.context()
+	for n := 0; n < c.retry.MaxRetries && err != nil && c.retry.retryable(err); n++ {
+		t := time.NewTimer(c.retry.backoff(n))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		err = attempt()
+	}
+	return err
+}
@@ -2,12 +2,14 @@ package jsonrpc
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -16,8 +18,21 @@ type rpcClient struct {
 	serverAddr string
 	user       string
 	passwd     string
+	// cookie, when set, supersedes user & passwd.
+	cookie     *cookieFile
 	httpClient *http.Client
 	timeout    int
+	// ctx is the context requests are bound to, nil means context.Background().
+	ctx context.Context
+	// wallet is the name of the wallet requests are scoped to, if any.
+	wallet string
+	// retry, when set, is the policy failed requests are retried with.
+	retry *RetryPolicy
+	// pool, when set, routes requests to several endpoints, see
+	// NewFailover. serverAddr and credentials are then unused.
+	pool *endpointPool
+	// middlewares wrap every call, see WithMiddleware.
+	middlewares []Middleware
 }
 
 // rpcRequest represent a RCP request
@@ -77,37 +92,179 @@ func newClient(host string, port int, user, passwd string, useSSL bool, timeout
 	return
 }
 
-// doTimeoutRequest process a HTTP request with timeout
-func (c *rpcClient) doTimeoutRequest(timer *time.Timer, req *http.Request) (*http.Response, error) {
-	type result struct {
-		resp *http.Response
-		err  error
+// withContext returns a shallow copy of c whose requests are bound to ctx.
+// The copy shares the underlying http.Client with c.
+func (c *rpcClient) withContext(ctx context.Context) *rpcClient {
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+// withTimeout returns a shallow copy of c with a different per request
+// timeout in seconds, zero disables the timeout.
+func (c *rpcClient) withTimeout(timeout int) *rpcClient {
+	c2 := *c
+	c2.timeout = timeout
+	return &c2
+}
+
+// withWallet returns a shallow copy of c whose requests are sent to the
+// /wallet/<name> endpoint. An empty name targets the bare server address.
+func (c *rpcClient) withWallet(name string) *rpcClient {
+	c2 := *c
+	c2.wallet = name
+	return &c2
+}
+
+// withRetry returns a shallow copy of c whose requests are retried with
+// policy, nil disables retries.
+func (c *rpcClient) withRetry(policy *RetryPolicy) *rpcClient {
+	c2 := *c
+	c2.retry = policy
+	return &c2
+}
+
+// url returns the URL requests are posted to.
+func (c *rpcClient) url() string {
+	if c.wallet == "" {
+		return c.serverAddr
 	}
-	done := make(chan result, 1)
-	go func() {
-		resp, err := c.httpClient.Do(req)
-		done <- result{resp, err}
-	}()
-	// Wait for the read or the timeout
-	select {
-	case r := <-done:
-		return r.resp, r.err
-	case <-timer.C:
-		return nil, errors.New("Timeout reading data from server")
+	return c.serverAddr + "/wallet/" + url.PathEscape(c.wallet)
+}
+
+// context returns the context requests are bound to.
+func (c *rpcClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
 	}
+	return c.ctx
 }
 
 // call prepare & exec the request
 func (c *rpcClient) call(method string, params interface{}) (rr rpcResponse, err error) {
-	connectTimer := time.NewTimer(time.Duration(c.timeout) * time.Second)
+	if len(c.middlewares) > 0 {
+		return c.callThrough(method, params)
+	}
+	return c.send(method, params)
+}
+
+// send execs the request, bypassing middlewares.
+func (c *rpcClient) send(method string, params interface{}) (rr rpcResponse, err error) {
 	rpcR := rpcRequest{method, params, time.Now().UnixNano(), "1.0"}
+	err = c.retryLoop(func() error {
+		rr = rpcResponse{}
+		data, err := c.post(rpcR)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(data, &rr); err != nil {
+			return err
+		}
+		// RPC errors are left to handleError, they are only returned here
+		// so that warmup errors get retried.
+		return handleError(nil, &rr)
+	})
+	if rr.Err != nil && err == error(rr.Err) {
+		err = nil
+	}
+	return
+}
+
+// callBatch sends requests as a single JSON-RPC batch and returns the
+// responses in the order of requests. Requests must have distinct ids.
+func (c *rpcClient) callBatch(requests []rpcRequest) (rrs []rpcResponse, err error) {
+	if len(c.middlewares) > 0 {
+		return c.callBatchThrough(requests)
+	}
+	return c.sendBatch(requests)
+}
+
+// sendBatch execs the batch, bypassing middlewares.
+func (c *rpcClient) sendBatch(requests []rpcRequest) (rrs []rpcResponse, err error) {
+	var data []byte
+	err = c.retryLoop(func() (err error) {
+		data, err = c.post(requests)
+		if err != nil {
+			return
+		}
+		// A rejected batch, eg during warmup, is a single response object.
+		var rr rpcResponse
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+			if json.Unmarshal(trimmed, &rr) == nil && rr.Err != nil {
+				return rr.Err
+			}
+		}
+		return
+	})
+	if err != nil {
+		return
+	}
+	// When the batch itself is rejected the server replies with a single
+	// response object instead of an array.
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var rr rpcResponse
+		if err = json.Unmarshal(trimmed, &rr); err != nil {
+			return
+		}
+		if rr.Err != nil {
+			err = rr.Err
+		} else {
+			err = errors.New("Bad batch response from server")
+		}
+		return
+	}
+	var unordered []rpcResponse
+	if err = json.Unmarshal(data, &unordered); err != nil {
+		return
+	}
+	byId := make(map[int64]rpcResponse, len(unordered))
+	for _, rr := range unordered {
+		byId[rr.Id] = rr
+	}
+	rrs = make([]rpcResponse, len(requests))
+	for i, req := range requests {
+		rr, ok := byId[req.Id]
+		if !ok {
+			err = fmt.Errorf("Missing response for batch request %d (%s)", i, req.Method)
+			return
+		}
+		rrs[i] = rr
+	}
+	return
+}
+
+// post sends payload as JSON and returns the raw response body.
+func (c *rpcClient) post(payload interface{}) (data []byte, err error) {
+	if c.pool != nil {
+		return c.pool.post(c, payload)
+	}
+	parent := c.context()
+	ctx := parent
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, time.Duration(c.timeout)*time.Second)
+		defer cancel()
+	}
 	payloadBuffer := &bytes.Buffer{}
 	jsonEncoder := json.NewEncoder(payloadBuffer)
-	err = jsonEncoder.Encode(rpcR)
+	err = jsonEncoder.Encode(payload)
 	if err != nil {
 		return
 	}
-	req, err := http.NewRequest("POST", c.serverAddr, payloadBuffer)
+	data, err = c.postBody(parent, ctx, payloadBuffer.Bytes(), false)
+	// bitcoind may have been restarted with a new cookie we missed, read it
+	// again and retry once.
+	var httpErr *HTTPError
+	if c.cookie != nil && errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized {
+		data, err = c.postBody(parent, ctx, payloadBuffer.Bytes(), true)
+	}
+	return
+}
+
+// postBody posts body and returns the raw response body. reloadCookie forces
+// the cookie file to be read again.
+func (c *rpcClient) postBody(parent, ctx context.Context, body []byte, reloadCookie bool) (data []byte, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url(), bytes.NewReader(body))
 	if err != nil {
 		return
 	}
@@ -115,21 +272,49 @@ func (c *rpcClient) call(method string, params interface{}) (rr rpcResponse, err
 	req.Header.Add("Accept", "application/json")
 
 	// Auth ?
-	if len(c.user) > 0 || len(c.passwd) > 0 {
-		req.SetBasicAuth(c.user, c.passwd)
+	user, passwd := c.user, c.passwd
+	if c.cookie != nil {
+		if user, passwd, err = c.cookie.credentials(reloadCookie); err != nil {
+			return
+		}
+	}
+	if len(user) > 0 || len(passwd) > 0 {
+		req.SetBasicAuth(user, passwd)
 	}
 
-	resp, err := c.doTimeoutRequest(connectTimer, req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		err = c.transportError(parent, ctx, err)
 		return
 	}
 	defer resp.Body.Close()
 
-	data, err := ioutil.ReadAll(resp.Body)
+	data, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
+		err = c.transportError(parent, ctx, err)
 		return
 	}
-
-	err = json.Unmarshal(data, &rr)
+	// bitcoind replies to RPC errors with an error status and a JSON body,
+	// only bare HTTP errors are reported as such.
+	if resp.StatusCode >= 400 {
+		if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+			err = &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+	}
 	return
 }
+
+// transportError classifies an error returned while sending a request or
+// reading its reply. It is ErrTimeout if the client timeout expired while
+// the caller's context is still alive, and a *TransportError otherwise.
+// Errors caused by the caller's context are returned unchanged so that
+// errors.Is(err, context.Canceled) keeps working.
+func (c *rpcClient) transportError(parent, ctx context.Context, err error) error {
+	if parent.Err() != nil {
+		return err
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return ErrTimeout
+	}
+	return newTransportError(err)
+}
@@ -1,6 +1,8 @@
 package jsonrpc
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	. "github.com/onsi/ginkgo"
@@ -64,6 +66,33 @@ var _ = Describe("RpcClient", func() {
 
 		})
 
+		Context("When context is canceled", func() {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+				fmt.Fprintln(w, "Hello, client")
+			}))
+			defer ts.Close()
+			p := strings.Split(ts.URL, ":")
+			host := p[1][2:]
+			port, err := strconv.ParseInt(p[2], 10, 64)
+			client, err := newClient(host, int(port), "fake", "fake", false, 30)
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			_, err = client.withContext(ctx).call("getdifficulty", nil)
+			elapsed := time.Since(start)
+
+			It("context err should occured", func() {
+				Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			})
+			It("should not wait for the server", func() {
+				Expect(elapsed).To(BeNumerically("<", 5*time.Second))
+			})
+		})
+
 	})
 
 })
@@ -0,0 +1,718 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+)
+
+// Wallet returns a copy of b whose calls are sent to the /wallet/<name>
+// endpoint, so that wallet RPCs (GetBalance, ListUnspent, SendToAddress...)
+// target the named wallet on a node with several wallets loaded.
+// An empty name targets the node default wallet.
+// The copy shares the underlying HTTP connections and context with b.
+func (b *Bitcoind) Wallet(name string) *Bitcoind {
+	return &Bitcoind{client: b.client.withWallet(name)}
+}
+
+// WalletName returns the name of the wallet b is scoped to, "" if none.
+func (b *Bitcoind) WalletName() string {
+	return b.client.wallet
+}
+
+// CreateWalletOptions represents the optional createwallet arguments
+// https://bitcoincore.org/en/doc/26.0.0/rpc/wallet/createwallet/
+type CreateWalletOptions struct {
+	// Disable the possibility of private keys (only watchonlys are possible in this mode)
+	DisablePrivateKeys bool `json:"disable_private_keys,omitempty"`
+
+	// Create a blank wallet, with no keys or HD seed
+	Blank bool `json:"blank,omitempty"`
+
+	// Encrypt the wallet with this passphrase
+	Passphrase string `json:"passphrase,omitempty"`
+
+	// Keep track of coin reuse, and treat dirty and clean coins differently with privacy considerations in mind
+	AvoidReuse bool `json:"avoid_reuse,omitempty"`
+
+	// Create a native descriptor wallet, nil lets the node choose
+	Descriptors *bool `json:"descriptors,omitempty"`
+
+	// Save wallet name to persistent settings and load on startup, nil leaves the setting unchanged
+	LoadOnStartup *bool `json:"load_on_startup,omitempty"`
+
+	// Use an external signer such as a hardware wallet
+	ExternalSigner bool `json:"external_signer,omitempty"`
+}
+
+// createWalletParams represents named parameters for createwallet
+type createWalletParams struct {
+	WalletName string `json:"wallet_name"`
+	CreateWalletOptions
+}
+
+// LoadWalletResult represents a response to createwallet and loadwallet calls
+type LoadWalletResult struct {
+	// The wallet name
+	Name string `json:"name"`
+
+	// Warning message if wallet was not loaded cleanly (deprecated by Bitcoin Core 25)
+	Warning string `json:"warning,omitempty"`
+
+	// Warning messages, if any
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// UnloadWalletResult represents a response to unloadwallet call
+type UnloadWalletResult struct {
+	// Warning message if wallet was not unloaded cleanly (deprecated by Bitcoin Core 25)
+	Warning string `json:"warning,omitempty"`
+
+	// Warning messages, if any
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// WalletDirEntry represents a wallet found in the wallet directory
+type WalletDirEntry struct {
+	// The wallet name
+	Name string `json:"name"`
+
+	// Warning messages, if any
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// CreateWallet creates and loads a new wallet.
+// opts may be nil to use the node defaults.
+func (b *Bitcoind) CreateWallet(name string, opts *CreateWalletOptions) (result LoadWalletResult, err error) {
+	params := createWalletParams{WalletName: name}
+	if opts != nil {
+		params.CreateWalletOptions = *opts
+	}
+	r, err := b.client.call("createwallet", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &result)
+	return
+}
+
+// LoadWallet loads a wallet from a wallet file or directory.
+// loadOnStartup may be nil to leave the startup setting unchanged.
+func (b *Bitcoind) LoadWallet(filename string, loadOnStartup *bool) (result LoadWalletResult, err error) {
+	params := []interface{}{filename}
+	if loadOnStartup != nil {
+		params = append(params, *loadOnStartup)
+	}
+	r, err := b.client.call("loadwallet", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &result)
+	return
+}
+
+// UnloadWallet unloads the wallet <name>. If name is "", the wallet b is
+// scoped to (see Wallet) is unloaded.
+// loadOnStartup may be nil to leave the startup setting unchanged.
+func (b *Bitcoind) UnloadWallet(name string, loadOnStartup *bool) (result UnloadWalletResult, err error) {
+	var params []interface{}
+	if name != "" {
+		params = append(params, name)
+	}
+	if loadOnStartup != nil {
+		if name == "" {
+			// A null wallet_name selects the wallet from the URI.
+			params = append(params, nil)
+		}
+		params = append(params, *loadOnStartup)
+	}
+	r, err := b.client.call("unloadwallet", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	// Old nodes reply null
+	if string(r.Result) != "null" {
+		err = json.Unmarshal(r.Result, &result)
+	}
+	return
+}
+
+// ListWallets returns the names of the currently loaded wallets.
+func (b *Bitcoind) ListWallets() (wallets []string, err error) {
+	r, err := b.client.call("listwallets", nil)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &wallets)
+	return
+}
+
+// ListWalletDir returns the wallets found in the node wallet directory.
+func (b *Bitcoind) ListWalletDir() (wallets []WalletDirEntry, err error) {
+	r, err := b.client.call("listwalletdir", nil)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	var result struct {
+		Wallets []WalletDirEntry `json:"wallets"`
+	}
+	if err = json.Unmarshal(r.Result, &result); err != nil {
+		return
+	}
+	wallets = result.Wallets
+	return
+}
+
+// LabelAddress represents an address returned by getaddressesbylabel
+type LabelAddress struct {
+	// Purpose of address ("send" for sending address, "receive" for receiving address)
+	Purpose string `json:"purpose"`
+}
+
+// GetAddressesByLabel returns the addresses assigned the specified label,
+// mapped to their purpose.
+func (b *Bitcoind) GetAddressesByLabel(label string) (addresses map[string]LabelAddress, err error) {
+	r, err := b.client.call("getaddressesbylabel", []string{label})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &addresses)
+	return
+}
+
+// ListLabels returns the list of all labels, or labels that are assigned
+// to addresses with a specific purpose ("send" or "receive", "" for all).
+func (b *Bitcoind) ListLabels(purpose string) (labels []string, err error) {
+	var params []string
+	if purpose != "" {
+		params = []string{purpose}
+	}
+	r, err := b.client.call("listlabels", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &labels)
+	return
+}
+
+// SetLabel sets the label associated with the given address.
+func (b *Bitcoind) SetLabel(address, label string) error {
+	r, err := b.client.call("setlabel", []string{address, label})
+	return handleError(err, &r)
+}
+
+// GetReceivedByLabel returns the total amount received by addresses with
+// label in transactions with at least minconf confirmations.
+func (b *Bitcoind) GetReceivedByLabel(label string, minconf uint32) (amount Amount, err error) {
+	r, err := b.client.call("getreceivedbylabel", []interface{}{label, minconf})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &amount)
+	return
+}
+
+// ReceivedByLabel represents how much coin a label have received
+type ReceivedByLabel struct {
+	// The label of the receiving addresses
+	Label string `json:"label"`
+
+	// Total amount received by addresses with this label
+	Amount Amount `json:"amount"`
+
+	// Number of confirmations of the most recent transaction included
+	Confirmations uint32 `json:"confirmations"`
+
+	// Only returns true if imported addresses were involved in transaction
+	InvolvesWatchonly bool `json:"involvesWatchonly,omitempty"`
+}
+
+// ListReceivedByLabel returns the amounts received by label.
+func (b *Bitcoind) ListReceivedByLabel(minConf uint32, includeEmpty bool) (list []ReceivedByLabel, err error) {
+	r, err := b.client.call("listreceivedbylabel", []interface{}{minConf, includeEmpty})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &list)
+	return
+}
+
+// SendOptions represents the optional send arguments
+// https://bitcoincore.org/en/doc/26.0.0/rpc/wallet/send/
+type SendOptions struct {
+	// Confirmation target in blocks
+	ConfTarget int `json:"conf_target,omitempty"`
+
+	// The fee estimate mode (ESTIMATE_MODE_*)
+	EstimateMode string `json:"estimate_mode,omitempty"`
+
+	// Fee rate in sat/vB
+	FeeRate float64 `json:"fee_rate,omitempty"`
+
+	// Automatically include coins from the wallet to cover the target amount, nil lets the node choose
+	AddInputs *bool `json:"add_inputs,omitempty"`
+
+	// When false, returns a serialized transaction which will not be added to the wallet or broadcast, nil means true
+	AddToWallet *bool `json:"add_to_wallet,omitempty"`
+
+	// The bitcoin address to receive the change
+	ChangeAddress string `json:"change_address,omitempty"`
+
+	// The index of the change output, nil for random
+	ChangePosition *int `json:"change_position,omitempty"`
+
+	// The output type to use for change ("legacy", "p2sh-segwit", "bech32", "bech32m")
+	ChangeType string `json:"change_type,omitempty"`
+
+	// Also select inputs which are watch only
+	IncludeWatching bool `json:"include_watching,omitempty"`
+
+	// Specify inputs instead of adding them automatically
+	Inputs []TxInput `json:"inputs,omitempty"`
+
+	// Raw locktime
+	Locktime uint32 `json:"locktime,omitempty"`
+
+	// Lock selected unspent outputs
+	LockUnspents bool `json:"lock_unspents,omitempty"`
+
+	// Always return a PSBT, implies AddToWallet false
+	Psbt bool `json:"psbt,omitempty"`
+
+	// Outputs (by index) to subtract the fee from
+	SubtractFeeFromOutputs []int `json:"subtract_fee_from_outputs,omitempty"`
+
+	// Marks this transaction as BIP125-replaceable, nil lets the node choose
+	Replaceable *bool `json:"replaceable,omitempty"`
+}
+
+// SendResult represents a response to send and sendall calls
+type SendResult struct {
+	// If the transaction has a complete set of signatures
+	Complete bool `json:"complete"`
+
+	// The transaction id for the send. Only returned when the transaction was complete.
+	Txid string `json:"txid,omitempty"`
+
+	// If AddToWallet is false, the hex-encoded raw transaction with signature(s)
+	Hex string `json:"hex,omitempty"`
+
+	// If more signatures are needed, or if AddToWallet is false, the base64-encoded (partially) signed transaction
+	Psbt string `json:"psbt,omitempty"`
+}
+
+// Send sends a transaction paying outputs, funded by the wallet.
+// opts may be nil to use the node defaults.
+func (b *Bitcoind) Send(outputs []Output, opts *SendOptions) (result SendResult, err error) {
+	params := map[string]interface{}{"outputs": outputs}
+	if opts != nil {
+		params["options"] = opts
+	}
+	r, err := b.client.call("send", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &result)
+	return
+}
+
+// SendAllRecipient represents a recipient of sendall.
+// A recipient with a zero Amount receives an equal share of the remaining
+// balance after the fixed amounts and the fee.
+type SendAllRecipient struct {
+	Address string
+	Amount  Amount
+}
+
+// MarshalJSON marshals the recipient as "address" or {"address": amount}
+func (s SendAllRecipient) MarshalJSON() ([]byte, error) {
+	if s.Amount == 0 {
+		return json.Marshal(s.Address)
+	}
+	return json.Marshal(map[string]Amount{s.Address: s.Amount})
+}
+
+// SendAllOptions represents the optional sendall arguments
+// https://bitcoincore.org/en/doc/26.0.0/rpc/wallet/sendall/
+type SendAllOptions struct {
+	// Confirmation target in blocks
+	ConfTarget int `json:"conf_target,omitempty"`
+
+	// The fee estimate mode (ESTIMATE_MODE_*)
+	EstimateMode string `json:"estimate_mode,omitempty"`
+
+	// Fee rate in sat/vB
+	FeeRate float64 `json:"fee_rate,omitempty"`
+
+	// When false, returns the serialized transaction without broadcasting or adding it to the wallet, nil means true
+	AddToWallet *bool `json:"add_to_wallet,omitempty"`
+
+	// Also select inputs which are watch-only
+	IncludeWatching bool `json:"include_watching,omitempty"`
+
+	// Use exactly the specified inputs to build the transaction
+	Inputs []TxInput `json:"inputs,omitempty"`
+
+	// Raw locktime
+	Locktime uint32 `json:"locktime,omitempty"`
+
+	// Lock selected unspent outputs
+	LockUnspents bool `json:"lock_unspents,omitempty"`
+
+	// Always return a PSBT, implies AddToWallet false
+	Psbt bool `json:"psbt,omitempty"`
+
+	// When true, only use UTXOs that can pay for their own fees to maximize the output amount
+	SendMax bool `json:"send_max,omitempty"`
+
+	// Require inputs with at least this many confirmations
+	MinConf int `json:"minconf,omitempty"`
+
+	// Require inputs with at most this many confirmations
+	MaxConf int `json:"maxconf,omitempty"`
+}
+
+// SendAll spends the whole wallet balance (or the given inputs) to recipients.
+// opts may be nil to use the node defaults.
+func (b *Bitcoind) SendAll(recipients []SendAllRecipient, opts *SendAllOptions) (result SendResult, err error) {
+	params := map[string]interface{}{"recipients": recipients}
+	if opts != nil {
+		params["options"] = opts
+	}
+	r, err := b.client.call("sendall", params)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &result)
+	return
+}
+
+// FundedPsbtOptions represents the optional walletcreatefundedpsbt arguments
+// https://bitcoincore.org/en/doc/26.0.0/rpc/wallet/walletcreatefundedpsbt/
+type FundedPsbtOptions struct {
+	// Automatically include coins from the wallet to cover the target amount, nil lets the node choose
+	AddInputs *bool `json:"add_inputs,omitempty"`
+
+	// Include inputs that are not safe to spend (unconfirmed transactions from outside keys and unconfirmed replacement transactions)
+	IncludeUnsafe bool `json:"include_unsafe,omitempty"`
+
+	// The bitcoin address to receive the change
+	ChangeAddress string `json:"changeAddress,omitempty"`
+
+	// The index of the change output, nil for random
+	ChangePosition *int `json:"changePosition,omitempty"`
+
+	// The output type to use for change ("legacy", "p2sh-segwit", "bech32", "bech32m")
+	ChangeType string `json:"change_type,omitempty"`
+
+	// Also select inputs which are watch only
+	IncludeWatching bool `json:"includeWatching,omitempty"`
+
+	// Lock selected unspent outputs
+	LockUnspents bool `json:"lockUnspents,omitempty"`
+
+	// Fee rate in sat/vB
+	FeeRate float64 `json:"fee_rate,omitempty"`
+
+	// Outputs (by index) to subtract the fee from
+	SubtractFeeFromOutputs []int `json:"subtractFeeFromOutputs,omitempty"`
+
+	// Marks this transaction as BIP125-replaceable, nil lets the node choose
+	Replaceable *bool `json:"replaceable,omitempty"`
+
+	// Confirmation target in blocks
+	ConfTarget int `json:"conf_target,omitempty"`
+
+	// The fee estimate mode (ESTIMATE_MODE_*)
+	EstimateMode string `json:"estimate_mode,omitempty"`
+}
+
+// FundedPsbtResult represents a response to walletcreatefundedpsbt call
+type FundedPsbtResult struct {
+	// The resulting raw transaction (base64-encoded string)
+	Psbt string `json:"psbt"`
+
+	// Fee the resulting transaction pays
+	Fee Amount `json:"fee"`
+
+	// The position of the added change output, or -1
+	ChangePos int `json:"changepos"`
+}
+
+// WalletCreateFundedPsbt creates and funds a transaction in the PSBT format.
+// Inputs are added by the wallet when inputs is empty or AddInputs is set.
+// opts may be nil to use the node defaults.
+func (b *Bitcoind) WalletCreateFundedPsbt(inputs []TxInput, outputs []Output, locktime uint32, opts *FundedPsbtOptions, bip32derivs bool) (result FundedPsbtResult, err error) {
+	if inputs == nil {
+		inputs = []TxInput{}
+	}
+	if opts == nil {
+		opts = &FundedPsbtOptions{}
+	}
+	r, err := b.client.call("walletcreatefundedpsbt", []interface{}{inputs, outputs, locktime, opts, bip32derivs})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &result)
+	return
+}
+
+// BumpFeeOptions represents the optional bumpfee and psbtbumpfee arguments
+// https://bitcoincore.org/en/doc/26.0.0/rpc/wallet/bumpfee/
+type BumpFeeOptions struct {
+	// Confirmation target in blocks
+	ConfTarget int `json:"conf_target,omitempty"`
+
+	// Fee rate in sat/vB
+	FeeRate float64 `json:"fee_rate,omitempty"`
+
+	// Whether the new transaction should still be marked BIP125-replaceable, nil means true
+	Replaceable *bool `json:"replaceable,omitempty"`
+
+	// The fee estimate mode (ESTIMATE_MODE_*)
+	EstimateMode string `json:"estimate_mode,omitempty"`
+
+	// New outputs replacing the original ones
+	Outputs []Output `json:"outputs,omitempty"`
+
+	// The 0-based index of the change output on the original transaction, nil lets the node choose
+	OriginalChangeIndex *int `json:"original_change_index,omitempty"`
+}
+
+// BumpFeeResult represents a response to bumpfee and psbtbumpfee calls
+type BumpFeeResult struct {
+	// The id of the new transaction (bumpfee only)
+	Txid string `json:"txid,omitempty"`
+
+	// The base64-encoded unsigned PSBT of the new transaction (psbtbumpfee only)
+	Psbt string `json:"psbt,omitempty"`
+
+	// The fee of the replaced transaction
+	OrigFee Amount `json:"origfee"`
+
+	// The fee of the new transaction
+	Fee Amount `json:"fee"`
+
+	// Errors encountered during processing (may be empty)
+	Errors []string `json:"errors"`
+}
+
+// BumpFee replaces the wallet transaction txid by one paying a higher fee
+// (BIP125) and broadcasts it.
+// opts may be nil to use the node defaults.
+func (b *Bitcoind) BumpFee(txid string, opts *BumpFeeOptions) (result BumpFeeResult, err error) {
+	err = b.bumpFee("bumpfee", txid, opts, &result)
+	return
+}
+
+// PsbtBumpFee returns an unsigned PSBT replacing the wallet transaction txid
+// by one paying a higher fee, for watch-only wallets or external signers.
+// opts may be nil to use the node defaults.
+func (b *Bitcoind) PsbtBumpFee(txid string, opts *BumpFeeOptions) (result BumpFeeResult, err error) {
+	err = b.bumpFee("psbtbumpfee", txid, opts, &result)
+	return
+}
+
+func (b *Bitcoind) bumpFee(method, txid string, opts *BumpFeeOptions, result *BumpFeeResult) error {
+	params := []interface{}{txid}
+	if opts != nil {
+		params = append(params, opts)
+	}
+	r, err := b.client.call(method, params)
+	if err = handleError(err, &r); err != nil {
+		return err
+	}
+	return json.Unmarshal(r.Result, result)
+}
+
+// WalletDescriptor represents a descriptor returned by listdescriptors
+type WalletDescriptor struct {
+	// Descriptor string representation
+	Desc string `json:"desc"`
+
+	// The creation time of the descriptor
+	Timestamp int64 `json:"timestamp"`
+
+	// Whether this descriptor is currently used to generate new addresses
+	Active bool `json:"active"`
+
+	// True if this descriptor is used to generate change addresses, only set for active descriptors
+	Internal *bool `json:"internal,omitempty"`
+
+	// Defined only for ranged descriptors, the range [begin, end] of derived indexes
+	Range []int64 `json:"range,omitempty"`
+
+	// Same as NextIndex field, deprecated
+	Next *int64 `json:"next,omitempty"`
+
+	// The next index to generate addresses from, defined only for ranged descriptors
+	NextIndex *int64 `json:"next_index,omitempty"`
+}
+
+// ListDescriptorsResult represents a response to listdescriptors call
+type ListDescriptorsResult struct {
+	// Name of wallet this operation was performed on
+	WalletName string `json:"wallet_name"`
+
+	// Array of descriptor objects (sorted by descriptor string representation)
+	Descriptors []WalletDescriptor `json:"descriptors"`
+}
+
+// ListDescriptors lists the descriptors imported into a descriptor-enabled
+// wallet. When private is set, descriptors include private keys.
+func (b *Bitcoind) ListDescriptors(private bool) (result ListDescriptorsResult, err error) {
+	r, err := b.client.call("listdescriptors", []bool{private})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &result)
+	return
+}
+
+// WalletTransactionDetails represents an entry of the details of a wallet transaction
+type WalletTransactionDetails struct {
+	// Only returns true if imported addresses were involved in transaction
+	InvolvesWatchonly bool `json:"involvesWatchonly,omitempty"`
+
+	// The bitcoin address involved in the transaction
+	Address string `json:"address,omitempty"`
+
+	// The transaction category ("send", "receive", "generate", "immature" or "orphan")
+	Category string `json:"category"`
+
+	// The amount, negative for the "send" category
+	Amount Amount `json:"amount"`
+
+	// A comment for the address/transaction, if any
+	Label string `json:"label,omitempty"`
+
+	// The vout value
+	Vout uint32 `json:"vout"`
+
+	// The amount of the fee, negative, only for the "send" category
+	Fee Amount `json:"fee,omitempty"`
+
+	// True if the transaction has been abandoned (inputs are respendable), only for the "send" category
+	Abandoned bool `json:"abandoned,omitempty"`
+
+	// Only if category is "send" or "receive", the descriptors that generated the address
+	ParentDescs []string `json:"parent_descs,omitempty"`
+}
+
+// WalletTransaction represents a response to a verbose gettransaction call
+type WalletTransaction struct {
+	// The amount, negative for sent transactions
+	Amount Amount `json:"amount"`
+
+	// The amount of the fee, negative, only for sent transactions
+	Fee Amount `json:"fee,omitempty"`
+
+	// The number of confirmations, negative if conflicted
+	Confirmations int64 `json:"confirmations"`
+
+	// Only present if the transaction's only input is a coinbase one
+	Generated bool `json:"generated,omitempty"`
+
+	// Whether we consider the transaction to be trusted and safe to spend from, only present for unconfirmed transactions
+	Trusted *bool `json:"trusted,omitempty"`
+
+	// The block hash containing the transaction
+	BlockHash string `json:"blockhash,omitempty"`
+
+	// The block height containing the transaction
+	BlockHeight int64 `json:"blockheight,omitempty"`
+
+	// The index of the transaction in the block that includes it
+	BlockIndex int64 `json:"blockindex,omitempty"`
+
+	// The block time in seconds since epoch (Jan 1 1970 GMT)
+	BlockTime int64 `json:"blocktime,omitempty"`
+
+	// The transaction id
+	Txid string `json:"txid"`
+
+	// The hash of serialized transaction, including witness data
+	Wtxid string `json:"wtxid,omitempty"`
+
+	// Conflicting transaction ids
+	WalletConflicts []string `json:"walletconflicts"`
+
+	// The txid if this tx was replaced
+	ReplacedByTxid string `json:"replaced_by_txid,omitempty"`
+
+	// The txid if this tx replaces one
+	ReplacesTxid string `json:"replaces_txid,omitempty"`
+
+	// The transaction time in seconds since epoch (Jan 1 1970 GMT)
+	Time int64 `json:"time"`
+
+	// The time received in seconds since epoch (Jan 1 1970 GMT)
+	TimeReceived int64 `json:"timereceived"`
+
+	// Whether this transaction signals BIP125 replaceability ("yes", "no" or "unknown")
+	Bip125Replaceable string `json:"bip125-replaceable,omitempty"`
+
+	// Only if category is "send" or "receive", the descriptors that generated the addresses
+	ParentDescs []string `json:"parent_descs,omitempty"`
+
+	// The wallet related details of the transaction
+	Details []WalletTransactionDetails `json:"details"`
+
+	// Raw data for transaction
+	Hex string `json:"hex"`
+
+	// The decoded transaction
+	Decoded *RawTransaction `json:"decoded,omitempty"`
+}
+
+// GetWalletTransaction returns detailed information about in-wallet
+// transaction txid, including the decoded transaction.
+func (b *Bitcoind) GetWalletTransaction(txid string, includeWatchonly bool) (transaction WalletTransaction, err error) {
+	r, err := b.client.call("gettransaction", []interface{}{txid, includeWatchonly, true})
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &transaction)
+	return
+}
+
+// BalanceDetails represents the balances of a set of outputs
+type BalanceDetails struct {
+	// Trusted balance (outputs created by the wallet or confirmed outputs)
+	Trusted Amount `json:"trusted"`
+
+	// Untrusted pending balance (outputs created by others that are in the mempool)
+	UntrustedPending Amount `json:"untrusted_pending"`
+
+	// Balance from immature coinbase outputs
+	Immature Amount `json:"immature"`
+
+	// Balance from coins sent to addresses that were previously spent from (potentially privacy violating), only with avoid_reuse
+	Used *Amount `json:"used,omitempty"`
+}
+
+// Balances represents a response to getbalances call
+type Balances struct {
+	// Balances from outputs that the wallet can sign
+	Mine BalanceDetails `json:"mine"`
+
+	// Watchonly balances (not present if wallet does not watch anything)
+	WatchOnly *BalanceDetails `json:"watchonly,omitempty"`
+
+	// Hash and height of the block this information was generated on
+	LastProcessedBlock *BlockRef `json:"lastprocessedblock,omitempty"`
+}
+
+// BlockRef identifies a block by hash and height
+type BlockRef struct {
+	Hash   string `json:"hash"`
+	Height int64  `json:"height"`
+}
+
+// GetBalances returns the wallet balances, in satoshis.
+func (b *Bitcoind) GetBalances() (balances Balances, err error) {
+	r, err := b.client.call("getbalances", nil)
+	if err = handleError(err, &r); err != nil {
+		return
+	}
+	err = json.Unmarshal(r.Result, &balances)
+	return
+}
@@ -0,0 +1,155 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Wallet", func() {
+	Describe("scope calls to a wallet", func() {
+		Context("when success", func() {
+			var path string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.EscapedPath()
+				fmt.Fprintln(w, `{"result":0.00001,"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			wallet := bitcoindClient.Wallet("my wallet")
+			_, err = wallet.GetBalance("*", 0)
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should post to the wallet endpoint", func() {
+				Expect(path).To(Equal("/wallet/my%20wallet"))
+			})
+			It("should keep the wallet name", func() {
+				Expect(wallet.WalletName()).To(Equal("my wallet"))
+				Expect(bitcoindClient.WalletName()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("createwallet", func() {
+		Context("when success", func() {
+			var params map[string]interface{}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				var req struct {
+					Params map[string]interface{} `json:"params"`
+				}
+				json.Unmarshal(body, &req)
+				params = req.Params
+				fmt.Fprintln(w, `{"result":{"name":"watch","warnings":[]},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			descriptors := true
+			result, err := bitcoindClient.CreateWallet("watch", &CreateWalletOptions{DisablePrivateKeys: true, Descriptors: &descriptors})
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should send named params", func() {
+				Expect(params).To(Equal(map[string]interface{}{
+					"wallet_name":          "watch",
+					"disable_private_keys": true,
+					"descriptors":          true,
+				}))
+			})
+			It("should return the wallet name", func() {
+				Expect(result.Name).To(Equal("watch"))
+			})
+		})
+	})
+
+	Describe("listwalletdir", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":{"wallets":[{"name":""},{"name":"watch"}]},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			wallets, err := bitcoindClient.ListWalletDir()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return wallets", func() {
+				Expect(wallets).To(Equal([]WalletDirEntry{{Name: ""}, {Name: "watch"}}))
+			})
+		})
+	})
+})
+
+var _ = Describe("Modern wallet", func() {
+	Describe("send", func() {
+		Context("when success", func() {
+			var body []byte
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ = ioutil.ReadAll(r.Body)
+				fmt.Fprintln(w, `{"result":{"complete":true,"txid":"c7e8e7a9b3e0e5f3b0d2a0d2d1a0d8f3e3b2c1d0e9f8a7b6c5d4e3f2a1b0c9d8"},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			result, err := bitcoindClient.Send([]Output{
+				{Address: "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080", Amount: 12345},
+				{Data: []byte("hello")},
+			}, &SendOptions{FeeRate: 2.5})
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should send outputs in BTC", func() {
+				Expect(string(body)).To(ContainSubstring(`"params":{"options":{"fee_rate":2.5},"outputs":[{"bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080":0.00012345},{"data":"68656c6c6f"}]}`))
+			})
+			It("should return the txid", func() {
+				Expect(result.Complete).To(BeTrue())
+				Expect(result.Txid).To(Equal("c7e8e7a9b3e0e5f3b0d2a0d2d1a0d8f3e3b2c1d0e9f8a7b6c5d4e3f2a1b0c9d8"))
+			})
+		})
+	})
+
+	Describe("getbalances", func() {
+		Context("when success", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintln(w, `{"result":{"mine":{"trusted":1.10000001,"untrusted_pending":0.00000000,"immature":50.00000000},"lastprocessedblock":{"hash":"0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206","height":101}},"error":null,"id":1400432805294160077}`)
+			})
+			ts, host, port, err := getNewTestServer(handler)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			defer ts.Close()
+			bitcoindClient, _ := New(host, port, "x", "fake", false)
+			balances, err := bitcoindClient.GetBalances()
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should return balances in satoshis", func() {
+				Expect(balances.Mine.Trusted).To(Equal(Amount(110000001)))
+				Expect(balances.Mine.Immature).To(Equal(Amount(5000000000)))
+				Expect(balances.WatchOnly).To(BeNil())
+				Expect(balances.LastProcessedBlock.Height).To(Equal(int64(101)))
+			})
+		})
+	})
+})
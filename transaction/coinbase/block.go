@@ -0,0 +1,142 @@
+package coinbase
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Template is a decoded getblocktemplate result.
+type Template struct {
+	Height    int64
+	Version   int32
+	PrevBlock chainhash.Hash
+	Bits      uint32
+	// CurTime is the time of the blocks built on the template, MinTime is
+	// the earliest valid time
+	CurTime time.Time
+	MinTime time.Time
+	// CoinbaseValue is the subsidy plus the fees of Transactions
+	CoinbaseValue int64
+	// Transactions are the transactions of the block, without the coinbase
+	Transactions []*wire.MsgTx
+	// Segwit is true when the block must commit to the witnesses
+	Segwit bool
+	// LongPollID identifies the template for long polling
+	LongPollID string
+}
+
+// ParseTemplate decodes a getblocktemplate result.
+func ParseTemplate(r *btcjson.GetBlockTemplateResult) (*Template, error) {
+	if r.CoinbaseValue == nil {
+		return nil, errors.New("coinbase: template without coinbasevalue")
+	}
+	t := &Template{
+		Height:        r.Height,
+		Version:       r.Version,
+		CurTime:       time.Unix(r.CurTime, 0),
+		MinTime:       time.Unix(r.MinTime, 0),
+		CoinbaseValue: *r.CoinbaseValue,
+		Segwit:        r.DefaultWitnessCommitment != "",
+		LongPollID:    r.LongPollID,
+	}
+	prev, err := chainhash.NewHashFromStr(r.PreviousHash)
+	if err != nil {
+		return nil, fmt.Errorf("coinbase: bad previousblockhash: %w", err)
+	}
+	t.PrevBlock = *prev
+	bits, err := strconv.ParseUint(r.Bits, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("coinbase: bad bits: %w", err)
+	}
+	t.Bits = uint32(bits)
+	for i, rtx := range r.Transactions {
+		serialized, err := hex.DecodeString(rtx.Data)
+		if err != nil {
+			return nil, fmt.Errorf("coinbase: bad transaction %d: %w", i, err)
+		}
+		tx := &wire.MsgTx{}
+		if err = tx.Deserialize(bytes.NewReader(serialized)); err != nil {
+			return nil, fmt.Errorf("coinbase: bad transaction %d: %w", i, err)
+		}
+		t.Transactions = append(t.Transactions, tx)
+	}
+	if t.Segwit {
+		// the commitment does not depend on the coinbase, so ours must be
+		// the template's
+		if hex.EncodeToString(witnessCommitmentScript(WitnessCommitment(t.Transactions))) != r.DefaultWitnessCommitment {
+			return nil, errors.New("coinbase: witness commitment does not match the template")
+		}
+	}
+	return t, nil
+}
+
+// Block builds a block on t paying its coinbase value to payoutScript. The
+// nonce of the header is zero, see Solve.
+func (t *Template) Block(payoutScript []byte, extraNonce uint64, flags []byte) (*wire.MsgBlock, error) {
+	p := Params{
+		Height:       t.Height,
+		ExtraNonce:   extraNonce,
+		Flags:        flags,
+		Value:        t.CoinbaseValue,
+		PayoutScript: payoutScript,
+	}
+	if t.Segwit {
+		p.WitnessCommitment = WitnessCommitment(t.Transactions)
+	}
+	coinbase, err := New(p)
+	if err != nil {
+		return nil, err
+	}
+	txs := append([]*wire.MsgTx{coinbase}, t.Transactions...)
+	timestamp := t.CurTime
+	if timestamp.Before(t.MinTime) {
+		timestamp = t.MinTime
+	}
+	return &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:    t.Version,
+			PrevBlock:  t.PrevBlock,
+			MerkleRoot: MerkleRoot(txs),
+			Timestamp:  timestamp,
+			Bits:       t.Bits,
+		},
+		Transactions: txs,
+	}, nil
+}
+
+// MerkleRoot returns the merkle root of the transaction ids of txs.
+func MerkleRoot(txs []*wire.MsgTx) chainhash.Hash {
+	return blockchain.CalcMerkleRoot(utilTxs(txs), false)
+}
+
+// WitnessCommitment returns the BIP141 commitment to the witnesses of a
+// block with txs after its coinbase, for the witness reserved value of New.
+func WitnessCommitment(txs []*wire.MsgTx) []byte {
+	// the coinbase wtxid is replaced by zero in the witness merkle tree
+	all := append([]*wire.MsgTx{wire.NewMsgTx(wire.TxVersion)}, txs...)
+	root := blockchain.CalcMerkleRoot(utilTxs(all), true)
+	var reserved [blockchain.CoinbaseWitnessDataLen]byte
+	return chainhash.DoubleHashB(append(root[:], reserved[:]...))
+}
+
+func witnessCommitmentScript(commitment []byte) []byte {
+	return append(append([]byte(nil), blockchain.WitnessMagicBytes...), commitment...)
+}
+
+func utilTxs(txs []*wire.MsgTx) []*btcutil.Tx {
+	utxs := make([]*btcutil.Tx, len(txs))
+	for i, tx := range txs {
+		utxs[i] = btcutil.NewTx(tx)
+	}
+	return utxs
+}
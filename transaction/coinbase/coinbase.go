@@ -0,0 +1,98 @@
+// Package coinbase assembles blocks from bitcoind block templates: it builds
+// the coinbase transaction, commits to the witnesses and the transactions
+// and searches the nonce of the header.
+//
+// A Miner long polls getblocktemplate and submits the blocks it finds, it is
+// meant for regtest where a CPU nonce search finds blocks instantly:
+//
+//	miner := coinbase.NewMiner(client, payoutScript)
+//	tmpl, err := miner.NextTemplate(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	block, err := miner.Mine(ctx, tmpl)
+package coinbase
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MaxScriptSize is the maximum size of a coinbase signature script.
+const MaxScriptSize = 100
+
+// Params describes a coinbase transaction.
+type Params struct {
+	// Height of the block, pushed first in the signature script (BIP34)
+	Height int64
+	// ExtraNonce is pushed after the height, changing it changes the merkle
+	// root once the nonce space of the header is exhausted
+	ExtraNonce uint64
+	// Flags are arbitrary data appended to the signature script, eg a pool
+	// tag
+	Flags []byte
+	// Value is the subsidy plus the fees of the block, in satoshis
+	Value int64
+	// PayoutScript is the output script receiving Value
+	PayoutScript []byte
+	// WitnessCommitment, when set, is committed in an OP_RETURN output and
+	// the coinbase input gets the witness reserved value (BIP141), see
+	// WitnessCommitment
+	WitnessCommitment []byte
+}
+
+// Script returns the coinbase signature script of height, extraNonce and
+// flags.
+func Script(height int64, extraNonce uint64, flags []byte) ([]byte, error) {
+	if height < 1 {
+		return nil, errors.New("coinbase: height must be positive")
+	}
+	var nonce [8]byte
+	binary.LittleEndian.PutUint64(nonce[:], extraNonce)
+	builder := txscript.NewScriptBuilder().AddInt64(height).AddData(nonce[:])
+	if len(flags) > 0 {
+		builder.AddData(flags)
+	}
+	script, err := builder.Script()
+	if err != nil {
+		return nil, err
+	}
+	if len(script) > MaxScriptSize {
+		return nil, errors.New("coinbase: signature script exceeds 100 bytes, shorten the flags")
+	}
+	return script, nil
+}
+
+// New builds the coinbase transaction described by p.
+func New(p Params) (*wire.MsgTx, error) {
+	script, err := Script(p.Height, p.ExtraNonce, p.Flags)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.PayoutScript) == 0 {
+		return nil, errors.New("coinbase: missing payout script")
+	}
+	if p.Value < 0 {
+		return nil, errors.New("coinbase: negative value")
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: math.MaxUint32},
+		SignatureScript:  script,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	tx.AddTxOut(wire.NewTxOut(p.Value, p.PayoutScript))
+	if p.WitnessCommitment != nil {
+		if len(p.WitnessCommitment) != 32 {
+			return nil, errors.New("coinbase: witness commitment must be 32 bytes")
+		}
+		tx.AddTxOut(wire.NewTxOut(0, witnessCommitmentScript(p.WitnessCommitment)))
+		tx.TxIn[0].Witness = wire.TxWitness{make([]byte, blockchain.CoinbaseWitnessDataLen)}
+	}
+	return tx, nil
+}
@@ -0,0 +1,13 @@
+package coinbase_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCoinbase(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Coinbase Suite")
+}
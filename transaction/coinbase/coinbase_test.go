@@ -0,0 +1,142 @@
+package coinbase_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/satshub/go-bitcoind/jsonrpc"
+	"github.com/satshub/go-bitcoind/jsonrpc/jsonrpctest"
+	"github.com/satshub/go-bitcoind/transaction/coinbase"
+)
+
+var payoutScript = []byte{txscript.OP_TRUE}
+
+// spend returns a transaction spending the first output of prev, leaving
+// fee, with a dummy witness.
+func spend(prev *wire.MsgTx, fee int64) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	in := wire.NewTxIn(&wire.OutPoint{Hash: prev.TxHash()}, nil, wire.TxWitness{{0x01}})
+	tx.AddTxIn(in)
+	tx.AddTxOut(wire.NewTxOut(prev.TxOut[0].Value-fee, payoutScript))
+	return tx
+}
+
+var _ = Describe("Coinbase", func() {
+	Describe("script", func() {
+		Context("when the height is small", func() {
+			script, err := coinbase.Script(1, 7, nil)
+			It("should push the height then the extra nonce", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(script).To(Equal([]byte{txscript.OP_1, 8, 7, 0, 0, 0, 0, 0, 0, 0}))
+			})
+		})
+
+		Context("when the flags are too long", func() {
+			_, err := coinbase.Script(800000, 0, make([]byte, 90))
+			It("should error", func() {
+				Expect(err).To(MatchError("coinbase: signature script exceeds 100 bytes, shorten the flags"))
+			})
+		})
+
+		Context("when building a coinbase", func() {
+			commitment := coinbase.WitnessCommitment(nil)
+			tx, err := coinbase.New(coinbase.Params{Height: 840000, Value: 312500000, PayoutScript: payoutScript, WitnessCommitment: commitment})
+			It("should not error", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should be BIP34 compliant", func() {
+				height, err := blockchain.ExtractCoinbaseHeight(btcutil.NewTx(tx))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(height).To(Equal(int32(840000)))
+				Expect(blockchain.IsCoinBaseTx(tx)).To(BeTrue())
+			})
+			It("should commit to the witnesses", func() {
+				Expect(tx.TxOut).To(HaveLen(2))
+				Expect(tx.TxOut[1].PkScript).To(Equal(append(append([]byte(nil), blockchain.WitnessMagicBytes...), commitment...)))
+				Expect(tx.TxIn[0].Witness).To(Equal(wire.TxWitness{make([]byte, 32)}))
+			})
+		})
+	})
+
+	Describe("miner", func() {
+		Context("when mining on regtest", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			b1 := s.MineBlock()
+			tx := spend(b1.Transactions[0], 5000)
+			s.AddMempoolTx(tx)
+			miner := coinbase.NewMiner(s.Client(), payoutScript)
+			miner.Flags = []byte("/go-bitcoind/")
+			ctx := context.Background()
+			tmpl, err := miner.NextTemplate(ctx)
+			block, errMine := miner.Mine(ctx, tmpl)
+			hash, height := s.Tip()
+			It("should decode the template", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tmpl.Height).To(Equal(int64(2)))
+				Expect(tmpl.Segwit).To(BeTrue())
+				Expect(tmpl.CoinbaseValue).To(Equal(blockchain.CalcBlockSubsidy(2, &chaincfg.RegressionNetParams) + 5000))
+				Expect(tmpl.Transactions).To(HaveLen(1))
+			})
+			It("should have the block accepted", func() {
+				Expect(errMine).NotTo(HaveOccurred())
+				Expect(height).To(Equal(int64(2)))
+				Expect(hash).To(Equal(block.BlockHash()))
+			})
+			It("should pay the coinbase value to the payout script", func() {
+				Expect(block.Transactions[0].TxOut[0].Value).To(Equal(tmpl.CoinbaseValue))
+				Expect(block.Transactions[0].TxOut[0].PkScript).To(Equal(payoutScript))
+				Expect(block.Transactions[1].TxHash()).To(Equal(tx.TxHash()))
+			})
+		})
+
+		Context("when long polling", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			miner := coinbase.NewMiner(s.Client(), payoutScript)
+			ctx := context.Background()
+			first, _ := miner.NextTemplate(ctx)
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				s.MineBlock()
+			}()
+			start := time.Now()
+			next, err := miner.NextTemplate(ctx)
+			waited := time.Since(start)
+			timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer cancel()
+			_, errTimeout := miner.NextTemplate(timeoutCtx)
+			It("should wait for a new template", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(waited).To(BeNumerically(">=", 50*time.Millisecond))
+				Expect(next.Height).To(Equal(first.Height + 1))
+			})
+			It("should be bound by the context", func() {
+				Expect(errors.Is(errTimeout, context.DeadlineExceeded)).To(BeTrue())
+			})
+		})
+
+		Context("when the template is stale", func() {
+			s := jsonrpctest.NewServer(nil)
+			defer s.Close()
+			miner := coinbase.NewMiner(s.Client(), payoutScript)
+			ctx := context.Background()
+			tmpl, _ := miner.NextTemplate(ctx)
+			s.MineBlock()
+			_, err := miner.Mine(ctx, tmpl)
+			It("should return the rejection", func() {
+				var rejected *jsonrpc.BlockRejectedError
+				Expect(errors.As(err, &rejected)).To(BeTrue())
+				Expect(rejected.Reason).To(Equal("inconclusive"))
+			})
+		})
+	})
+})
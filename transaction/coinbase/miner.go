@@ -0,0 +1,69 @@
+package coinbase
+
+import (
+	"context"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satshub/go-bitcoind/jsonrpc"
+)
+
+// DefaultRules are the getblocktemplate rules of a Miner, bitcoind requires
+// segwit.
+var DefaultRules = []string{"segwit"}
+
+// A Miner builds blocks from the templates of a bitcoind node and submits
+// them. A Miner is not safe for concurrent use.
+type Miner struct {
+	client       *jsonrpc.Bitcoind
+	payoutScript []byte
+	// Flags are appended to the coinbase signature script of the blocks
+	Flags []byte
+	// Rules are sent to getblocktemplate, DefaultRules by default
+	Rules []string
+
+	longPollID string
+	extraNonce uint64
+}
+
+// NewMiner returns a Miner paying the coinbase of its blocks to
+// payoutScript.
+func NewMiner(client *jsonrpc.Bitcoind, payoutScript []byte) *Miner {
+	return &Miner{client: client, payoutScript: payoutScript, Rules: DefaultRules}
+}
+
+// NextTemplate returns the current template of the node on the first call.
+// Later calls long poll: they return once the template differs from the
+// previous one, because a block was found or the mempool changed, or when
+// ctx is done.
+func (m *Miner) NextTemplate(ctx context.Context) (*Template, error) {
+	r, err := m.client.WithContext(ctx).GetBlockTemplateLongPoll(m.Rules, m.longPollID)
+	if err != nil {
+		return nil, err
+	}
+	t, err := ParseTemplate(&r)
+	if err != nil {
+		return nil, err
+	}
+	m.longPollID = t.LongPollID
+	return t, nil
+}
+
+// Mine builds blocks on t, bumping the extra nonce each time the nonce
+// space is exhausted, until one is solved, and submits it. It returns the
+// block, or a *jsonrpc.BlockRejectedError when the node did not accept it,
+// eg because t is stale.
+func (m *Miner) Mine(ctx context.Context, t *Template) (*wire.MsgBlock, error) {
+	for {
+		m.extraNonce++
+		block, err := t.Block(m.payoutScript, m.extraNonce, m.Flags)
+		if err != nil {
+			return nil, err
+		}
+		if Solve(ctx, &block.Header) {
+			return block, m.client.WithContext(ctx).SubmitBlockMsg(block)
+		}
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package coinbase
+
+import (
+	"context"
+	"math"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ctxCheckInterval is the number of nonces tried between checks of the
+// context.
+const ctxCheckInterval = 1 << 16
+
+// Solve searches, from header.Nonce up, a nonce for which the hash of
+// header is below the target of its bits, and sets it. It returns false
+// when the nonce space is exhausted, the block must then be rebuilt with
+// another extra nonce, or when ctx is done.
+//
+// The search runs on one CPU core, it is only practical on regtest.
+func Solve(ctx context.Context, header *wire.BlockHeader) bool {
+	target := blockchain.CompactToBig(header.Bits)
+	for nonce := uint64(header.Nonce); nonce <= math.MaxUint32; nonce++ {
+		if nonce%ctxCheckInterval == 0 && ctx.Err() != nil {
+			return false
+		}
+		header.Nonce = uint32(nonce)
+		hash := header.BlockHash()
+		if blockchain.HashToBig(&hash).Cmp(target) <= 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -2,11 +2,15 @@ package internal
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
-	"math"
 	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/satshub/go-bitcoind/jsonrpc"
 )
 
 type BitcoinRpc struct {
@@ -24,6 +28,34 @@ func defaultJsonRpcInfo() (info map[string]interface{}) {
 	return
 }
 
+// clients caches the jsonrpc client of each BitcoinRpc, so its connections
+// are reused across calls.
+var clients sync.Map
+
+// client returns the jsonrpc client for the node and path of bitcoinRpc, the
+// raw transaction calls go through its typed API.
+func (bitcoinRpc BitcoinRpc) client() (client *jsonrpc.Bitcoind, err error) {
+	if cached, ok := clients.Load(bitcoinRpc); ok {
+		client = cached.(*jsonrpc.Bitcoind)
+		return
+	}
+	port, err := strconv.Atoi(bitcoinRpc.RpcPort)
+	if err != nil {
+		err = fmt.Errorf("@strconv.Atoi(bitcoinRpc.RpcPort): %v", err)
+		return
+	}
+	client, err = jsonrpc.NewWithOptions(bitcoinRpc.RpcConnect, port,
+		jsonrpc.WithBasicAuth(bitcoinRpc.RpcUser, bitcoinRpc.RpcPW),
+		jsonrpc.WithPath(bitcoinRpc.RpcPath))
+	if err != nil {
+		err = fmt.Errorf("@jsonrpc.NewWithOptions(...): %v", err)
+		return
+	}
+	cached, _ := clients.LoadOrStore(bitcoinRpc, client)
+	client = cached.(*jsonrpc.Bitcoind)
+	return
+}
+
 func (bitcoinRpc BitcoinRpc) request(jsonRpcBytes []byte) (body []byte, err error) {
 
 	request, err := http.NewRequest("POST", fmt.Sprintf("http://%s:%s/%s", bitcoinRpc.RpcConnect, bitcoinRpc.RpcPort, bitcoinRpc.RpcPath), bytes.NewBuffer(jsonRpcBytes))
@@ -121,63 +153,66 @@ func (bitcoinRpc BitcoinRpc) ListUnspentOfAddress(minconf int, maxconf int, addr
 
 func (bitcoinRpc BitcoinRpc) CreateRawTransaction(inTxUnspents []map[string]interface{}, outAddresses map[string]float64, outDataHex string) (rawTx string, err error) {
 
-	tCreateTxOuts := make([]map[string]interface{}, 0)
+	inputs := make([]jsonrpc.TxInput, 0)
+	for _, inTxUnspent := range inTxUnspents {
+		input := jsonrpc.TxInput{}
+		input.Txid, _ = inTxUnspent["txid"].(string)
+		switch vout := inTxUnspent["vout"].(type) {
+		case int:
+			input.Vout = uint32(vout)
+		case float64:
+			input.Vout = uint32(vout)
+		default:
+			err = fmt.Errorf("incorrect vout[%v] of txid[%s]", inTxUnspent["vout"], input.Txid)
+			return
+		}
+		inputs = append(inputs, input)
+	}
 
-	// outParamsAddressAmount
+	outputs := make([]jsonrpc.Output, 0)
 	for outAddress, outAmount := range outAddresses {
-		tParamsAddress := make(map[string]interface{})
-		outAmount = math.Round((outAmount)*100000000) / 100000000
 		if outAmount < 0.00000000 {
 			// Only Filtering when minus-amount
 			// Zero-amount is needed sometimes
 			// Zero-amount will be controlled on service
 			continue
 		}
-		tParamsAddress[outAddress] = outAmount
-		tCreateTxOuts = append(tCreateTxOuts, tParamsAddress)
+		amount, errInner := jsonrpc.NewAmount(outAmount)
+		if errInner != nil {
+			err = fmt.Errorf("@jsonrpc.NewAmount(%f): %v", outAmount, errInner)
+			return
+		}
+		outputs = append(outputs, jsonrpc.Output{Address: outAddress, Amount: amount})
 	}
 
-	// outParamsData
 	if outDataHex != "" {
-		tCreateTxOuts = append(tCreateTxOuts, map[string]interface{}{"data": outDataHex})
+		data, errInner := hex.DecodeString(outDataHex)
+		if errInner != nil {
+			err = fmt.Errorf("@hex.DecodeString(outDataHex): %v", errInner)
+			return
+		}
+		outputs = append(outputs, jsonrpc.Output{Data: data})
 	}
 
-	if len(tCreateTxOuts) == 0 {
-		err = fmt.Errorf("len(tCreateTxOuts) == 0 : incorrect outAddresses and outDataHex")
+	if len(outputs) == 0 {
+		err = fmt.Errorf("len(outputs) == 0 : incorrect outAddresses and outDataHex")
 		return
 	}
 
-	jsonRpcInfo := defaultJsonRpcInfo()
-	jsonRpcInfo["method"] = "createrawtransaction"
-	jsonRpcInfo["params"] = []interface{}{inTxUnspents, tCreateTxOuts}
-	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	client, err := bitcoinRpc.client()
 	if err != nil {
-		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
 		return
 	}
-
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	tx, err := client.CreateRawTransaction(inputs, outputs, 0, false)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@client.CreateRawTransaction(inputs, outputs): %v", err)
 		return
 	}
 
-	type resultCreateRaxTx struct {
-		RawTx string `json:"result"`
-	}
-	result := resultCreateRaxTx{}
-	err = json.Unmarshal(body, &result)
+	rawTx, err = jsonrpc.EncodeTx(tx)
 	if err != nil {
-		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
-		return
-	}
-	rawTx = result.RawTx
-
-	if rawTx == "" {
-		err = fmt.Errorf("rawTx == '': rawTx of result is empty")
-		return
+		err = fmt.Errorf("@jsonrpc.EncodeTx(tx): %v", err)
 	}
-
 	return
 }
 
@@ -215,72 +250,47 @@ func (bitcoinRpc BitcoinRpc) DumpPrivateKey(address string) (privKey string, err
 
 func (bitcoinRpc BitcoinRpc) SignRawTransactionWithKey(rawTx string, privKey string) (signedRawTx string, err error) {
 
-	jsonRpcInfo := defaultJsonRpcInfo()
-	jsonRpcInfo["method"] = "signrawtransactionwithkey"
-	jsonRpcInfo["params"] = []interface{}{rawTx, []string{privKey}}
-	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	tx, err := jsonrpc.DecodeTx(rawTx)
 	if err != nil {
-		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		err = fmt.Errorf("@jsonrpc.DecodeTx(rawTx): %v", err)
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	client, err := bitcoinRpc.client()
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
 		return
 	}
-
-	type signedRawTxInfo struct {
-		Hex      string `json:"hex"`      // (string) the transaction id
-		Complete bool   `json:"complete"` // (numeric) the vout value
-	}
-	type resultSignedRawTxInfo struct {
-		SignedRawTxInfo signedRawTxInfo `json:"result"`
-	}
-
-	// tmpBody := string(body)
-	// fmt.Printf("%s", tmpBody)
-
-	result := resultSignedRawTxInfo{}
-	err = json.Unmarshal(body, &result)
+	result, err := client.SignRawTransactionWithKey(tx, []string{privKey}, nil, "")
 	if err != nil {
-		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		err = fmt.Errorf("@client.SignRawTransactionWithKey(tx, privKey): %v", err)
 		return
 	}
 
-	signedRawTx = result.SignedRawTxInfo.Hex
-
+	signedRawTx, err = jsonrpc.EncodeTx(result.Tx)
+	if err != nil {
+		err = fmt.Errorf("@jsonrpc.EncodeTx(result.Tx): %v", err)
+	}
 	return
 }
 
 func (bitcoinRpc BitcoinRpc) SendRawTransaction(signedRawTx string) (txID string, err error) {
 
-	jsonRpcInfo := defaultJsonRpcInfo()
-	jsonRpcInfo["method"] = "sendrawtransaction"
-	jsonRpcInfo["params"] = []interface{}{signedRawTx}
-	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	tx, err := jsonrpc.DecodeTx(signedRawTx)
 	if err != nil {
-		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		err = fmt.Errorf("@jsonrpc.DecodeTx(signedRawTx): %v", err)
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	client, err := bitcoinRpc.client()
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
 		return
 	}
-
-	type resultSendRawTx struct {
-		TxID string `json:"result"`
-	}
-	result := resultSendRawTx{}
-	err = json.Unmarshal(body, &result)
+	txID, err = client.SendRawTransaction(tx, nil)
 	if err != nil {
-		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		err = fmt.Errorf("@client.SendRawTransaction(tx): %v", err)
 		return
 	}
 
-	txID = result.TxID
 	return
 }
 
@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satshub/go-bitcoind/jsonrpc"
+	"github.com/satshub/go-bitcoind/jsonrpc/jsonrpctest"
+)
+
+// TestFakeBitcoind runs BitcoinRpc against the fake bitcoind of jsonrpctest.
+func TestFakeBitcoind(t *testing.T) {
+	s := jsonrpctest.NewServer(nil)
+	defer s.Close()
+	s.SetAuth("user", "pass")
+	bitcoinRpc := BitcoinRpc{
+		RpcUser:    "user",
+		RpcPW:      "pass",
+		RpcConnect: s.Host,
+		RpcPort:    strconv.Itoa(s.Port),
+		RpcPath:    "wallet/test",
+	}
+
+	b1 := s.MineBlock()
+	blockCount, err := bitcoinRpc.GetBlockCount()
+	if err != nil || blockCount != 1 {
+		t.Fatalf("GetBlockCount() = %d, %v", blockCount, err)
+	}
+	blockHash, err := bitcoinRpc.GetBlockHash(1)
+	if err != nil || blockHash != b1.BlockHash().String() {
+		t.Fatalf("GetBlockHash(1) = %s, %v", blockHash, err)
+	}
+	block, err := bitcoinRpc.GetBlock(blockHash)
+	if err != nil || block["height"] != int64(1) || len(block["tx"].([]string)) != 1 {
+		t.Fatalf("GetBlock(%s) = %v, %v", blockHash, block, err)
+	}
+
+	coinbase := b1.Transactions[0]
+	s.AddUTXO(jsonrpctest.UTXO{
+		TxID:          coinbase.TxHash().String(),
+		Address:       "bcrt1qaddress",
+		Amount:        jsonrpc.Amount(coinbase.TxOut[0].Value),
+		Confirmations: 1,
+	})
+	unspents, err := bitcoinRpc.ListUnspentOfAddress(0, 0, []string{"bcrt1qaddress"})
+	if err != nil || len(unspents) != 1 || unspents[0]["txid"] != coinbase.TxHash().String() {
+		t.Fatalf("ListUnspentOfAddress() = %v, %v", unspents, err)
+	}
+
+	// The fake server has no wallet to build and sign transactions, the
+	// handlers check the typed requests and answer with fixed transactions.
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: coinbase.TxHash()}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(coinbase.TxOut[0].Value-1000, []byte{txscript.OP_TRUE}))
+	var buf bytes.Buffer
+	if err = tx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	txHex := hex.EncodeToString(buf.Bytes())
+	s.Handle("createrawtransaction", func(params json.RawMessage) (interface{}, error) {
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 {
+			t.Errorf("createrawtransaction params = %s", params)
+		} else if string(args[1]) != `[{"data":"48454c4c4f"}]` {
+			t.Errorf("createrawtransaction outputs = %s", args[1])
+		}
+		return txHex, nil
+	})
+	s.Handle("signrawtransactionwithkey", func(params json.RawMessage) (interface{}, error) {
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil || len(args) < 2 || string(args[1]) != `["privkey"]` {
+			t.Errorf("signrawtransactionwithkey params = %s", params)
+		}
+		return map[string]interface{}{"hex": txHex, "complete": true}, nil
+	})
+
+	inTxUnspents := []map[string]interface{}{{"txid": coinbase.TxHash().String(), "vout": 0}}
+	rawTx, err := bitcoinRpc.CreateRawTransaction(inTxUnspents, nil, "48454c4c4f")
+	if err != nil || rawTx != txHex {
+		t.Fatalf("CreateRawTransaction() = %s, %v", rawTx, err)
+	}
+	signedRawTx, err := bitcoinRpc.SignRawTransactionWithKey(rawTx, "privkey")
+	if err != nil || signedRawTx != txHex {
+		t.Fatalf("SignRawTransactionWithKey() = %s, %v", signedRawTx, err)
+	}
+	txID, err := bitcoinRpc.SendRawTransaction(signedRawTx)
+	if err != nil || txID != tx.TxHash().String() {
+		t.Fatalf("SendRawTransaction() = %s, %v", txID, err)
+	}
+	rawTxInfo, err := bitcoinRpc.GetRawTransaction(txID)
+	if err != nil || rawTxInfo["hex"] != txHex {
+		t.Fatalf("GetRawTransaction(%s) = %v, %v", txID, rawTxInfo, err)
+	}
+
+	for _, call := range s.CallsOf("createrawtransaction") {
+		if call.Wallet != "test" {
+			t.Errorf("createrawtransaction called on wallet %q", call.Wallet)
+		}
+	}
+}
+
+// TestFakeBitcoindZeroInputs signs a transaction without inputs, which
+// only decodes with the legacy serialization.
+func TestFakeBitcoindZeroInputs(t *testing.T) {
+	s := jsonrpctest.NewServer(nil)
+	defer s.Close()
+	bitcoinRpc := BitcoinRpc{
+		RpcConnect: s.Host,
+		RpcPort:    strconv.Itoa(s.Port),
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+	var buf bytes.Buffer
+	if err := tx.SerializeNoWitness(&buf); err != nil {
+		t.Fatal(err)
+	}
+	txHex := hex.EncodeToString(buf.Bytes())
+	s.Handle("signrawtransactionwithkey", func(params json.RawMessage) (interface{}, error) {
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil || len(args) < 1 || string(args[0]) != `"`+txHex+`"` {
+			t.Errorf("signrawtransactionwithkey params = %s", params)
+		}
+		return map[string]interface{}{"hex": txHex, "complete": false}, nil
+	})
+
+	signedRawTx, err := bitcoinRpc.SignRawTransactionWithKey(txHex, "privkey")
+	if err != nil || signedRawTx != txHex {
+		t.Fatalf("SignRawTransactionWithKey() = %s, %v", signedRawTx, err)
+	}
+}
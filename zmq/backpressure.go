@@ -0,0 +1,329 @@
+package zmq
+
+import (
+	"encoding/gob"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultSpillMemLimit = 1024
+
+// backpressure is the policy of a subscription for messages that don't fit in its channel.
+type backpressure int
+
+const (
+	dropOldest backpressure = iota
+	dropNewest
+	block
+	spill
+)
+
+// A SubOption configures a subscription. By default a full subscription channel drops
+// its oldest message to make room for the newest.
+type SubOption func(*subOptions) error
+
+// subOptions represents the settings of a subscription collected from SubOptions.
+type subOptions struct {
+	policy        backpressure
+	timeout       time.Duration
+	spillDir      string
+	spillMemLimit int
+
+	dropped uint64 // Set atomically.
+	// spill is the *spillQueue of the subscription with the spill policy.
+	spill interface{ stop() }
+}
+
+// WithDropNewest drops the newest message when the subscription channel is full.
+func WithDropNewest() SubOption {
+	return func(o *subOptions) error {
+		o.policy = dropNewest
+		return nil
+	}
+}
+
+// WithBlock waits up to timeout for room in the subscription channel before dropping
+// the message, or until the client is closed if timeout is zero.
+// While waiting no message is delivered to the other subscriptions, and canceling
+// subscriptions blocks.
+func WithBlock(timeout time.Duration) SubOption {
+	return func(o *subOptions) error {
+		if timeout < 0 {
+			return errors.New("Bad option: negative block timeout")
+		}
+		o.policy = block
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithSpill queues the messages that don't fit in the subscription channel, so none is
+// dropped. The queue is held in memory, or when dir is set, up to memLimit messages in
+// memory and the rest in a temporary file in dir. If memLimit is not set (or set to zero)
+// then it defaults to DefaultSpillMemLimit.
+//
+// The messages are written to disk with encoding/gob, messages that can't be encoded
+// (GapMsg with an Err) are dropped.
+func WithSpill(dir string, memLimit int) SubOption {
+	return func(o *subOptions) error {
+		if memLimit < 0 {
+			return errors.New("Bad option: negative spill memory limit")
+		}
+		if memLimit == 0 {
+			memLimit = DefaultSpillMemLimit
+		}
+		o.policy = spill
+		o.spillDir = dir
+		o.spillMemLimit = memLimit
+		return nil
+	}
+}
+
+func newSubOptions(opts []SubOption) (*subOptions, error) {
+	o := new(subOptions)
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Dropped returns the number of messages the subscription channel subCh dropped because it
+// was full. It returns zero once the subscription is canceled.
+func (bc *BitcoindClient) Dropped(subCh interface{}) uint64 {
+	bc.subs.RLock()
+	defer bc.subs.RUnlock()
+	if o := bc.subs.opts[subCh]; o != nil {
+		return atomic.LoadUint64(&o.dropped)
+	}
+	return 0
+}
+
+// registerSub records the options o of the subscription channel ch. Must be called with subs locked.
+func registerSub[T any](subs *subscriptions, ch chan T, o *subOptions) {
+	if subs.opts == nil {
+		subs.opts = make(map[interface{}]*subOptions)
+	}
+	if o.policy == spill {
+		o.spill = newSpillQueue(ch, o.spillDir, o.spillMemLimit, &o.dropped)
+	}
+	subs.opts[ch] = o
+}
+
+// closeSub closes the subscription channel ch and releases its options. Must be called with subs locked.
+func closeSub[T any](subs *subscriptions, ch chan T) {
+	if o := subs.opts[ch]; o != nil {
+		if o.spill != nil {
+			o.spill.stop()
+		}
+		delete(subs.opts, ch)
+	}
+	close(ch)
+}
+
+// push pushes msg onto the subscription channels chs, according to their policies.
+// Must be called with subs read locked.
+func push[T any](subs *subscriptions, chs []chan T, msg T) {
+	for _, ch := range chs {
+		o := subs.opts[ch]
+		if o == nil {
+			o = new(subOptions)
+		}
+		switch o.policy {
+		case dropOldest:
+			select {
+			case ch <- msg:
+			default:
+				select {
+				// Pop the oldest item and push the newest item (the user will miss a message).
+				case _ = <-ch:
+					atomic.AddUint64(&o.dropped, 1)
+					ch <- msg
+				case ch <- msg:
+				default:
+					atomic.AddUint64(&o.dropped, 1)
+				}
+			}
+		case dropNewest:
+			select {
+			case ch <- msg:
+			default:
+				atomic.AddUint64(&o.dropped, 1)
+			}
+		case block:
+			select {
+			case ch <- msg:
+				continue
+			default:
+			}
+			var timer *time.Timer
+			var timeout <-chan time.Time
+			if o.timeout > 0 {
+				timer = time.NewTimer(o.timeout)
+				timeout = timer.C
+			}
+			select {
+			case ch <- msg:
+			case <-timeout:
+				atomic.AddUint64(&o.dropped, 1)
+			case <-subs.closing:
+				atomic.AddUint64(&o.dropped, 1)
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+		case spill:
+			if err := o.spill.(*spillQueue[T]).put(msg); err != nil {
+				atomic.AddUint64(&o.dropped, 1)
+			}
+		}
+	}
+}
+
+// spillQueue is an unbounded FIFO queue feeding a subscription channel. The
+// messages beyond memLimit are kept in a file when dir is set.
+type spillQueue[T any] struct {
+	ch       chan T
+	dir      string
+	memLimit int
+	dropped  *uint64
+
+	mu  sync.Mutex
+	mem []T
+	// The messages on disk are newer than the ones in mem, they are appended
+	// through w and read back in order through r.
+	w, r   *os.File
+	enc    *gob.Encoder
+	dec    *gob.Decoder
+	onDisk int
+
+	ready chan struct{}
+	quit  chan struct{}
+	done  chan struct{}
+}
+
+func newSpillQueue[T any](ch chan T, dir string, memLimit int, dropped *uint64) *spillQueue[T] {
+	q := &spillQueue[T]{
+		ch:       ch,
+		dir:      dir,
+		memLimit: memLimit,
+		dropped:  dropped,
+		ready:    make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go q.forward()
+	return q
+}
+
+func (q *spillQueue[T]) put(msg T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.dir == "" || (q.onDisk == 0 && len(q.mem) < q.memLimit) {
+		q.mem = append(q.mem, msg)
+	} else {
+		if q.w == nil {
+			if err := q.openFile(); err != nil {
+				return err
+			}
+		}
+		if err := q.enc.Encode(&msg); err != nil {
+			return err
+		}
+		q.onDisk++
+	}
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pop removes and returns the oldest message, ok is false if the queue is empty.
+func (q *spillQueue[T]) pop() (msg T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.mem) == 0 && q.onDisk > 0 {
+		for len(q.mem) < q.memLimit && q.onDisk > 0 {
+			var m T
+			if err := q.dec.Decode(&m); err != nil {
+				// The rest of the file is lost.
+				atomic.AddUint64(q.dropped, uint64(q.onDisk))
+				q.onDisk = 0
+				break
+			}
+			q.mem = append(q.mem, m)
+			q.onDisk--
+		}
+		if q.onDisk == 0 {
+			q.closeFile()
+		}
+	}
+	if len(q.mem) == 0 {
+		return
+	}
+	msg, ok = q.mem[0], true
+	var zero T
+	q.mem[0] = zero
+	q.mem = q.mem[1:]
+	return
+}
+
+// forward feeds the subscription channel from the queue until stopped.
+func (q *spillQueue[T]) forward() {
+	defer close(q.done)
+	for {
+		msg, ok := q.pop()
+		if !ok {
+			select {
+			case <-q.ready:
+				continue
+			case <-q.quit:
+				return
+			}
+		}
+		select {
+		case q.ch <- msg:
+		case <-q.quit:
+			return
+		}
+	}
+}
+
+// stop stops feeding the subscription channel, so that it can be closed, and removes the file.
+func (q *spillQueue[T]) stop() {
+	close(q.quit)
+	<-q.done
+	q.mu.Lock()
+	q.closeFile()
+	q.mu.Unlock()
+}
+
+func (q *spillQueue[T]) openFile() (err error) {
+	if q.w, err = os.CreateTemp(q.dir, "zmq-spill-*"); err != nil {
+		return err
+	}
+	if q.r, err = os.Open(q.w.Name()); err != nil {
+		q.w.Close()
+		os.Remove(q.w.Name())
+		q.w = nil
+		return err
+	}
+	q.enc = gob.NewEncoder(q.w)
+	q.dec = gob.NewDecoder(q.r)
+	return nil
+}
+
+func (q *spillQueue[T]) closeFile() {
+	if q.w == nil {
+		return
+	}
+	q.r.Close()
+	q.w.Close()
+	os.Remove(q.w.Name())
+	q.w, q.r, q.enc, q.dec = nil, nil, nil, nil
+}
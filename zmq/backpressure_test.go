@@ -0,0 +1,94 @@
+package zmq
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSub(t *testing.T, bc *BitcoindClient, opts ...SubOption) chan HashMsg {
+	o, err := newSubOptions(opts)
+	require.NoError(t, err)
+	ch := make(chan HashMsg, 2)
+	bc.subs.Lock()
+	registerSub(&bc.subs, ch, o)
+	bc.subs.Unlock()
+	t.Cleanup(func() {
+		bc.subs.Lock()
+		closeSub(&bc.subs, ch)
+		bc.subs.Unlock()
+	})
+	return ch
+}
+
+func pushSeqs(bc *BitcoindClient, ch chan HashMsg, seqs ...uint32) {
+	bc.subs.RLock()
+	defer bc.subs.RUnlock()
+	for _, seq := range seqs {
+		push(&bc.subs, []chan HashMsg{ch}, HashMsg{Seq: seq})
+	}
+}
+
+func recvSeqs(ch chan HashMsg, n int) (seqs []uint32) {
+	for i := 0; i < n; i++ {
+		seqs = append(seqs, (<-ch).Seq)
+	}
+	return
+}
+
+func TestBackpressure(t *testing.T) {
+	bc := &BitcoindClient{}
+
+	oldest := newTestSub(t, bc)
+	pushSeqs(bc, oldest, 1, 2, 3, 4)
+	assert.Equal(t, []uint32{3, 4}, recvSeqs(oldest, 2))
+	assert.Equal(t, uint64(2), bc.Dropped(oldest))
+
+	newest := newTestSub(t, bc, WithDropNewest())
+	pushSeqs(bc, newest, 1, 2, 3, 4)
+	assert.Equal(t, []uint32{1, 2}, recvSeqs(newest, 2))
+	assert.Equal(t, uint64(2), bc.Dropped(newest))
+
+	blocking := newTestSub(t, bc, WithBlock(50*time.Millisecond))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-blocking
+	}()
+	pushSeqs(bc, blocking, 1, 2, 3, 4)
+	assert.Equal(t, []uint32{2, 3}, recvSeqs(blocking, 2))
+	assert.Equal(t, uint64(1), bc.Dropped(blocking))
+
+	_, err := newSubOptions([]SubOption{WithBlock(-time.Second)})
+	assert.EqualError(t, err, "Bad option: negative block timeout")
+}
+
+func TestBackpressureSpill(t *testing.T) {
+	bc := &BitcoindClient{}
+	dir := t.TempDir()
+
+	memory := newTestSub(t, bc, WithSpill("", 0))
+	disk := newTestSub(t, bc, WithSpill(dir, 3))
+	var seqs []uint32
+	for seq := uint32(1); seq <= 20; seq++ {
+		seqs = append(seqs, seq)
+	}
+	pushSeqs(bc, memory, seqs...)
+	pushSeqs(bc, disk, seqs[:10]...)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Equal(t, seqs[:5], recvSeqs(disk, 5))
+	pushSeqs(bc, disk, seqs[10:]...)
+	assert.Equal(t, seqs[5:], recvSeqs(disk, 15))
+	assert.Equal(t, seqs, recvSeqs(memory, 20))
+	assert.Zero(t, bc.Dropped(disk))
+
+	// The file is removed once drained.
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
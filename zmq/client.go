@@ -1,24 +1,30 @@
 package zmq
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/joakimofv/sanity"
 	zmq "github.com/pebbe/zmq4"
 )
 
 const (
-	MAJOR_VERSION               = 23
-	DefaultSubChannelBufferSize = 2
-	DefaultRpcUriPath           = "/"
+	MAJOR_VERSION                  = 23
+	DefaultSubChannelBufferSize    = 2
+	DefaultRpcUriPath              = "/"
+	DefaultZmqReconnectInterval    = 100 * time.Millisecond
+	DefaultZmqReconnectIntervalMax = time.Minute
 )
 
 var (
 	ErrRpcDisabled       = errors.New("RPC disabled (RpcAddress was not set).")
-	ErrSubscribeDisabled = errors.New("Subscribe disabled (ZmqPubAddress was not set).")
+	ErrSubscribeDisabled = errors.New("Subscribe disabled (ZmqPubAddress was not set for the topic).")
 	ErrSubscribeExited   = errors.New("Subscription backend has exited.")
 )
 
@@ -45,20 +51,50 @@ type Config struct {
 	// ZmqPubAddress is the public address that the bitcoind instance uses for zmqpub,
 	// corresponding to what is set when starting bitcoind through one or multiple of:
 	// {-zmqpubhashtx=address -zmqpubhashblock=address -zmqpubrawblock=address -zmqpubrawtx=address -zmqpubsequence=address}.
-	// Only a single address is supported in this client. Either use the same address for
-	// all desired topics when starting bitcoind, or create a seperate client for each address.
+	// Topics published on other addresses can be set in ZmqTopicAddresses.
 	//
 	// Example: "tcp://8.8.8.8:1234"
 	// More examples at: https://github.com/bitcoin/bitcoin/blob/master/doc/zmq.md (the host part in those examples
 	// are local IPs and should be replaced with public IPs here on the client side)
 	//
-	// If ZmqPubAddress is not set then the Subscribe functions will return ErrSubscribeDisabled when called.
+	// If no address is set for a topic then its Subscribe functions will return ErrSubscribeDisabled when called.
 	ZmqPubAddress string
 
+	// ZmqTopicAddresses maps topics ("hashtx", "hashblock", "rawtx", "rawblock" or "sequence") to the
+	// zmqpub address they are published on, overriding ZmqPubAddress.
+	//
+	// Example: map[string]string{"rawblock": "tcp://8.8.8.8:1235"}
+	ZmqTopicAddresses map[string]string
+
+	// ZmqSilenceTimeout is how long the connection to an address with subscribed topics can go
+	// without a message before it is considered stale and reconnected, see SubscribeStatus.
+	// Set it according to the topics, eg blocks are ten minutes apart on average but an hour
+	// without one is not unusual.
+	// If not set (or set to zero) then stale connections are not detected.
+	ZmqSilenceTimeout time.Duration
+
+	// ZmqHeartbeatInterval enables ZMTP heartbeats (libzmq 4.2+), that detect dead connections
+	// faster than TCP does.
+	// If not set (or set to zero) then heartbeats are disabled.
+	ZmqHeartbeatInterval time.Duration
+
+	// ZmqReconnectInterval and ZmqReconnectIntervalMax bound the backoff of reconnections, the
+	// interval doubles from the former up to the latter.
+	// If not set (or set to zero) then default to DefaultZmqReconnectInterval and DefaultZmqReconnectIntervalMax.
+	ZmqReconnectInterval    time.Duration
+	ZmqReconnectIntervalMax time.Duration
+
 	// SubChannelBufferSize sets the number of entries that a subscription channel can hold
 	// before dropping entries, if it is not drained fast enough.
 	// If not set (or set to zero) then defaults to DefaultSubChannelBufferSize.
 	SubChannelBufferSize int
+
+	// CatchUp enables back-filling the messages missed in a gap of the sequence numbers,
+	// see GapMsg. Missed blocks are fetched with getblockhash and getblock, missed mempool
+	// transactions with getrawmempool and getrawtransaction. The "sequence" topic is not
+	// back-filled. The catch-up runs on a goroutine per topic, the messages of the topic
+	// received meanwhile are held back and delivered after the replayed ones. Requires RpcAddress.
+	CatchUp bool
 }
 
 // BitcoindClient is a client that provides methods for interacting with bitcoind.
@@ -69,20 +105,26 @@ type BitcoindClient struct {
 	closed int32 // Set atomically.
 	wg     sync.WaitGroup
 	quit   chan struct{}
+	// ctx is canceled by Close, to abort the RPC calls of the zmqHandler goroutine.
+	ctx  context.Context
+	stop context.CancelFunc
 
 	Cfg Config
 
 	// httpClient is a reusable and concurrency safe client for all HTTP requests.
 	// JSON-RPC is over HTTP.
 	httpClient http.Client
+	rpcID      uint64 // Set atomically.
 
 	// ZMQ subscription related things.
-	zctx *zmq.Context
-	zsub *zmq.Socket
-	subs subscriptions
+	zctx      *zmq.Context
+	endpoints []*endpoint
+	// zsubs maps the topics to the endpoint they are published on, topics without an address are missing.
+	zsubs map[string]*endpoint
+	subs  subscriptions
 	// subs.zfront --> zback is used like a channel to send messages to the zmqHandler goroutine.
 	// Have to use zmq sockets in place of native channels for communication from
-	// other functions to the goroutine, since it is constantly waiting on the zsub socket,
+	// other functions to the goroutine, since it is constantly waiting on the zsub sockets,
 	// it can't select on a channel at the same time but can poll on multiple sockets.
 	zback *zmq.Socket
 }
@@ -97,6 +139,7 @@ func New(cfg Config) (*BitcoindClient, error) {
 		Cfg:  cfg,
 		quit: make(chan struct{}),
 	}
+	bc.ctx, bc.stop = context.WithCancel(context.Background())
 
 	// JSON-RPC.
 	if bc.Cfg.RpcAddress != "" {
@@ -108,22 +151,58 @@ func New(cfg Config) (*BitcoindClient, error) {
 		}
 	}
 
+	if bc.Cfg.CatchUp && bc.Cfg.RpcAddress == "" {
+		return nil, errors.New("CatchUp requires RpcAddress.")
+	}
+
 	// ZMQ Subscribe.
-	if bc.Cfg.ZmqPubAddress != "" {
+	addresses := make(map[string]string)
+	for _, topic := range zmqTopics {
+		if address := bc.Cfg.ZmqTopicAddresses[topic]; address != "" {
+			addresses[topic] = address
+		} else if bc.Cfg.ZmqPubAddress != "" {
+			addresses[topic] = bc.Cfg.ZmqPubAddress
+		}
+	}
+	for topic := range bc.Cfg.ZmqTopicAddresses {
+		if _, ok := addresses[topic]; !ok {
+			return nil, fmt.Errorf("Unknown ZMQ topic %q.", topic)
+		}
+	}
+	if len(addresses) > 0 {
 		if bc.Cfg.SubChannelBufferSize == 0 {
 			bc.Cfg.SubChannelBufferSize = DefaultSubChannelBufferSize
 		}
+		if bc.Cfg.ZmqReconnectInterval == 0 {
+			bc.Cfg.ZmqReconnectInterval = DefaultZmqReconnectInterval
+		}
+		if bc.Cfg.ZmqReconnectIntervalMax == 0 {
+			bc.Cfg.ZmqReconnectIntervalMax = DefaultZmqReconnectIntervalMax
+		}
 
 		zctx, err := zmq.NewContext()
 		if err != nil {
 			return nil, err
 		}
-		zsub, err := zctx.NewSocket(zmq.SUB)
-		if err != nil {
-			return nil, err
-		}
-		if err := zsub.Connect(bc.Cfg.ZmqPubAddress); err != nil {
-			return nil, err
+		bc.zsubs = make(map[string]*endpoint)
+		for _, topic := range zmqTopics {
+			address, ok := addresses[topic]
+			if !ok {
+				continue
+			}
+			for _, ep := range bc.endpoints {
+				if ep.address == address {
+					bc.zsubs[topic] = ep
+				}
+			}
+			if bc.zsubs[topic] == nil {
+				ep, err := newEndpoint(zctx, &bc.Cfg, address, len(bc.endpoints))
+				if err != nil {
+					return nil, err
+				}
+				bc.endpoints = append(bc.endpoints, ep)
+				bc.zsubs[topic] = ep
+			}
 		}
 		zback, err := zctx.NewSocket(zmq.PAIR)
 		if err != nil {
@@ -141,8 +220,9 @@ func New(cfg Config) (*BitcoindClient, error) {
 		}
 
 		bc.zctx = zctx
-		bc.zsub = zsub
 		bc.subs.exited = make(chan struct{})
+		bc.subs.connChanged = make(chan struct{})
+		bc.subs.closing = bc.ctx.Done()
 		bc.subs.zfront = zfront
 		bc.zback = zback
 
@@ -158,6 +238,7 @@ func (bc *BitcoindClient) Close() (err error) {
 	if !atomic.CompareAndSwapInt32(&bc.closed, 0, 1) {
 		return errors.New("BitcoindClient already closed")
 	}
+	bc.stop()
 	if bc.zctx != nil {
 		bc.zctx.SetRetryAfterEINTR(false)
 		bc.subs.Lock()
@@ -174,3 +255,45 @@ func (bc *BitcoindClient) Close() (err error) {
 	bc.wg.Wait()
 	return nil
 }
+
+// Ready checks the health of the connections to bitcoind and returns a
+// descriptive error if one of them is down. Disabled connections are not
+// checked.
+//
+// The RPC connection is checked with a getblockchaininfo call, that fails
+// while bitcoind is warming up. ZMQ is healthy when the subscriber is
+// connected to all the zmqpub addresses. The connections are made in the
+// background, so Ready waits for them until ctx is done.
+func (bc *BitcoindClient) Ready(ctx context.Context) error {
+	if bc.Cfg.RpcAddress != "" {
+		if _, err := bc.GetBlockchainInfo(ctx); err != nil {
+			return fmt.Errorf("RPC not ready: %w", err)
+		}
+	}
+	if bc.zctx == nil {
+		return nil
+	}
+	for {
+		bc.subs.RLock()
+		connected, changed := bc.subs.connected, bc.subs.connChanged
+		bc.subs.RUnlock()
+		if connected {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-bc.subs.exited:
+			return ErrSubscribeExited
+		case <-ctx.Done():
+			var addresses []string
+			bc.subs.RLock()
+			for _, ep := range bc.endpoints {
+				if !ep.connected {
+					addresses = append(addresses, ep.address)
+				}
+			}
+			bc.subs.RUnlock()
+			return fmt.Errorf("ZMQ not connected to %s: %w", strings.Join(addresses, ", "), ctx.Err())
+		}
+	}
+}
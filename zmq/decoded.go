@@ -0,0 +1,310 @@
+package zmq
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TxMsg is a subscription event coming from a "rawtx" ZMQ message, decoded.
+// Tx is shared by all the subscriptions and must not be modified.
+type TxMsg struct {
+	Tx       *wire.MsgTx
+	Hash     chainhash.Hash // txid, use Hash.String() to get it into the RPC method string format.
+	Seq      uint32
+	Replayed bool // see RawMsg.Replayed
+}
+
+// BlockMsg is a subscription event coming from a "rawblock" ZMQ message, decoded.
+// Block is shared by all the subscriptions and must not be modified.
+type BlockMsg struct {
+	Block    *wire.MsgBlock
+	Hash     chainhash.Hash // use Hash.String() to get it into the RPC method string format.
+	Seq      uint32
+	Replayed bool // see RawMsg.Replayed
+}
+
+// TxFilter selects the transactions pushed onto a SubscribeTxs channel. A transaction
+// matches when one of its outputs matches one of the criteria. An empty filter matches
+// all transactions.
+type TxFilter struct {
+	// Scripts are output scripts (scriptPubKey).
+	Scripts [][]byte
+	// Addresses are the addresses paid by the outputs.
+	Addresses []btcutil.Address
+	// OpReturnPrefixes are prefixes of the data pushed by OP_RETURN outputs, eg a protocol tag.
+	// The data of all the pushes is concatenated before matching.
+	OpReturnPrefixes [][]byte
+}
+
+// txFilter is a TxFilter prepared for matching.
+type txFilter struct {
+	all              bool
+	scripts          map[string]struct{}
+	opReturnPrefixes [][]byte
+}
+
+type txSubscription struct {
+	ch     chan TxMsg
+	filter txFilter
+}
+
+func newTxFilter(filter *TxFilter) (f txFilter, err error) {
+	if filter == nil || len(filter.Scripts)+len(filter.Addresses)+len(filter.OpReturnPrefixes) == 0 {
+		f.all = true
+		return
+	}
+	f.scripts = make(map[string]struct{})
+	for _, script := range filter.Scripts {
+		f.scripts[string(script)] = struct{}{}
+	}
+	for _, addr := range filter.Addresses {
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return f, err
+		}
+		f.scripts[string(script)] = struct{}{}
+	}
+	f.opReturnPrefixes = filter.OpReturnPrefixes
+	return
+}
+
+func (f *txFilter) match(tx *wire.MsgTx) bool {
+	if f.all {
+		return true
+	}
+	for _, out := range tx.TxOut {
+		if _, ok := f.scripts[string(out.PkScript)]; ok {
+			return true
+		}
+		if len(f.opReturnPrefixes) > 0 && len(out.PkScript) > 0 && out.PkScript[0] == txscript.OP_RETURN {
+			data, err := txscript.PushedData(out.PkScript)
+			if err != nil {
+				continue
+			}
+			payload := bytes.Join(data, nil)
+			for _, prefix := range f.opReturnPrefixes {
+				if bytes.HasPrefix(payload, prefix) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// matchTxs returns the channels of the decoded tx subscriptions that tx matches.
+// Must be called with subs locked.
+func (subs *subscriptions) matchTxs(tx *wire.MsgTx) (chs []chan TxMsg) {
+	for i := range subs.txs {
+		if subs.txs[i].filter.match(tx) {
+			chs = append(chs, subs.txs[i].ch)
+		}
+	}
+	return
+}
+
+// SubscribeTxs subscribes to the ZMQ "rawtx" messages as TxMsg items pushed onto the channel,
+// decoded once for all the subscriptions. Only the transactions matching filter are pushed,
+// filter can be nil to get all of them.
+//
+// Call cancel to cancel the subscription and let the client release the resources. The channel is closed
+// when the subscription is canceled or when the client is closed.
+func (bc *BitcoindClient) SubscribeTxs(filter *TxFilter, opts ...SubOption) (subCh chan TxMsg, cancel func(), err error) {
+	if bc.zsubs["rawtx"] == nil {
+		err = ErrSubscribeDisabled
+		return
+	}
+	o, err := newSubOptions(opts)
+	if err != nil {
+		return
+	}
+	f, err := newTxFilter(filter)
+	if err != nil {
+		return
+	}
+	bc.subs.Lock()
+	select {
+	case <-bc.subs.exited:
+		err = ErrSubscribeExited
+		bc.subs.Unlock()
+		return
+	default:
+	}
+	if len(bc.subs.rawTx) == 0 && len(bc.subs.txs) == 0 {
+		_, err = bc.subs.zfront.SendMessage("subscribe", "rawtx")
+		if err != nil {
+			bc.subs.Unlock()
+			return
+		}
+	}
+	subCh = make(chan TxMsg, bc.Cfg.SubChannelBufferSize)
+	registerSub(&bc.subs, subCh, o)
+	bc.subs.txs = append(bc.subs.txs, txSubscription{ch: subCh, filter: f})
+	bc.subs.Unlock()
+	cancel = func() { bc.unsubscribeTxs(subCh) }
+	return
+}
+
+func (bc *BitcoindClient) unsubscribeTxs(subCh chan TxMsg) (err error) {
+	bc.subs.Lock()
+	select {
+	case <-bc.subs.exited:
+		err = ErrSubscribeExited
+		bc.subs.Unlock()
+		return
+	default:
+	}
+	for i, sub := range bc.subs.txs {
+		if sub.ch == subCh {
+			bc.subs.txs = append(bc.subs.txs[:i], bc.subs.txs[i+1:]...)
+			if len(bc.subs.rawTx) == 0 && len(bc.subs.txs) == 0 {
+				_, err = bc.subs.zfront.SendMessage("unsubscribe", "rawtx")
+			}
+			break
+		}
+	}
+	closeSub(&bc.subs, subCh)
+	bc.subs.Unlock()
+	return
+}
+
+// SubscribeBlocks subscribes to the ZMQ "rawblock" messages as BlockMsg items pushed onto the channel,
+// decoded once for all the subscriptions.
+//
+// Call cancel to cancel the subscription and let the client release the resources. The channel is closed
+// when the subscription is canceled or when the client is closed.
+func (bc *BitcoindClient) SubscribeBlocks(opts ...SubOption) (subCh chan BlockMsg, cancel func(), err error) {
+	if bc.zsubs["rawblock"] == nil {
+		err = ErrSubscribeDisabled
+		return
+	}
+	o, err := newSubOptions(opts)
+	if err != nil {
+		return
+	}
+	bc.subs.Lock()
+	select {
+	case <-bc.subs.exited:
+		err = ErrSubscribeExited
+		bc.subs.Unlock()
+		return
+	default:
+	}
+	if len(bc.subs.rawBlock) == 0 && len(bc.subs.blocks) == 0 {
+		_, err = bc.subs.zfront.SendMessage("subscribe", "rawblock")
+		if err != nil {
+			bc.subs.Unlock()
+			return
+		}
+	}
+	subCh = make(chan BlockMsg, bc.Cfg.SubChannelBufferSize)
+	registerSub(&bc.subs, subCh, o)
+	bc.subs.blocks = append(bc.subs.blocks, subCh)
+	bc.subs.Unlock()
+	cancel = func() { bc.unsubscribeBlocks(subCh) }
+	return
+}
+
+func (bc *BitcoindClient) unsubscribeBlocks(subCh chan BlockMsg) (err error) {
+	bc.subs.Lock()
+	select {
+	case <-bc.subs.exited:
+		err = ErrSubscribeExited
+		bc.subs.Unlock()
+		return
+	default:
+	}
+	for i, ch := range bc.subs.blocks {
+		if ch == subCh {
+			bc.subs.blocks = append(bc.subs.blocks[:i], bc.subs.blocks[i+1:]...)
+			if len(bc.subs.rawBlock) == 0 && len(bc.subs.blocks) == 0 {
+				_, err = bc.subs.zfront.SendMessage("unsubscribe", "rawblock")
+			}
+			break
+		}
+	}
+	closeSub(&bc.subs, subCh)
+	bc.subs.Unlock()
+	return
+}
+
+func decodeTx(rawMsg RawMsg) (txMsg TxMsg, err error) {
+	txMsg.Tx = new(wire.MsgTx)
+	if err = txMsg.Tx.Deserialize(bytes.NewReader(rawMsg.Serialized)); err != nil {
+		return
+	}
+	txMsg.Hash = txMsg.Tx.TxHash()
+	txMsg.Seq = rawMsg.Seq
+	txMsg.Replayed = rawMsg.Replayed
+	return
+}
+
+func decodeBlock(rawMsg RawMsg) (blockMsg BlockMsg, err error) {
+	blockMsg.Block = new(wire.MsgBlock)
+	if err = blockMsg.Block.Deserialize(bytes.NewReader(rawMsg.Serialized)); err != nil {
+		return
+	}
+	blockMsg.Hash = blockMsg.Block.BlockHash()
+	blockMsg.Seq = rawMsg.Seq
+	blockMsg.Replayed = rawMsg.Replayed
+	return
+}
+
+// pushRawTx pushes rawMsg onto the "rawtx" subscription channels, and decoded onto the
+// matching SubscribeTxs channels. Must be called with subs locked.
+func (subs *subscriptions) pushRawTx(rawMsg RawMsg) {
+	push(subs, subs.rawTx, rawMsg)
+	if len(subs.txs) == 0 {
+		return
+	}
+	txMsg, err := decodeTx(rawMsg)
+	if err != nil {
+		// This is a fault. Drop the message.
+		return
+	}
+	push(subs, subs.matchTxs(txMsg.Tx), txMsg)
+}
+
+// pushRawBlock pushes rawMsg onto the "rawblock" subscription channels, and decoded onto
+// the SubscribeBlocks channels. Must be called with subs locked.
+func (subs *subscriptions) pushRawBlock(rawMsg RawMsg) {
+	push(subs, subs.rawBlock, rawMsg)
+	if len(subs.blocks) == 0 {
+		return
+	}
+	blockMsg, err := decodeBlock(rawMsg)
+	if err != nil {
+		// This is a fault. Drop the message.
+		return
+	}
+	push(subs, subs.blocks, blockMsg)
+}
+
+// pushReplayedRawTx is pushRawTx for replayed messages, see pushReplayed.
+func (bc *BitcoindClient) pushReplayedRawTx(ctx context.Context, rawMsg RawMsg) error {
+	if err := pushReplayed(ctx, &bc.subs, func() []chan RawMsg { return bc.subs.rawTx }, rawMsg); err != nil {
+		return err
+	}
+	txMsg, err := decodeTx(rawMsg)
+	if err != nil {
+		return nil
+	}
+	return pushReplayed(ctx, &bc.subs, func() []chan TxMsg { return bc.subs.matchTxs(txMsg.Tx) }, txMsg)
+}
+
+// pushReplayedRawBlock is pushRawBlock for replayed messages, see pushReplayed.
+func (bc *BitcoindClient) pushReplayedRawBlock(ctx context.Context, rawMsg RawMsg) error {
+	if err := pushReplayed(ctx, &bc.subs, func() []chan RawMsg { return bc.subs.rawBlock }, rawMsg); err != nil {
+		return err
+	}
+	blockMsg, err := decodeBlock(rawMsg)
+	if err != nil {
+		return nil
+	}
+	return pushReplayed(ctx, &bc.subs, func() []chan BlockMsg { return bc.subs.blocks }, blockMsg)
+}
@@ -0,0 +1,77 @@
+package zmq
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushRawTx(t *testing.T) {
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(bytes.Repeat([]byte{1}, 20), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	payToAddr, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	opReturn, err := txscript.NullDataScript([]byte("tag:payload"))
+	require.NoError(t, err)
+	other := []byte{txscript.OP_TRUE}
+
+	serialize := func(scripts ...[]byte) RawMsg {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+		for _, script := range scripts {
+			tx.AddTxOut(wire.NewTxOut(1000, script))
+		}
+		var buf bytes.Buffer
+		require.NoError(t, tx.Serialize(&buf))
+		return RawMsg{Serialized: buf.Bytes(), Seq: 7}
+	}
+
+	var subs subscriptions
+	newSub := func(filter *TxFilter) chan TxMsg {
+		f, err := newTxFilter(filter)
+		require.NoError(t, err)
+		ch := make(chan TxMsg, 10)
+		subs.txs = append(subs.txs, txSubscription{ch: ch, filter: f})
+		return ch
+	}
+	all := newSub(nil)
+	byAddr := newSub(&TxFilter{Addresses: []btcutil.Address{addr}})
+	byScript := newSub(&TxFilter{Scripts: [][]byte{other}})
+	byTag := newSub(&TxFilter{OpReturnPrefixes: [][]byte{[]byte("tag:")}})
+
+	subs.pushRawTx(serialize(payToAddr))
+	subs.pushRawTx(serialize(other, opReturn))
+	subs.pushRawTx(RawMsg{Serialized: []byte{1, 2, 3}})
+
+	assert.Len(t, all, 2)
+	assert.Len(t, byAddr, 1)
+	assert.Len(t, byScript, 1)
+	assert.Len(t, byTag, 1)
+
+	txMsg := <-byTag
+	assert.Equal(t, txMsg.Tx.TxHash(), txMsg.Hash)
+	assert.Equal(t, uint32(7), txMsg.Seq)
+	assert.Same(t, txMsg.Tx, (<-byScript).Tx)
+}
+
+func TestPushRawBlock(t *testing.T) {
+	block := chaincfg.MainNetParams.GenesisBlock
+	var buf bytes.Buffer
+	require.NoError(t, block.Serialize(&buf))
+
+	var subs subscriptions
+	ch := make(chan BlockMsg, 1)
+	subs.blocks = append(subs.blocks, ch)
+	subs.pushRawBlock(RawMsg{Serialized: buf.Bytes(), Seq: 3})
+
+	blockMsg := <-ch
+	assert.Equal(t, *chaincfg.MainNetParams.GenesisHash, blockMsg.Hash)
+	assert.Len(t, blockMsg.Block.Transactions, 1)
+	assert.Equal(t, uint32(3), blockMsg.Seq)
+}
@@ -0,0 +1,162 @@
+package zmq
+
+import (
+	"fmt"
+	"time"
+
+	zmq "github.com/pebbe/zmq4"
+)
+
+// zmqTopics are the topics published by bitcoind.
+var zmqTopics = []string{"hashtx", "hashblock", "rawtx", "rawblock", "sequence"}
+
+// ConnState is an enum describing the state of a ZMQ connection.
+type ConnState int
+
+const (
+	ZmqDisconnected ConnState = iota // Not connected, ZMQ retries in the background
+	ZmqConnected                     // Connected
+	ZmqStale                         // Silent for longer than ZmqSilenceTimeout, reconnecting, not ready until connected again
+)
+
+func (cs ConnState) String() string {
+	return [...]string{"Disconnected", "Connected", "Stale"}[cs]
+}
+
+// StatusMsg is a subscription event reporting a change of the state of the connection to a zmqpub address.
+type StatusMsg struct {
+	Address string
+	State   ConnState
+	Time    time.Time
+}
+
+// endpoint is the connection to a zmqpub address of bitcoind, shared by the topics published on it.
+type endpoint struct {
+	address string
+	zsub    *zmq.Socket
+	// zmon receives the connection events of zsub.
+	zmon *zmq.Socket
+
+	// Owned by the zmqHandler goroutine.
+	topics        int // number of subscribed topics
+	latestEvent   time.Time
+	backoff       time.Duration
+	nextReconnect time.Time
+
+	// connected is guarded by subs.
+	connected bool
+}
+
+func newEndpoint(zctx *zmq.Context, cfg *Config, address string, id int) (*endpoint, error) {
+	zsub, err := zctx.NewSocket(zmq.SUB)
+	if err != nil {
+		return nil, err
+	}
+	if err := zsub.SetReconnectIvl(cfg.ZmqReconnectInterval); err != nil {
+		return nil, err
+	}
+	if err := zsub.SetReconnectIvlMax(cfg.ZmqReconnectIntervalMax); err != nil {
+		return nil, err
+	}
+	if cfg.ZmqHeartbeatInterval > 0 {
+		if err := zsub.SetHeartbeatIvl(cfg.ZmqHeartbeatInterval); err != nil {
+			return nil, err
+		}
+	}
+	monitor := fmt.Sprintf("inproc://monitor-%d", id)
+	if err := zsub.Monitor(monitor, zmq.EVENT_CONNECTED|zmq.EVENT_DISCONNECTED); err != nil {
+		return nil, err
+	}
+	zmon, err := zctx.NewSocket(zmq.PAIR)
+	if err != nil {
+		return nil, err
+	}
+	if err := zmon.Connect(monitor); err != nil {
+		return nil, err
+	}
+	if err := zsub.Connect(address); err != nil {
+		return nil, err
+	}
+	return &endpoint{address: address, zsub: zsub, zmon: zmon}, nil
+}
+
+// nextBackoff returns the delay before the next reconnection, doubling from
+// ZmqReconnectInterval up to ZmqReconnectIntervalMax.
+func (ep *endpoint) nextBackoff(cfg *Config) time.Duration {
+	if ep.backoff == 0 {
+		return cfg.ZmqReconnectInterval
+	}
+	return min(2*ep.backoff, cfg.ZmqReconnectIntervalMax)
+}
+
+// checkSilence reconnects the endpoints with subscribed topics that have been
+// silent for longer than ZmqSilenceTimeout.
+func (bc *BitcoindClient) checkSilence(now time.Time) error {
+	for _, ep := range bc.endpoints {
+		if ep.topics == 0 || now.Sub(ep.latestEvent) < bc.Cfg.ZmqSilenceTimeout || now.Before(ep.nextReconnect) {
+			continue
+		}
+		bc.setState(ep, ZmqStale, now)
+		// The subscriptions belong to the socket, they are sent again on the new connection.
+		if err := ep.zsub.Disconnect(ep.address); err != nil {
+			return err
+		}
+		if err := ep.zsub.Connect(ep.address); err != nil {
+			return err
+		}
+		ep.backoff = ep.nextBackoff(&bc.Cfg)
+		ep.nextReconnect = now.Add(ep.backoff)
+		ep.latestEvent = now
+	}
+	return nil
+}
+
+// SubscribeStatus subscribes to the state changes of the ZMQ connections as StatusMsg items pushed
+// onto the channel. It doesn't subscribe to a ZMQ topic of its own.
+//
+// Call cancel to cancel the subscription and let the client release the resources. The channel is closed
+// when the subscription is canceled or when the client is closed.
+func (bc *BitcoindClient) SubscribeStatus(opts ...SubOption) (subCh chan StatusMsg, cancel func(), err error) {
+	if bc.zctx == nil {
+		err = ErrSubscribeDisabled
+		return
+	}
+	o, err := newSubOptions(opts)
+	if err != nil {
+		return
+	}
+	bc.subs.Lock()
+	select {
+	case <-bc.subs.exited:
+		err = ErrSubscribeExited
+		bc.subs.Unlock()
+		return
+	default:
+	}
+	subCh = make(chan StatusMsg, bc.Cfg.SubChannelBufferSize)
+	registerSub(&bc.subs, subCh, o)
+	bc.subs.status = append(bc.subs.status, subCh)
+	bc.subs.Unlock()
+	cancel = func() { bc.unsubscribeStatus(subCh) }
+	return
+}
+
+func (bc *BitcoindClient) unsubscribeStatus(subCh chan StatusMsg) (err error) {
+	bc.subs.Lock()
+	select {
+	case <-bc.subs.exited:
+		err = ErrSubscribeExited
+		bc.subs.Unlock()
+		return
+	default:
+	}
+	for i, ch := range bc.subs.status {
+		if ch == subCh {
+			bc.subs.status = append(bc.subs.status[:i], bc.subs.status[i+1:]...)
+			break
+		}
+	}
+	closeSub(&bc.subs, subCh)
+	bc.subs.Unlock()
+	return
+}
@@ -0,0 +1,142 @@
+package zmq
+
+import (
+	"testing"
+	"time"
+
+	zmq "github.com/pebbe/zmq4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextBackoff(t *testing.T) {
+	cfg := Config{ZmqReconnectInterval: time.Second, ZmqReconnectIntervalMax: 5 * time.Second}
+	var ep endpoint
+	var backoffs []time.Duration
+	for i := 0; i < 5; i++ {
+		ep.backoff = ep.nextBackoff(&cfg)
+		backoffs = append(backoffs, ep.backoff)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, backoffs)
+}
+
+func TestNewTopicAddresses(t *testing.T) {
+	_, err := New(Config{ZmqTopicAddresses: map[string]string{"hashblocks": "tcp://127.0.0.1:28332"}})
+	assert.EqualError(t, err, `Unknown ZMQ topic "hashblocks".`)
+
+	bc, err := New(Config{})
+	assert.NoError(t, err)
+	defer bc.Close()
+	_, _, err = bc.SubscribeRawBlock()
+	assert.Equal(t, ErrSubscribeDisabled, err)
+	_, _, err = bc.SubscribeStatus()
+	assert.Equal(t, ErrSubscribeDisabled, err)
+}
+
+func TestCheckSilence(t *testing.T) {
+	cfg := Config{ZmqSilenceTimeout: time.Second, ZmqReconnectInterval: time.Minute, ZmqReconnectIntervalMax: time.Hour}
+	zctx, err := zmq.NewContext()
+	require.NoError(t, err)
+	defer zctx.Term()
+	ep, err := newEndpoint(zctx, &cfg, "tcp://127.0.0.1:28399", 0)
+	require.NoError(t, err)
+	defer ep.zmon.Close()
+	defer ep.zsub.Close()
+	bc := &BitcoindClient{Cfg: cfg, endpoints: []*endpoint{ep}}
+	bc.subs.connChanged = make(chan struct{})
+	status := make(chan StatusMsg, 10)
+	bc.subs.status = []chan StatusMsg{status}
+
+	bc.setState(ep, ZmqConnected, time.Now())
+	bc.setState(ep, ZmqConnected, time.Now())
+	assert.True(t, bc.subs.connected)
+	require.Len(t, status, 1)
+	assert.Equal(t, ZmqConnected, (<-status).State)
+
+	// Without a subscribed topic the endpoint is expected to be silent.
+	start := time.Now()
+	require.NoError(t, bc.checkSilence(start.Add(time.Hour)))
+	assert.Empty(t, status)
+
+	ep.topics = 1
+	ep.latestEvent = start
+	require.NoError(t, bc.checkSilence(start.Add(time.Second/2)))
+	assert.Empty(t, status)
+
+	// Silent for ZmqSilenceTimeout, the endpoint is stale and reconnects.
+	now := start.Add(time.Second)
+	require.NoError(t, bc.checkSilence(now))
+	require.Len(t, status, 1)
+	assert.Equal(t, StatusMsg{Address: ep.address, State: ZmqStale, Time: now}, <-status)
+	assert.Equal(t, time.Minute, ep.backoff)
+	assert.Equal(t, now, ep.latestEvent)
+	// Ready mustn't report a stale endpoint as healthy.
+	assert.False(t, ep.connected)
+	assert.False(t, bc.subs.connected)
+
+	// Still silent, the next reconnection waits for the backoff, then doubles it.
+	require.NoError(t, bc.checkSilence(now.Add(30*time.Second)))
+	assert.Empty(t, status)
+	now = now.Add(time.Minute)
+	require.NoError(t, bc.checkSilence(now))
+	require.Len(t, status, 1)
+	assert.Equal(t, ZmqStale, (<-status).State)
+	assert.Equal(t, 2*time.Minute, ep.backoff)
+	assert.False(t, bc.subs.connected)
+
+	// The monitor reports the new connection.
+	bc.setState(ep, ZmqConnected, now)
+	assert.True(t, bc.subs.connected)
+	require.Len(t, status, 1)
+	assert.Equal(t, ZmqConnected, (<-status).State)
+
+	bc.setState(ep, ZmqDisconnected, now)
+	assert.False(t, bc.subs.connected)
+	require.Len(t, status, 1)
+	assert.Equal(t, ZmqDisconnected, (<-status).State)
+}
+
+func TestDispatchSequence(t *testing.T) {
+	bc := &BitcoindClient{}
+	sequence := make(chan SequenceMsg, 10)
+	bc.subs.sequence = []chan SequenceMsg{sequence}
+	hash := make([]byte, 32)
+	hash[0] = 1
+
+	// Truncated bodies are dropped.
+	bc.dispatch("sequence", hash, 1)
+	bc.dispatch("sequence", append(hash, 'A'), 2)
+	bc.dispatch("sequence", append(hash, 'R', 1, 2, 3, 4, 5, 6, 7), 3)
+	assert.Empty(t, sequence)
+
+	bc.dispatch("sequence", append(hash, 'C'), 4)
+	bc.dispatch("sequence", append(hash, 'A', 5, 0, 0, 0, 0, 0, 0, 0), 5)
+	require.Len(t, sequence, 2)
+	assert.Equal(t, SequenceMsg{Hash: [32]byte{1}, Event: BlockConnected, Seq: 4}, <-sequence)
+	assert.Equal(t, SequenceMsg{Hash: [32]byte{1}, Event: TransactionAdded, MempoolSeq: 5, Seq: 5}, <-sequence)
+}
+
+func TestSetStateBlockingStatus(t *testing.T) {
+	ep := &endpoint{address: "tcp://127.0.0.1:28332"}
+	bc := &BitcoindClient{endpoints: []*endpoint{ep}}
+	bc.subs.connChanged = make(chan struct{})
+	o, err := newSubOptions([]SubOption{WithBlock(0)})
+	require.NoError(t, err)
+	status := make(chan StatusMsg)
+	registerSub(&bc.subs, status, o)
+	bc.subs.status = []chan StatusMsg{status}
+
+	done := make(chan struct{})
+	go func() {
+		bc.setState(ep, ZmqConnected, time.Now())
+		close(done)
+	}()
+	// The state is visible while the push waits for the subscriber.
+	require.Eventually(t, func() bool {
+		bc.subs.RLock()
+		defer bc.subs.RUnlock()
+		return bc.subs.connected
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, ZmqConnected, (<-status).State)
+	<-done
+}
@@ -0,0 +1,249 @@
+package zmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultFollowerPollInterval = 30 * time.Second
+	DefaultFollowerMaxHeaders   = 100
+)
+
+// FollowerConfig configures a ChainFollower.
+type FollowerConfig struct {
+	// Tip is the hash of the last block processed before a restart, as persisted by the caller
+	// from the Connected and Disconnected callbacks. The follower resumes from it, disconnecting
+	// it first if it was reorged out meanwhile.
+	// If not set then the follower starts at the current tip of bitcoind, without a callback for it.
+	Tip string
+
+	// Connected is called for each block connected to the followed chain, in ascending height order.
+	Connected func(block BlockHeader) error
+
+	// Disconnected is called for each block disconnected from the followed chain by a reorg,
+	// in descending height order, before the blocks of the new chain are connected.
+	Disconnected func(block BlockHeader) error
+
+	// PollInterval is how long the follower waits for a block event from ZMQ before it polls over RPC.
+	// If not set (or set to zero) then defaults to DefaultFollowerPollInterval.
+	PollInterval time.Duration
+
+	// MaxHeaders is the number of recent headers kept in memory to handle reorgs without RPC calls.
+	// Deeper reorgs fetch the missing headers over RPC.
+	// If not set (or set to zero) then defaults to DefaultFollowerMaxHeaders.
+	MaxHeaders int
+}
+
+// ChainFollower follows the most-work chain of bitcoind, calling back for
+// every block connected and disconnected. It is driven by the "sequence"
+// messages when the client has ZmqPubAddress set, and by RPC polling
+// otherwise or when ZMQ is silent. The chain itself is always read over RPC,
+// so lost messages can't make it skip a block.
+//
+// Must be created with NewChainFollower and driven by Run.
+type ChainFollower struct {
+	bc  *BitcoindClient
+	cfg FollowerConfig
+
+	mu sync.Mutex
+	// chain holds the recent headers of the followed chain, the tip last.
+	chain []BlockHeader
+}
+
+// NewChainFollower returns a follower of the chain of bitcoind through bc, which must have RpcAddress set.
+func NewChainFollower(bc *BitcoindClient, cfg FollowerConfig) (*ChainFollower, error) {
+	if bc.Cfg.RpcAddress == "" {
+		return nil, ErrRpcDisabled
+	}
+	if cfg.Connected == nil || cfg.Disconnected == nil {
+		return nil, errors.New("Connected and Disconnected callbacks must be set.")
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = DefaultFollowerPollInterval
+	}
+	if cfg.MaxHeaders == 0 {
+		cfg.MaxHeaders = DefaultFollowerMaxHeaders
+	}
+	return &ChainFollower{bc: bc, cfg: cfg}, nil
+}
+
+// Tip returns the header of the last block connected, zero before Run has started.
+func (f *ChainFollower) Tip() BlockHeader {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.chain) == 0 {
+		return BlockHeader{}
+	}
+	return f.chain[len(f.chain)-1]
+}
+
+// Run follows the chain until ctx is done or a callback returns an error, and returns
+// the reason. RPC errors are retried at the next poll. The callbacks are called from
+// the goroutine of Run.
+func (f *ChainFollower) Run(ctx context.Context) error {
+	var sequence chan SequenceMsg
+	var gaps chan GapMsg
+	if f.bc.zsubs["sequence"] != nil {
+		var cancel func()
+		var err error
+		// A dropped block event would only be caught up at the next poll.
+		if sequence, cancel, err = f.bc.SubscribeSequence(WithSpill("", 0)); err != nil {
+			return err
+		}
+		defer cancel()
+		if gaps, cancel, err = f.bc.SubscribeGaps(); err != nil {
+			return err
+		}
+		defer cancel()
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-sequence:
+			if !ok {
+				return ErrSubscribeExited
+			}
+			// Only block events need a sync, and postpone the poll.
+			if msg.Event != BlockConnected && msg.Event != BlockDisconnected {
+				continue
+			}
+			timer.Reset(f.cfg.PollInterval)
+		case gap, ok := <-gaps:
+			if !ok {
+				return ErrSubscribeExited
+			}
+			if gap.Topic != "sequence" {
+				continue
+			}
+		case <-timer.C:
+			timer.Reset(f.cfg.PollInterval)
+		}
+		if err := f.sync(ctx); err != nil {
+			var cbErr callbackError
+			if errors.As(err, &cbErr) {
+				return cbErr.err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// callbackError wraps the errors of the callbacks, to tell them from RPC errors.
+type callbackError struct {
+	err error
+}
+
+func (e callbackError) Error() string {
+	return e.err.Error()
+}
+
+// sync brings the followed chain up to the most-work chain of bitcoind.
+func (f *ChainFollower) sync(ctx context.Context) error {
+	if err := f.init(ctx); err != nil {
+		return err
+	}
+	for {
+		// Disconnect the blocks reorged out, down to the fork point.
+		for {
+			tip := f.Tip()
+			header, err := f.bc.GetBlockHeader(ctx, tip.Hash)
+			if err != nil {
+				return err
+			}
+			if header.Confirmations != -1 {
+				break
+			}
+			if err := f.disconnect(ctx); err != nil {
+				return err
+			}
+		}
+
+		count, err := f.bc.GetBlockCount(ctx)
+		if err != nil {
+			return err
+		}
+		reorged := false
+		for height := f.Tip().Height + 1; height <= count; height++ {
+			hash, err := f.bc.GetBlockHash(ctx, height)
+			if err != nil {
+				return err
+			}
+			header, err := f.bc.GetBlockHeader(ctx, hash)
+			if err != nil {
+				return err
+			}
+			if header.PreviousBlockHash != f.Tip().Hash {
+				// A reorg happened during the sync.
+				reorged = true
+				break
+			}
+			if err := f.cfg.Connected(header); err != nil {
+				return callbackError{err}
+			}
+			f.mu.Lock()
+			f.chain = append(f.chain, header)
+			if len(f.chain) > f.cfg.MaxHeaders {
+				f.chain = append(f.chain[:0], f.chain[len(f.chain)-f.cfg.MaxHeaders:]...)
+			}
+			f.mu.Unlock()
+		}
+		if !reorged {
+			return nil
+		}
+	}
+}
+
+// init loads the starting tip of the followed chain.
+func (f *ChainFollower) init(ctx context.Context) (err error) {
+	if len(f.chain) > 0 {
+		return nil
+	}
+	hash := f.cfg.Tip
+	if hash == "" {
+		if hash, err = f.bc.GetBestBlockHash(ctx); err != nil {
+			return err
+		}
+	}
+	header, err := f.bc.GetBlockHeader(ctx, hash)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.chain = []BlockHeader{header}
+	f.mu.Unlock()
+	return nil
+}
+
+// disconnect disconnects the tip of the followed chain, fetching its parent
+// over RPC if it isn't kept in memory.
+func (f *ChainFollower) disconnect(ctx context.Context) error {
+	tip := f.Tip()
+	if len(f.chain) == 1 {
+		if tip.PreviousBlockHash == "" {
+			return errors.New("Genesis block disconnected.")
+		}
+		parent, err := f.bc.GetBlockHeader(ctx, tip.PreviousBlockHash)
+		if err != nil {
+			return err
+		}
+		f.mu.Lock()
+		f.chain = []BlockHeader{parent, tip}
+		f.mu.Unlock()
+	}
+	if err := f.cfg.Disconnected(tip); err != nil {
+		return callbackError{err}
+	}
+	f.mu.Lock()
+	f.chain = f.chain[:len(f.chain)-1]
+	f.mu.Unlock()
+	return nil
+}
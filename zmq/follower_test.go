@@ -0,0 +1,125 @@
+package zmq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testChain is the chain of a fake bitcoind, blocks are named "<branch><height>".
+type testChain struct {
+	sync.Mutex
+	main    []string
+	headers map[string]BlockHeader
+}
+
+func newTestChain(length int) *testChain {
+	c := &testChain{headers: make(map[string]BlockHeader)}
+	c.extend(0, "a", length)
+	return c
+}
+
+// extend replaces the main chain above height with n blocks of branch.
+func (c *testChain) extend(height int, branch string, n int) {
+	c.Lock()
+	defer c.Unlock()
+	c.main = c.main[:height]
+	for i := 0; i < n; i++ {
+		h := int64(len(c.main))
+		header := BlockHeader{Hash: fmt.Sprintf("%s%d", branch, h), Height: h}
+		if h > 0 {
+			header.PreviousBlockHash = c.main[h-1]
+		}
+		c.headers[header.Hash] = header
+		c.main = append(c.main, header.Hash)
+	}
+}
+
+func (c *testChain) reply(w http.ResponseWriter, req rpcRequest) {
+	c.Lock()
+	defer c.Unlock()
+	params, _ := req.Params.([]interface{})
+	var result interface{}
+	switch req.Method {
+	case "getbestblockhash":
+		result = c.main[len(c.main)-1]
+	case "getblockcount":
+		result = len(c.main) - 1
+	case "getblockhash":
+		result = c.main[int(params[0].(float64))]
+	case "getblockheader":
+		header := c.headers[params[0].(string)]
+		header.Confirmations = -1
+		if c.main[header.Height] == header.Hash {
+			header.Confirmations = int64(len(c.main)) - header.Height
+		}
+		result = header
+	}
+	data, _ := json.Marshal(result)
+	fmt.Fprintf(w, `{"result":%s,"error":null,"id":%d}`, data, req.ID)
+}
+
+func TestChainFollower(t *testing.T) {
+	chain := newTestChain(5)
+	bc, _ := newRpcClient(t, chain.reply)
+	var events []string
+	f, err := NewChainFollower(bc, FollowerConfig{
+		Tip:          "a2",
+		Connected:    func(block BlockHeader) error { events = append(events, "+"+block.Hash); return nil },
+		Disconnected: func(block BlockHeader) error { events = append(events, "-"+block.Hash); return nil },
+		MaxHeaders:   2,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, f.sync(ctx))
+	assert.Equal(t, []string{"+a3", "+a4"}, events)
+
+	// The reorg is deeper than the headers kept.
+	events = nil
+	chain.extend(3, "b", 3)
+	require.NoError(t, f.sync(ctx))
+	assert.Equal(t, []string{"-a4", "-a3", "+b3", "+b4", "+b5"}, events)
+	assert.Equal(t, "b5", f.Tip().Hash)
+
+	// Resuming from a tip that was reorged out.
+	events = nil
+	f, err = NewChainFollower(bc, FollowerConfig{
+		Tip:          "a4",
+		Connected:    func(block BlockHeader) error { events = append(events, "+"+block.Hash); return nil },
+		Disconnected: func(block BlockHeader) error { events = append(events, "-"+block.Hash); return nil },
+	})
+	require.NoError(t, err)
+	require.NoError(t, f.sync(ctx))
+	assert.Equal(t, []string{"-a4", "-a3", "+b3", "+b4", "+b5"}, events)
+}
+
+func TestChainFollowerRun(t *testing.T) {
+	chain := newTestChain(3)
+	bc, _ := newRpcClient(t, chain.reply)
+	connected := make(chan string, 10)
+	f, err := NewChainFollower(bc, FollowerConfig{
+		Tip:          "a2",
+		Connected:    func(block BlockHeader) error { connected <- block.Hash; return nil },
+		Disconnected: func(block BlockHeader) error { return fmt.Errorf("disconnected %s", block.Hash) },
+		PollInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() { done <- f.Run(context.Background()) }()
+	chain.extend(3, "a", 1)
+	assert.Equal(t, "a3", <-connected)
+
+	// Callback errors stop Run.
+	chain.extend(3, "b", 2)
+	assert.EqualError(t, <-done, "disconnected a3")
+	assert.Equal(t, "a3", f.Tip().Hash)
+}
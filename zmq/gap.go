@@ -0,0 +1,384 @@
+package zmq
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// CatchUpTimeout bounds the RPC calls made to back-fill a single gap.
+	CatchUpTimeout = 30 * time.Second
+	// MaxCatchUpBlocks is the largest number of blocks back-filled for a single gap.
+	MaxCatchUpBlocks = 1000
+
+	// maxSeenTxs bounds the set of txids remembered for the "hashtx" and "rawtx" catch-up.
+	maxSeenTxs = 1 << 20
+	// replayRetryInterval is how often full subscription channels are retried while replaying.
+	replayRetryInterval = 10 * time.Millisecond
+)
+
+// GapMsg reports that ZMQ messages of a topic were lost before reaching the client,
+// detected by a jump in the sequence numbers of the topic. Messages can be lost by
+// bitcoind (high water mark reached) or in transit.
+//
+// Gaps are only detected between messages received by the client. Messages dropped
+// because a subscription channel was full are not reported, they show as a jump in
+// the Seq of the messages received from the channel.
+type GapMsg struct {
+	Topic string // "hashtx", "hashblock", "rawtx", "rawblock" or "sequence"
+	From  uint32 // sequence number of the first missed message
+	To    uint32 // sequence number of the last missed message
+
+	// Replayed is the number of messages back-filled over RPC, when Config.CatchUp is set.
+	// The replayed messages are pushed before the GapMsg and the message that revealed the gap.
+	Replayed int
+	// Err is set when the catch-up failed, the messages replayed until then are still delivered.
+	Err error
+}
+
+// Missed returns the number of messages missed.
+func (g GapMsg) Missed() uint32 {
+	return g.To - g.From + 1
+}
+
+// topicState is the gap detection state of a topic, owned by the zmqHandler goroutine,
+// or by the topicWorker of the topic with Config.CatchUp.
+type topicState struct {
+	seq uint32
+	// lastBlock is the hash of the last block delivered, "hashblock" and "rawblock" only.
+	lastBlock string
+	// seenTxs holds the txids in the mempool when the topic was first seen, or at the last
+	// catch-up, and the txids delivered since, "hashtx" and "rawtx" with Config.CatchUp only.
+	seenTxs map[string]struct{}
+}
+
+// topicMsg is a message of a topic queued for its topicWorker. A reset message starts
+// the gap detection over, as after unsubscribing.
+type topicMsg struct {
+	body  []byte
+	seq   uint32
+	reset bool
+}
+
+// topicWorker checks the gaps and delivers the messages of a topic with Config.CatchUp,
+// so that the catch-up RPC calls don't hold up the zmqHandler goroutine. The messages
+// received during a catch-up wait in the queue, they are delivered after the replayed ones.
+type topicWorker struct {
+	queue   *spillQueue[topicMsg]
+	ch      chan topicMsg
+	dropped uint64
+	done    chan struct{}
+}
+
+func (bc *BitcoindClient) newTopicWorker(topic string) *topicWorker {
+	w := &topicWorker{ch: make(chan topicMsg), done: make(chan struct{})}
+	w.queue = newSpillQueue(w.ch, "", 0, &w.dropped)
+	go bc.runTopicWorker(topic, w)
+	return w
+}
+
+func (bc *BitcoindClient) runTopicWorker(topic string, w *topicWorker) {
+	defer close(w.done)
+	topics := make(map[string]*topicState)
+	for msg := range w.ch {
+		if msg.reset {
+			delete(topics, topic)
+			continue
+		}
+		bc.checkGap(topics, topic, msg.seq, msg.body)
+		bc.dispatch(topic, msg.body, msg.seq)
+	}
+}
+
+// stop drops the queued messages and waits for the message being delivered.
+func (w *topicWorker) stop() {
+	w.queue.stop()
+	close(w.ch)
+	<-w.done
+}
+
+// SubscribeGaps subscribes to the gaps detected in the messages of the other subscriptions,
+// as GapMsg items pushed onto the channel. It doesn't subscribe to a ZMQ topic of its own.
+//
+// Call cancel to cancel the subscription and let the client release the resources. The channel is closed
+// when the subscription is canceled or when the client is closed.
+func (bc *BitcoindClient) SubscribeGaps(opts ...SubOption) (subCh chan GapMsg, cancel func(), err error) {
+	if bc.zctx == nil {
+		err = ErrSubscribeDisabled
+		return
+	}
+	o, err := newSubOptions(opts)
+	if err != nil {
+		return
+	}
+	bc.subs.Lock()
+	select {
+	case <-bc.subs.exited:
+		err = ErrSubscribeExited
+		bc.subs.Unlock()
+		return
+	default:
+	}
+	subCh = make(chan GapMsg, bc.Cfg.SubChannelBufferSize)
+	registerSub(&bc.subs, subCh, o)
+	bc.subs.gaps = append(bc.subs.gaps, subCh)
+	bc.subs.Unlock()
+	cancel = func() { bc.unsubscribeGaps(subCh) }
+	return
+}
+
+func (bc *BitcoindClient) unsubscribeGaps(subCh chan GapMsg) (err error) {
+	bc.subs.Lock()
+	select {
+	case <-bc.subs.exited:
+		err = ErrSubscribeExited
+		bc.subs.Unlock()
+		return
+	default:
+	}
+	for i, ch := range bc.subs.gaps {
+		if ch == subCh {
+			bc.subs.gaps = append(bc.subs.gaps[:i], bc.subs.gaps[i+1:]...)
+			break
+		}
+	}
+	closeSub(&bc.subs, subCh)
+	bc.subs.Unlock()
+	return
+}
+
+// checkGap checks the sequence number seq of a message of topic with body msg
+// against the previous message of the topic, reports a gap and back-fills it
+// if Config.CatchUp is set. It must be called before delivering the message.
+func (bc *BitcoindClient) checkGap(topics map[string]*topicState, topic string, seq uint32, msg []byte) {
+	st, ok := topics[topic]
+	if !ok {
+		// First message since subscribing, nothing to compare with.
+		st = &topicState{}
+		topics[topic] = st
+		if bc.Cfg.CatchUp && (topic == "hashtx" || topic == "rawtx") {
+			bc.seedTxs(st)
+		}
+	} else if seq <= st.seq {
+		// bitcoind restarted, its sequence numbers start over. The message with seq 0 may
+		// have been missed while reconnecting.
+	} else if seq != st.seq+1 {
+		gap := GapMsg{Topic: topic, From: st.seq + 1, To: seq - 1}
+		if bc.Cfg.CatchUp {
+			ctx, cancel := context.WithTimeout(bc.ctx, CatchUpTimeout)
+			gap.Replayed, gap.Err = bc.catchUp(ctx, topic, st, msg)
+			cancel()
+		}
+		bc.subs.RLock()
+		push(&bc.subs, bc.subs.gaps, gap)
+		bc.subs.RUnlock()
+	}
+	st.seq = seq
+
+	switch topic {
+	case "hashblock":
+		st.lastBlock = hex.EncodeToString(msg)
+	case "rawblock":
+		if len(msg) >= wire.MaxBlockHeaderPayload {
+			st.lastBlock = chainhash.DoubleHashH(msg[:wire.MaxBlockHeaderPayload]).String()
+		}
+	case "hashtx":
+		if bc.Cfg.CatchUp {
+			st.seeTx(hex.EncodeToString(msg))
+		}
+	case "rawtx":
+		if bc.Cfg.CatchUp {
+			var tx wire.MsgTx
+			if err := tx.Deserialize(bytes.NewReader(msg)); err == nil {
+				st.seeTx(tx.TxHash().String())
+			}
+		}
+	}
+}
+
+// seedTxs marks the transactions in the mempool as seen, so that the first catch-up
+// only replays the transactions that entered it afterwards. If getrawmempool fails
+// the first catch-up replays the whole mempool.
+func (bc *BitcoindClient) seedTxs(st *topicState) {
+	ctx, cancel := context.WithTimeout(bc.ctx, CatchUpTimeout)
+	defer cancel()
+	mempool, err := bc.GetRawMempoolSequence(ctx)
+	if err != nil {
+		return
+	}
+	st.seenTxs = make(map[string]struct{}, len(mempool.Txids))
+	for _, txid := range mempool.Txids {
+		st.seeTx(txid)
+	}
+}
+
+func (st *topicState) seeTx(txid string) {
+	if st.seenTxs == nil || len(st.seenTxs) >= maxSeenTxs {
+		st.seenTxs = make(map[string]struct{})
+	}
+	st.seenTxs[txid] = struct{}{}
+}
+
+// catchUp back-fills the messages of topic missed before msg and returns the
+// number of messages replayed.
+func (bc *BitcoindClient) catchUp(ctx context.Context, topic string, st *topicState, msg []byte) (int, error) {
+	switch topic {
+	case "hashblock", "rawblock":
+		return bc.catchUpBlocks(ctx, topic, st, msg)
+	case "hashtx", "rawtx":
+		return bc.catchUpTxs(ctx, topic, st)
+	}
+	// The "sequence" events can't be recreated, the mempool has to be
+	// resynchronized with GetRawMempoolSequence.
+	return 0, nil
+}
+
+// catchUpBlocks replays the blocks of the most-work chain between the last
+// block delivered and the block of msg, in ascending height order. When the
+// last block delivered was reorged out the replay starts after the fork point.
+func (bc *BitcoindClient) catchUpBlocks(ctx context.Context, topic string, st *topicState, msg []byte) (int, error) {
+	if st.lastBlock == "" {
+		return 0, nil
+	}
+	var hash string
+	if topic == "hashblock" {
+		hash = hex.EncodeToString(msg)
+	} else if len(msg) >= wire.MaxBlockHeaderPayload {
+		hash = chainhash.DoubleHashH(msg[:wire.MaxBlockHeaderPayload]).String()
+	} else {
+		return 0, nil
+	}
+	header, err := bc.GetBlockHeader(ctx, hash)
+	if err != nil {
+		return 0, err
+	}
+	fork, err := bc.GetBlockHeader(ctx, st.lastBlock)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; fork.Confirmations == -1; i++ {
+		if i == MaxCatchUpBlocks || fork.PreviousBlockHash == "" {
+			return 0, fmt.Errorf("Fork point of %s not found.", st.lastBlock)
+		}
+		if fork, err = bc.GetBlockHeader(ctx, fork.PreviousBlockHash); err != nil {
+			return 0, err
+		}
+	}
+	if header.Height-fork.Height-1 > MaxCatchUpBlocks {
+		return 0, fmt.Errorf("Gap of %d blocks is too large to catch up.", header.Height-fork.Height-1)
+	}
+
+	replayed := 0
+	for height := fork.Height + 1; height < header.Height; height++ {
+		hash, err := bc.GetBlockHash(ctx, height)
+		if err != nil {
+			return replayed, err
+		}
+		if topic == "hashblock" {
+			hashMsg := HashMsg{Replayed: true}
+			if _, err := hex.Decode(hashMsg.Hash[:], []byte(hash)); err != nil {
+				return replayed, err
+			}
+			err = pushReplayed(ctx, &bc.subs, func() []chan HashMsg { return bc.subs.hashBlock }, hashMsg)
+		} else {
+			var block []byte
+			if block, err = bc.GetRawBlock(ctx, hash); err != nil {
+				return replayed, err
+			}
+			err = bc.pushReplayedRawBlock(ctx, RawMsg{Serialized: block, Replayed: true})
+		}
+		if err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// catchUpTxs replays the mempool transactions that were not delivered since
+// the topic was first seen or since the last catch-up. Transactions that were confirmed or evicted during the
+// gap are not replayed, and transactions delivered before a reset of the
+// bounded set of seen txids may be replayed twice.
+func (bc *BitcoindClient) catchUpTxs(ctx context.Context, topic string, st *topicState) (int, error) {
+	mempool, err := bc.GetRawMempoolSequence(ctx)
+	if err != nil {
+		return 0, err
+	}
+	replayed := 0
+	for _, txid := range mempool.Txids {
+		if _, ok := st.seenTxs[txid]; ok {
+			continue
+		}
+		if topic == "hashtx" {
+			hashMsg := HashMsg{Replayed: true}
+			if _, err := hex.Decode(hashMsg.Hash[:], []byte(txid)); err != nil {
+				return replayed, err
+			}
+			err = pushReplayed(ctx, &bc.subs, func() []chan HashMsg { return bc.subs.hashTx }, hashMsg)
+		} else {
+			tx, rpcErr := bc.GetRawTransaction(ctx, txid)
+			if rpcErr != nil {
+				if _, ok := rpcErr.(BitcoindError); ok {
+					// Left the mempool meanwhile.
+					continue
+				}
+				return replayed, rpcErr
+			}
+			err = bc.pushReplayedRawTx(ctx, RawMsg{Serialized: tx, Replayed: true})
+		}
+		if err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	st.seenTxs = make(map[string]struct{}, len(mempool.Txids))
+	for _, txid := range mempool.Txids {
+		st.seenTxs[txid] = struct{}{}
+	}
+	return replayed, nil
+}
+
+// pushReplayed pushes msg onto the subscription channels returned by chs. Unlike push it
+// waits for the channels to have room, until ctx is done, whatever their policy. Channels
+// canceled meanwhile are skipped.
+func pushReplayed[T any](ctx context.Context, subs *subscriptions, chs func() []chan T, msg T) error {
+	pushed := make(map[chan T]bool)
+	for {
+		pending := false
+		subs.RLock()
+		for _, ch := range chs() {
+			if pushed[ch] {
+				continue
+			}
+			if o := subs.opts[ch]; o != nil && o.policy == spill {
+				if err := o.spill.(*spillQueue[T]).put(msg); err != nil {
+					atomic.AddUint64(&o.dropped, 1)
+				}
+				pushed[ch] = true
+				continue
+			}
+			select {
+			case ch <- msg:
+				pushed[ch] = true
+			default:
+				pending = true
+			}
+		}
+		subs.RUnlock()
+		if !pending {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(replayRetryInterval):
+		}
+	}
+}
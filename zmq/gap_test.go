@@ -0,0 +1,180 @@
+package zmq
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockHash returns the hash of the test block at height, or of a stale block.
+func blockHash(height int64, stale bool) string {
+	if stale {
+		return fmt.Sprintf("ff%062x", height)
+	}
+	return fmt.Sprintf("%064x", height)
+}
+
+func hashBytes(t *testing.T, hash string) []byte {
+	b, err := hex.DecodeString(hash)
+	require.NoError(t, err)
+	return b
+}
+
+func TestGapCatchUpBlocks(t *testing.T) {
+	// The main chain is 0-9, a stale block hangs on 3.
+	bc, _ := newRpcClient(t, func(w http.ResponseWriter, req rpcRequest) {
+		params := req.Params.([]interface{})
+		var result interface{}
+		switch req.Method {
+		case "getblockhash":
+			result = blockHash(int64(params[0].(float64)), false)
+		case "getblockheader":
+			var height int64
+			fmt.Sscanf(params[0].(string)[2:], "%x", &height)
+			header := BlockHeader{Hash: params[0].(string), Height: height, Confirmations: 10 - height}
+			if params[0].(string)[:2] == "ff" {
+				header.Confirmations = -1
+			}
+			if height > 0 {
+				header.PreviousBlockHash = blockHash(height-1, false)
+			}
+			result = header
+		}
+		data, _ := json.Marshal(result)
+		fmt.Fprintf(w, `{"result":%s,"error":null,"id":%d}`, data, req.ID)
+	})
+	bc.Cfg.CatchUp = true
+	hashBlock := make(chan HashMsg, 10)
+	gaps := make(chan GapMsg, 10)
+	bc.subs.hashBlock = []chan HashMsg{hashBlock}
+	bc.subs.gaps = []chan GapMsg{gaps}
+	topics := make(map[string]*topicState)
+
+	bc.checkGap(topics, "hashblock", 7, hashBytes(t, blockHash(1, false)))
+	bc.checkGap(topics, "hashblock", 8, hashBytes(t, blockHash(2, false)))
+	assert.Empty(t, gaps)
+
+	bc.checkGap(topics, "hashblock", 10, hashBytes(t, blockHash(5, false)))
+	require.Len(t, gaps, 1)
+	assert.Equal(t, GapMsg{Topic: "hashblock", From: 9, To: 9, Replayed: 2}, <-gaps)
+	assert.Equal(t, uint32(1), GapMsg{From: 9, To: 9}.Missed())
+	for _, height := range []int64{3, 4} {
+		msg := <-hashBlock
+		assert.True(t, msg.Replayed)
+		assert.Equal(t, blockHash(height, false), hex.EncodeToString(msg.Hash[:]))
+	}
+
+	// The last block delivered was reorged out, the replay starts after the fork point.
+	bc.checkGap(topics, "hashblock", 11, hashBytes(t, blockHash(4, true)))
+	bc.checkGap(topics, "hashblock", 15, hashBytes(t, blockHash(6, false)))
+	assert.Equal(t, GapMsg{Topic: "hashblock", From: 12, To: 14, Replayed: 2}, <-gaps)
+	for _, height := range []int64{4, 5} {
+		msg := <-hashBlock
+		assert.Equal(t, blockHash(height, false), hex.EncodeToString(msg.Hash[:]))
+	}
+
+	// bitcoind restarted.
+	bc.checkGap(topics, "hashblock", 0, hashBytes(t, blockHash(7, false)))
+	assert.Empty(t, gaps)
+
+	// bitcoind restarted and its first messages were missed while reconnecting.
+	bc.checkGap(topics, "hashblock", 1, hashBytes(t, blockHash(8, false)))
+	bc.checkGap(topics, "hashblock", 1, hashBytes(t, blockHash(9, false)))
+	bc.checkGap(topics, "hashblock", 2, hashBytes(t, blockHash(9, false)))
+	assert.Empty(t, gaps)
+	assert.Empty(t, hashBlock)
+	assert.Equal(t, uint32(2), topics["hashblock"].seq)
+}
+
+func TestGapCatchUpTxs(t *testing.T) {
+	// The mempool holds old when the topic is first seen.
+	old := blockHash(9, false)
+	txids := []string{blockHash(1, false), blockHash(2, false), blockHash(3, false)}
+	var mu sync.Mutex
+	mempool := []string{old}
+	bc, _ := newRpcClient(t, func(w http.ResponseWriter, req rpcRequest) {
+		mu.Lock()
+		data, _ := json.Marshal(RawMempoolSequence{Txids: mempool, MempoolSequence: 42})
+		mu.Unlock()
+		fmt.Fprintf(w, `{"result":%s,"error":null,"id":%d}`, data, req.ID)
+	})
+	bc.Cfg.CatchUp = true
+	hashTx := make(chan HashMsg, 10)
+	gaps := make(chan GapMsg, 10)
+	bc.subs.hashTx = []chan HashMsg{hashTx}
+	bc.subs.gaps = []chan GapMsg{gaps}
+	topics := make(map[string]*topicState)
+
+	bc.checkGap(topics, "hashtx", 1, hashBytes(t, txids[0]))
+	mu.Lock()
+	mempool = append(mempool, txids...)
+	mu.Unlock()
+	bc.checkGap(topics, "hashtx", 3, hashBytes(t, blockHash(4, false)))
+	assert.Equal(t, GapMsg{Topic: "hashtx", From: 2, To: 2, Replayed: 2}, <-gaps)
+	for _, txid := range txids[1:] {
+		msg := <-hashTx
+		assert.True(t, msg.Replayed)
+		assert.Equal(t, txid, hex.EncodeToString(msg.Hash[:]))
+	}
+	assert.Empty(t, hashTx)
+
+	// Without CatchUp the gap is only reported.
+	bc.Cfg.CatchUp = false
+	bc.checkGap(topics, "hashtx", 9, hashBytes(t, blockHash(5, false)))
+	assert.Equal(t, GapMsg{Topic: "hashtx", From: 4, To: 8}, <-gaps)
+	assert.Empty(t, hashTx)
+}
+
+func TestTopicWorker(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	bc, _ := newRpcClient(t, func(w http.ResponseWriter, req rpcRequest) {
+		// The seeding sees an empty mempool, the catch-up waits for release.
+		var txids []string
+		if atomic.AddInt32(&calls, 1) > 1 {
+			<-release
+			txids = []string{blockHash(2, false), blockHash(3, false)}
+		}
+		data, _ := json.Marshal(RawMempoolSequence{Txids: txids})
+		fmt.Fprintf(w, `{"result":%s,"error":null,"id":%d}`, data, req.ID)
+	})
+	bc.Cfg.CatchUp = true
+	hashTx := make(chan HashMsg, 10)
+	gaps := make(chan GapMsg, 10)
+	bc.subs.hashTx = []chan HashMsg{hashTx}
+	bc.subs.gaps = []chan GapMsg{gaps}
+
+	w := bc.newTopicWorker("hashtx")
+	defer w.stop()
+	for i, seq := range []uint32{1, 3, 4} {
+		w.queue.put(topicMsg{body: hashBytes(t, blockHash(int64(i+1)*10, false)), seq: seq})
+	}
+	assert.Equal(t, HashMsg{Hash: [32]byte{31: 10}, Seq: 1}, <-hashTx)
+	// The live messages are held back during the catch-up.
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, hashTx)
+	assert.Empty(t, gaps)
+
+	close(release)
+	assert.Equal(t, GapMsg{Topic: "hashtx", From: 2, To: 2, Replayed: 2}, <-gaps)
+	var got []string
+	for i := 0; i < 4; i++ {
+		msg := <-hashTx
+		got = append(got, hex.EncodeToString(msg.Hash[:]))
+	}
+	assert.Equal(t, []string{blockHash(2, false), blockHash(3, false), blockHash(20, false), blockHash(30, false)}, got)
+
+	// A reset starts the gap detection over.
+	w.queue.put(topicMsg{reset: true})
+	w.queue.put(topicMsg{body: hashBytes(t, blockHash(40, false)), seq: 9})
+	assert.Equal(t, uint32(9), (<-hashTx).Seq)
+	assert.Empty(t, gaps)
+}
@@ -0,0 +1,62 @@
+package zmq
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satshub/go-bitcoind/jsonrpc/jsonrpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFakeBitcoind runs the RPC consumers of the client against the fake bitcoind of jsonrpctest.
+func TestFakeBitcoind(t *testing.T) {
+	s := jsonrpctest.NewServer(nil)
+	defer s.Close()
+	s.SetAuth("user", "pass")
+	bc, err := New(Config{
+		RpcAddress:  fmt.Sprintf("%s:%d", s.Host, s.Port),
+		RpcUser:     "user",
+		RpcPassword: "pass",
+	})
+	require.NoError(t, err)
+	defer bc.Close()
+	ctx := context.Background()
+
+	var connected []int64
+	f, err := NewChainFollower(bc, FollowerConfig{
+		Tip:          chaincfg.RegressionNetParams.GenesisHash.String(),
+		Connected:    func(header BlockHeader) error { connected = append(connected, header.Height); return nil },
+		Disconnected: func(BlockHeader) error { return nil },
+	})
+	require.NoError(t, err)
+	b1 := s.MineBlock()
+	b2 := s.MineBlock()
+	require.NoError(t, f.sync(ctx))
+	assert.Equal(t, []int64{1, 2}, connected)
+	assert.Equal(t, b2.BlockHash().String(), f.Tip().Hash)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: b1.Transactions[0].TxHash()}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(b1.Transactions[0].TxOut[0].Value-1000, []byte{txscript.OP_TRUE}))
+	var buf bytes.Buffer
+	require.NoError(t, tx.Serialize(&buf))
+	txid, err := bc.SendRawTransaction(ctx, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, tx.TxHash().String(), txid)
+	raw, err := bc.GetRawTransaction(ctx, txid)
+	require.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), raw)
+
+	m := &MempoolMirror{bc: bc, idx: newMempoolIndex(), synced: make(chan struct{})}
+	require.NoError(t, m.resync(ctx))
+	assert.Equal(t, s.MempoolSequence(), m.MempoolSeq())
+	mtx, ok := m.Get(tx.TxHash())
+	require.True(t, ok)
+	assert.Equal(t, int64(1000), mtx.Fee)
+}
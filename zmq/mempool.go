@@ -0,0 +1,403 @@
+package zmq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// mempoolRetryInterval is how long MempoolMirror waits before retrying a failed resync.
+const mempoolRetryInterval = time.Second
+
+// DefaultFeeBuckets are fee-rate bucket bounds, in sat/vB, for MempoolMirror.FeeBuckets.
+var DefaultFeeBuckets = []float64{1, 2, 3, 5, 8, 10, 15, 20, 30, 50, 75, 100, 150, 200, 300, 500, 1000}
+
+// errMempoolGap means that MempoolMirror missed mempool events.
+var errMempoolGap = errors.New("Mempool sequence gap.")
+
+// MempoolTx is a transaction of MempoolMirror. It is shared and must not be modified.
+type MempoolTx struct {
+	Tx      *wire.MsgTx
+	Hash    chainhash.Hash // txid
+	Fee     int64          // base fee, satoshis
+	VSize   int64
+	FeeRate float64 // sat/vB
+	Time    time.Time
+}
+
+// FeeBucket is a fee-rate range of MempoolMirror.FeeBuckets.
+type FeeBucket struct {
+	MinFeeRate float64 // sat/vB, inclusive
+	MaxFeeRate float64 // sat/vB, exclusive, +Inf for the last bucket
+	Count      int
+	VSize      int64
+	Fees       int64 // satoshis
+}
+
+// mempoolIndex holds the transactions of MempoolMirror and their indexes.
+type mempoolIndex struct {
+	txs      map[chainhash.Hash]*MempoolTx
+	spenders map[wire.OutPoint]*MempoolTx
+	scripts  map[string]map[chainhash.Hash]*MempoolTx
+}
+
+func newMempoolIndex() *mempoolIndex {
+	return &mempoolIndex{
+		txs:      make(map[chainhash.Hash]*MempoolTx),
+		spenders: make(map[wire.OutPoint]*MempoolTx),
+		scripts:  make(map[string]map[chainhash.Hash]*MempoolTx),
+	}
+}
+
+func (idx *mempoolIndex) add(tx *MempoolTx) {
+	idx.remove(tx.Hash)
+	idx.txs[tx.Hash] = tx
+	for _, in := range tx.Tx.TxIn {
+		idx.spenders[in.PreviousOutPoint] = tx
+	}
+	for _, out := range tx.Tx.TxOut {
+		byScript := idx.scripts[string(out.PkScript)]
+		if byScript == nil {
+			byScript = make(map[chainhash.Hash]*MempoolTx)
+			idx.scripts[string(out.PkScript)] = byScript
+		}
+		byScript[tx.Hash] = tx
+	}
+}
+
+func (idx *mempoolIndex) remove(txid chainhash.Hash) {
+	tx := idx.txs[txid]
+	if tx == nil {
+		return
+	}
+	delete(idx.txs, txid)
+	for _, in := range tx.Tx.TxIn {
+		if idx.spenders[in.PreviousOutPoint] == tx {
+			delete(idx.spenders, in.PreviousOutPoint)
+		}
+	}
+	for _, out := range tx.Tx.TxOut {
+		byScript := idx.scripts[string(out.PkScript)]
+		delete(byScript, txid)
+		if len(byScript) == 0 {
+			delete(idx.scripts, string(out.PkScript))
+		}
+	}
+}
+
+// MempoolMirror keeps an in-process copy of the mempool of bitcoind, from the
+// "sequence" messages and getrawmempool, following
+// https://github.com/bitcoin/bitcoin/blob/master/doc/zmq.md#usage. It resyncs
+// itself when messages are lost.
+//
+// Every added transaction costs a getrawtransaction and a getmempoolentry call,
+// and every connected block a getblock call.
+//
+// Must be created with NewMempoolMirror and driven by Run. The query methods are
+// safe for concurrent use.
+type MempoolMirror struct {
+	bc *BitcoindClient
+
+	mu  sync.RWMutex
+	idx *mempoolIndex
+	// seq is the mempool sequence number idx is valid at.
+	seq uint64
+	// blockSince is whether a block was connected or disconnected since seq, the
+	// mempool sequence numbers of the block transactions are not published.
+	blockSince bool
+
+	synced     chan struct{}
+	syncedOnce sync.Once
+}
+
+// NewMempoolMirror returns a mirror of the mempool of bitcoind through bc, which must have
+// RpcAddress set and ZmqPubAddress or ZmqTopicAddresses set for the "sequence" topic.
+func NewMempoolMirror(bc *BitcoindClient) (*MempoolMirror, error) {
+	if bc.Cfg.RpcAddress == "" {
+		return nil, ErrRpcDisabled
+	}
+	if bc.zsubs["sequence"] == nil {
+		return nil, ErrSubscribeDisabled
+	}
+	return &MempoolMirror{
+		bc:     bc,
+		idx:    newMempoolIndex(),
+		synced: make(chan struct{}),
+	}, nil
+}
+
+// Synced returns a channel that is closed once the mirror has synced for the first time.
+func (m *MempoolMirror) Synced() <-chan struct{} {
+	return m.synced
+}
+
+// Run maintains the mirror until ctx is done or the client is closed, and returns the reason.
+// RPC errors trigger a resync.
+func (m *MempoolMirror) Run(ctx context.Context) error {
+	// The messages received during a resync are applied after it, so none can be dropped.
+	sequence, cancel, err := m.bc.SubscribeSequence(WithSpill("", 0))
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	resync := true
+	var zmqSeq uint32
+	haveZmqSeq := false
+	for {
+		if resync {
+			if err := m.resync(ctx); err != nil {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(mempoolRetryInterval):
+					continue
+				}
+			}
+			resync = false
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-sequence:
+			if !ok {
+				return ErrSubscribeExited
+			}
+			// Messages were lost, or bitcoind restarted if the seq is zero.
+			if haveZmqSeq && msg.Seq != zmqSeq+1 {
+				resync = true
+			}
+			zmqSeq, haveZmqSeq = msg.Seq, true
+			if resync {
+				continue
+			}
+			if err := m.apply(ctx, msg); err != nil {
+				resync = true
+			}
+		}
+	}
+}
+
+// resync replaces the content of the mirror with a snapshot of the mempool.
+// The transactions already known are not fetched again.
+func (m *MempoolMirror) resync(ctx context.Context) error {
+	snapshot, err := m.bc.GetRawMempoolSequence(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.RLock()
+	old := m.idx
+	m.mu.RUnlock()
+
+	idx := newMempoolIndex()
+	for _, txid := range snapshot.Txids {
+		hash, err := chainhash.NewHashFromStr(txid)
+		if err != nil {
+			return err
+		}
+		if tx := old.txs[*hash]; tx != nil {
+			idx.add(tx)
+			continue
+		}
+		tx, err := m.fetch(ctx, txid)
+		if err != nil {
+			var bitcoindErr BitcoindError
+			if errors.As(err, &bitcoindErr) {
+				// Left the mempool meanwhile, its removal comes after the snapshot.
+				continue
+			}
+			return err
+		}
+		idx.add(tx)
+	}
+
+	m.mu.Lock()
+	m.idx = idx
+	m.seq = snapshot.MempoolSequence
+	m.blockSince = false
+	m.mu.Unlock()
+	m.syncedOnce.Do(func() { close(m.synced) })
+	return nil
+}
+
+// apply applies the event msg to the mirror.
+func (m *MempoolMirror) apply(ctx context.Context, msg SequenceMsg) error {
+	// The hashes of the messages are in the RPC byte order.
+	var hash chainhash.Hash
+	for i := range msg.Hash {
+		hash[i] = msg.Hash[len(msg.Hash)-1-i]
+	}
+
+	switch msg.Event {
+	case BlockConnected:
+		txids, err := m.bc.GetBlockTxids(ctx, hash.String())
+		if err != nil {
+			return err
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, txid := range txids {
+			if h, err := chainhash.NewHashFromStr(txid); err == nil {
+				m.idx.remove(*h)
+			}
+		}
+		m.blockSince = true
+		return nil
+	case BlockDisconnected:
+		// The transactions of the block return to the mempool with their own events.
+		m.mu.Lock()
+		m.blockSince = true
+		m.mu.Unlock()
+		return nil
+	}
+
+	m.mu.RLock()
+	seq, blockSince := m.seq, m.blockSince
+	m.mu.RUnlock()
+	if msg.MempoolSeq <= seq {
+		// Already in the snapshot.
+		return nil
+	}
+	if msg.MempoolSeq != seq+1 && !blockSince {
+		return errMempoolGap
+	}
+
+	var tx *MempoolTx
+	if msg.Event == TransactionAdded {
+		var err error
+		if tx, err = m.fetch(ctx, hash.String()); err != nil {
+			var bitcoindErr BitcoindError
+			if !errors.As(err, &bitcoindErr) {
+				return err
+			}
+			// Left the mempool meanwhile, its removal event follows.
+			tx = nil
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if tx != nil {
+		m.idx.add(tx)
+	} else {
+		m.idx.remove(hash)
+	}
+	m.seq = msg.MempoolSeq
+	m.blockSince = false
+	return nil
+}
+
+// fetch returns the mempool transaction txid.
+func (m *MempoolMirror) fetch(ctx context.Context, txid string) (*MempoolTx, error) {
+	serialized, err := m.bc.GetRawTransaction(ctx, txid)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := m.bc.GetMempoolEntry(ctx, txid)
+	if err != nil {
+		return nil, err
+	}
+	tx := &MempoolTx{
+		Tx:    new(wire.MsgTx),
+		Fee:   int64(math.Round(entry.Fees.Base * 1e8)),
+		VSize: entry.VSize,
+		Time:  time.Unix(entry.Time, 0),
+	}
+	if err := tx.Tx.Deserialize(bytes.NewReader(serialized)); err != nil {
+		return nil, fmt.Errorf("Transaction %s: %w", txid, err)
+	}
+	tx.Hash = tx.Tx.TxHash()
+	if tx.VSize > 0 {
+		tx.FeeRate = float64(tx.Fee) / float64(tx.VSize)
+	}
+	return tx, nil
+}
+
+// MempoolSeq returns the mempool sequence number the mirror is at.
+func (m *MempoolMirror) MempoolSeq() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.seq
+}
+
+// Len returns the number of transactions in the mirror.
+func (m *MempoolMirror) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.idx.txs)
+}
+
+// Get returns the transaction txid.
+func (m *MempoolMirror) Get(txid chainhash.Hash) (tx *MempoolTx, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tx, ok = m.idx.txs[txid]
+	return
+}
+
+// Spender returns the transaction spending outpoint.
+func (m *MempoolMirror) Spender(outpoint wire.OutPoint) (tx *MempoolTx, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tx, ok = m.idx.spenders[outpoint]
+	return
+}
+
+// Conflicts returns the transactions spending the same outputs as tx, other than tx itself.
+func (m *MempoolMirror) Conflicts(tx *wire.MsgTx) (conflicts []*MempoolTx) {
+	txid := tx.TxHash()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	seen := make(map[chainhash.Hash]bool)
+	for _, in := range tx.TxIn {
+		spender := m.idx.spenders[in.PreviousOutPoint]
+		if spender != nil && spender.Hash != txid && !seen[spender.Hash] {
+			seen[spender.Hash] = true
+			conflicts = append(conflicts, spender)
+		}
+	}
+	return
+}
+
+// ByScript returns the transactions with an output paying to script.
+func (m *MempoolMirror) ByScript(script []byte) (txs []*MempoolTx) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, tx := range m.idx.scripts[string(script)] {
+		txs = append(txs, tx)
+	}
+	return
+}
+
+// FeeBuckets returns the transactions of the mirror aggregated by fee rate, between the
+// ascending bounds in sat/vB, eg DefaultFeeBuckets. The first bucket starts at zero and the
+// last one has no upper bound.
+func (m *MempoolMirror) FeeBuckets(bounds []float64) []FeeBucket {
+	buckets := make([]FeeBucket, len(bounds)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].MinFeeRate = bounds[i-1]
+		}
+		if i < len(bounds) {
+			buckets[i].MaxFeeRate = bounds[i]
+		} else {
+			buckets[i].MaxFeeRate = math.Inf(1)
+		}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, tx := range m.idx.txs {
+		i := sort.Search(len(bounds), func(i int) bool { return bounds[i] > tx.FeeRate })
+		buckets[i].Count++
+		buckets[i].VSize += tx.VSize
+		buckets[i].Fees += tx.Fee
+	}
+	return buckets
+}
@@ -0,0 +1,176 @@
+package zmq
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMempool is the mempool of a fake bitcoind.
+type testMempool struct {
+	sync.Mutex
+	seq    uint64
+	txs    map[string]*wire.MsgTx
+	fees   map[string]int64
+	blocks map[string][]string
+}
+
+// newTestTx returns a transaction spending the outpoints of prevs and paying to script.
+func newTestTx(script []byte, prevs ...wire.OutPoint) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	for i := range prevs {
+		tx.AddTxIn(wire.NewTxIn(&prevs[i], nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(1000, script))
+	return tx
+}
+
+func (mp *testMempool) add(tx *wire.MsgTx, fee int64) SequenceMsg {
+	mp.Lock()
+	defer mp.Unlock()
+	txid := tx.TxHash().String()
+	mp.txs[txid] = tx
+	mp.fees[txid] = fee
+	mp.seq++
+	return mp.msg(txid, TransactionAdded)
+}
+
+func (mp *testMempool) remove(tx *wire.MsgTx) SequenceMsg {
+	mp.Lock()
+	defer mp.Unlock()
+	txid := tx.TxHash().String()
+	delete(mp.txs, txid)
+	mp.seq++
+	return mp.msg(txid, TransactionRemoved)
+}
+
+// mine confirms txs in a block, without sequence events for them.
+func (mp *testMempool) mine(txs ...*wire.MsgTx) SequenceMsg {
+	mp.Lock()
+	defer mp.Unlock()
+	hash := fmt.Sprintf("%064x", len(mp.blocks)+1)
+	var txids []string
+	for _, tx := range txs {
+		txid := tx.TxHash().String()
+		txids = append(txids, txid)
+		delete(mp.txs, txid)
+		mp.seq++
+	}
+	mp.blocks[hash] = txids
+	return mp.msg(hash, BlockConnected)
+}
+
+func (mp *testMempool) msg(hash string, event SequenceEvent) SequenceMsg {
+	msg := SequenceMsg{Event: event}
+	hex.Decode(msg.Hash[:], []byte(hash))
+	if event == TransactionAdded || event == TransactionRemoved {
+		msg.MempoolSeq = mp.seq
+	}
+	return msg
+}
+
+func (mp *testMempool) reply(w http.ResponseWriter, req rpcRequest) {
+	mp.Lock()
+	defer mp.Unlock()
+	params := req.Params.([]interface{})
+	var result interface{}
+	notFound := func() {
+		fmt.Fprintf(w, `{"result":null,"error":{"code":-5,"message":"Transaction not in mempool"},"id":%d}`, req.ID)
+	}
+	switch req.Method {
+	case "getrawmempool":
+		seq := RawMempoolSequence{Txids: []string{}, MempoolSequence: mp.seq}
+		for txid := range mp.txs {
+			seq.Txids = append(seq.Txids, txid)
+		}
+		result = seq
+	case "getrawtransaction":
+		tx := mp.txs[params[0].(string)]
+		if tx == nil {
+			notFound()
+			return
+		}
+		var buf bytes.Buffer
+		tx.Serialize(&buf)
+		result = hex.EncodeToString(buf.Bytes())
+	case "getmempoolentry":
+		tx := mp.txs[params[0].(string)]
+		if tx == nil {
+			notFound()
+			return
+		}
+		var entry MempoolEntry
+		entry.VSize = int64(tx.SerializeSize())
+		entry.Fees.Base = float64(mp.fees[params[0].(string)]) / 1e8
+		result = entry
+	case "getblock":
+		result = map[string]interface{}{"tx": mp.blocks[params[0].(string)]}
+	}
+	data, _ := json.Marshal(result)
+	fmt.Fprintf(w, `{"result":%s,"error":null,"id":%d}`, data, req.ID)
+}
+
+func TestMempoolMirror(t *testing.T) {
+	mp := &testMempool{txs: make(map[string]*wire.MsgTx), fees: make(map[string]int64), blocks: make(map[string][]string)}
+	bc, _ := newRpcClient(t, mp.reply)
+	m := &MempoolMirror{bc: bc, idx: newMempoolIndex(), synced: make(chan struct{})}
+	ctx := context.Background()
+
+	coin := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 0}
+	script := []byte{0x51}
+	parent := newTestTx(script, coin)
+	mp.add(parent, 100)
+	require.NoError(t, m.resync(ctx))
+	<-m.Synced()
+	assert.Equal(t, 1, m.Len())
+	assert.Equal(t, uint64(1), m.MempoolSeq())
+
+	child := newTestTx([]byte{0x52}, wire.OutPoint{Hash: parent.TxHash()})
+	require.NoError(t, m.apply(ctx, mp.add(child, 100000)))
+	tx, ok := m.Get(child.TxHash())
+	require.True(t, ok)
+	assert.Equal(t, int64(100000), tx.Fee)
+	assert.Equal(t, float64(100000)/float64(child.SerializeSize()), tx.FeeRate)
+	spender, ok := m.Spender(coin)
+	require.True(t, ok)
+	assert.Equal(t, parent.TxHash(), spender.Hash)
+	assert.Len(t, m.ByScript(script), 1)
+
+	// A double spend of coin conflicts with parent.
+	doubleSpend := newTestTx([]byte{0x53}, coin)
+	conflicts := m.Conflicts(doubleSpend)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, parent.TxHash(), conflicts[0].Hash)
+	assert.Empty(t, m.Conflicts(parent))
+
+	buckets := m.FeeBuckets([]float64{10, 100})
+	assert.Equal(t, 1, buckets[0].Count)
+	assert.Equal(t, 0, buckets[1].Count)
+	assert.Equal(t, 1, buckets[2].Count)
+	assert.Equal(t, int64(100100), buckets[0].Fees+buckets[2].Fees)
+
+	// Mining parent removes it without an event, the next sequence number jumps.
+	require.NoError(t, m.apply(ctx, mp.mine(parent)))
+	_, ok = m.Get(parent.TxHash())
+	assert.False(t, ok)
+	require.NoError(t, m.apply(ctx, mp.remove(child)))
+	assert.Equal(t, 0, m.Len())
+
+	// Events already in the snapshot are ignored, gaps are reported.
+	added := mp.add(parent, 1000)
+	require.NoError(t, m.resync(ctx))
+	require.NoError(t, m.apply(ctx, added))
+	assert.Equal(t, 1, m.Len())
+	mp.add(child, 1000)
+	assert.Equal(t, errMempoolGap, m.apply(ctx, mp.remove(child)))
+}
@@ -0,0 +1,156 @@
+package zmq
+
+import (
+	"context"
+	"encoding/hex"
+)
+
+// BlockchainInfo is the result of GetBlockchainInfo.
+type BlockchainInfo struct {
+	Chain                string  `json:"chain"`
+	Blocks               int64   `json:"blocks"`
+	Headers              int64   `json:"headers"`
+	BestBlockHash        string  `json:"bestblockhash"`
+	Difficulty           float64 `json:"difficulty"`
+	Time                 int64   `json:"time"`
+	MedianTime           int64   `json:"mediantime"`
+	VerificationProgress float64 `json:"verificationprogress"`
+	InitialBlockDownload bool    `json:"initialblockdownload"`
+	Pruned               bool    `json:"pruned"`
+}
+
+// BlockHeader is the result of GetBlockHeader.
+type BlockHeader struct {
+	Hash              string  `json:"hash"`
+	Confirmations     int64   `json:"confirmations"` // -1 when the block is not on the main chain
+	Height            int64   `json:"height"`
+	Version           int32   `json:"version"`
+	MerkleRoot        string  `json:"merkleroot"`
+	Time              int64   `json:"time"`
+	MedianTime        int64   `json:"mediantime"`
+	Nonce             uint32  `json:"nonce"`
+	Bits              string  `json:"bits"`
+	Difficulty        float64 `json:"difficulty"`
+	ChainWork         string  `json:"chainwork"`
+	NTx               int     `json:"nTx"`
+	PreviousBlockHash string  `json:"previousblockhash"` // empty for the genesis block
+	NextBlockHash     string  `json:"nextblockhash"`     // empty for the tip
+}
+
+// RawMempoolSequence is the result of GetRawMempoolSequence.
+type RawMempoolSequence struct {
+	Txids []string `json:"txids"`
+	// MempoolSequence is the value of SequenceMsg.MempoolSeq the txids are
+	// valid at
+	MempoolSequence uint64 `json:"mempool_sequence"`
+}
+
+// MempoolEntry is the result of GetMempoolEntry.
+type MempoolEntry struct {
+	VSize  int64 `json:"vsize"`
+	Weight int64 `json:"weight"`
+	Time   int64 `json:"time"`   // entry time, unix seconds
+	Height int64 `json:"height"` // block height at entry
+	Fees   struct {
+		Base     float64 `json:"base"` // BTC
+		Modified float64 `json:"modified"`
+	} `json:"fees"`
+	Depends []string `json:"depends"` // unconfirmed parent txids
+}
+
+// ZmqNotification is an entry of GetZmqNotifications.
+type ZmqNotification struct {
+	Type    string `json:"type"` // eg "pubhashblock"
+	Address string `json:"address"`
+	HWM     int    `json:"hwm"`
+}
+
+// GetBlockchainInfo returns the state of the block chain.
+func (bc *BitcoindClient) GetBlockchainInfo(ctx context.Context) (info BlockchainInfo, err error) {
+	err = bc.Call(ctx, "getblockchaininfo", nil, &info)
+	return
+}
+
+// GetBestBlockHash returns the hash of the tip of the most-work chain.
+func (bc *BitcoindClient) GetBestBlockHash(ctx context.Context) (hash string, err error) {
+	err = bc.Call(ctx, "getbestblockhash", nil, &hash)
+	return
+}
+
+// GetBlockCount returns the height of the most-work chain.
+func (bc *BitcoindClient) GetBlockCount(ctx context.Context) (height int64, err error) {
+	err = bc.Call(ctx, "getblockcount", nil, &height)
+	return
+}
+
+// GetBlockHash returns the hash of the block at height in the most-work
+// chain.
+func (bc *BitcoindClient) GetBlockHash(ctx context.Context, height int64) (hash string, err error) {
+	err = bc.Call(ctx, "getblockhash", []interface{}{height}, &hash)
+	return
+}
+
+// GetBlockHeader returns the header of the block hash.
+func (bc *BitcoindClient) GetBlockHeader(ctx context.Context, hash string) (header BlockHeader, err error) {
+	err = bc.Call(ctx, "getblockheader", []interface{}{hash, true}, &header)
+	return
+}
+
+// GetRawBlock returns the serialized block hash, in the format of the
+// "rawblock" messages.
+func (bc *BitcoindClient) GetRawBlock(ctx context.Context, hash string) ([]byte, error) {
+	var data string
+	if err := bc.Call(ctx, "getblock", []interface{}{hash, 0}, &data); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(data)
+}
+
+// GetBlockTxids returns the txids of the transactions of the block hash.
+func (bc *BitcoindClient) GetBlockTxids(ctx context.Context, hash string) ([]string, error) {
+	var block struct {
+		Tx []string `json:"tx"`
+	}
+	if err := bc.Call(ctx, "getblock", []interface{}{hash, 1}, &block); err != nil {
+		return nil, err
+	}
+	return block.Tx, nil
+}
+
+// GetRawTransaction returns the serialized transaction txid, in the format
+// of the "rawtx" messages. Confirmed transactions are only found by nodes
+// with -txindex.
+func (bc *BitcoindClient) GetRawTransaction(ctx context.Context, txid string) ([]byte, error) {
+	var data string
+	if err := bc.Call(ctx, "getrawtransaction", []interface{}{txid, false}, &data); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(data)
+}
+
+// GetRawMempoolSequence returns the txids of the mempool and the mempool
+// sequence number they are valid at, to synchronize with the "sequence"
+// messages.
+func (bc *BitcoindClient) GetRawMempoolSequence(ctx context.Context) (seq RawMempoolSequence, err error) {
+	err = bc.Call(ctx, "getrawmempool", []interface{}{false, true}, &seq)
+	return
+}
+
+// GetMempoolEntry returns the mempool data of the transaction txid.
+func (bc *BitcoindClient) GetMempoolEntry(ctx context.Context, txid string) (entry MempoolEntry, err error) {
+	err = bc.Call(ctx, "getmempoolentry", []interface{}{txid}, &entry)
+	return
+}
+
+// GetZmqNotifications returns the active ZMQ notifications of bitcoind.
+func (bc *BitcoindClient) GetZmqNotifications(ctx context.Context) (notifications []ZmqNotification, err error) {
+	err = bc.Call(ctx, "getzmqnotifications", nil, &notifications)
+	return
+}
+
+// SendRawTransaction broadcasts the serialized transaction tx and returns
+// its txid.
+func (bc *BitcoindClient) SendRawTransaction(ctx context.Context, tx []byte) (txid string, err error) {
+	err = bc.Call(ctx, "sendrawtransaction", []interface{}{hex.EncodeToString(tx)}, &txid)
+	return
+}
@@ -0,0 +1,116 @@
+package zmq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// BitcoindError is an error returned by bitcoind in a JSON-RPC response.
+type BitcoindError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e BitcoindError) Error() string {
+	return fmt.Sprintf("bitcoind error %d: %s", e.Code, e.Message)
+}
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *BitcoindError  `json:"error"`
+	ID     uint64          `json:"id"`
+}
+
+type uriPathKey struct{}
+
+// UseUriPath returns a copy of ctx that makes the RPC requests made with it
+// use uriPath instead of Config.RpcUriPath.
+//
+// Example: ctx = UseUriPath(ctx, "/wallet/<WalletName>")
+func UseUriPath(ctx context.Context, uriPath string) context.Context {
+	if !strings.HasPrefix(uriPath, "/") {
+		uriPath = "/" + uriPath
+	}
+	return context.WithValue(ctx, uriPathKey{}, uriPath)
+}
+
+// rpcURL returns the URL of the requests made with ctx.
+func (bc *BitcoindClient) rpcURL(ctx context.Context) string {
+	uriPath := bc.Cfg.RpcUriPath
+	if p, ok := ctx.Value(uriPathKey{}).(string); ok {
+		uriPath = p
+	}
+	host := bc.Cfg.RpcAddress
+	if strings.HasPrefix(host, ":") {
+		host = "127.0.0.1" + host
+	}
+	return "http://" + host + uriPath
+}
+
+// Call sends a JSON-RPC request for method with params, a slice of
+// positional params or a map of named params, and unmarshals the result
+// into result unless it is nil.
+// Errors reported by bitcoind are returned as BitcoindError. The request is
+// aborted when ctx is done.
+func (bc *BitcoindClient) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	if bc.Cfg.RpcAddress == "" {
+		return ErrRpcDisabled
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "1.0",
+		ID:      atomic.AddUint64(&bc.rpcID, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, bc.rpcURL(ctx), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(bc.Cfg.RpcUser, bc.Cfg.RpcPassword)
+	resp, err := bc.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// bitcoind replies errors with an HTTP error status and a JSON body,
+	// other failures (eg 401 on bad credentials) have no JSON body.
+	var rpcResp rpcResponse
+	if err = json.Unmarshal(data, &rpcResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("RPC %s: HTTP status %s", method, resp.Status)
+		}
+		return fmt.Errorf("RPC %s: %w", method, err)
+	}
+	if rpcResp.Error != nil {
+		return *rpcResp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(rpcResp.Result, result)
+}
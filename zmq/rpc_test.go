@@ -0,0 +1,95 @@
+package zmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRpcClient returns a client of a test server replying to every request
+// with reply. The URL paths of the requests are recorded in paths.
+func newRpcClient(t *testing.T, reply func(w http.ResponseWriter, req rpcRequest)) (bc *BitcoindClient, paths *[]string) {
+	paths = new([]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, passwd, _ := r.BasicAuth()
+		if user != "user" || passwd != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		*paths = append(*paths, r.URL.Path)
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		reply(w, req)
+	}))
+	t.Cleanup(ts.Close)
+	bc, err := New(Config{
+		RpcAddress:  strings.TrimPrefix(ts.URL, "http://"),
+		RpcUser:     "user",
+		RpcPassword: "pass",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { bc.Close() })
+	return bc, paths
+}
+
+func TestCall(t *testing.T) {
+	bc, paths := newRpcClient(t, func(w http.ResponseWriter, req rpcRequest) {
+		switch req.Method {
+		case "getblockhash":
+			fmt.Fprintf(w, `{"result":"000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f","error":null,"id":%d}`, req.ID)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":%d}`, req.ID)
+		}
+	})
+	ctx := context.Background()
+
+	hash, err := bc.GetBlockHash(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f", hash)
+
+	err = bc.Call(UseUriPath(ctx, "wallet/hot"), "getwalletinfo", nil, nil)
+	var bitcoindErr BitcoindError
+	require.True(t, errors.As(err, &bitcoindErr))
+	assert.Equal(t, -32601, bitcoindErr.Code)
+
+	assert.Equal(t, []string{"/", "/wallet/hot"}, *paths)
+}
+
+func TestCallErrors(t *testing.T) {
+	bc, _ := newRpcClient(t, func(w http.ResponseWriter, req rpcRequest) {})
+	bc.Cfg.RpcPassword = "wrong"
+	_, err := bc.GetBlockCount(context.Background())
+	assert.EqualError(t, err, "RPC getblockcount: HTTP status 401 Unauthorized")
+
+	disabled, err := New(Config{})
+	require.NoError(t, err)
+	defer disabled.Close()
+	_, err = disabled.GetBlockCount(context.Background())
+	assert.Equal(t, ErrRpcDisabled, err)
+}
+
+func TestReady(t *testing.T) {
+	warmup := true
+	bc, _ := newRpcClient(t, func(w http.ResponseWriter, req rpcRequest) {
+		if warmup {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, `{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":%d}`, req.ID)
+			return
+		}
+		fmt.Fprintf(w, `{"result":{"chain":"regtest","blocks":101},"error":null,"id":%d}`, req.ID)
+	})
+	err := bc.Ready(context.Background())
+	assert.EqualError(t, err, "RPC not ready: bitcoind error -28: Loading block index...")
+
+	warmup = false
+	assert.NoError(t, bc.Ready(context.Background()))
+}
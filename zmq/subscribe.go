@@ -12,12 +12,16 @@ import (
 type HashMsg struct {
 	Hash [32]byte // use encoding/hex.EncodeToString() to get it into the RPC method string format.
 	Seq  uint32
+	// Replayed is true for messages back-filled over RPC after a gap, see Config.CatchUp. Their Seq is zero.
+	Replayed bool
 }
 
 // RawMsg is a subscription event coming from a "raw"-type ZMQ message.
 type RawMsg struct {
 	Serialized []byte // use encoding/hex.EncodeToString() to get it into the RPC method string format.
 	Seq        uint32
+	// Replayed is true for messages back-filled over RPC after a gap, see Config.CatchUp. Their Seq is zero.
+	Replayed bool
 }
 
 // SequenceMsg is a subscription event coming from a "sequence" ZMQ message.
@@ -25,6 +29,7 @@ type SequenceMsg struct {
 	Hash       [32]byte // use encoding/hex.EncodeToString() to get it into the RPC method string format.
 	Event      SequenceEvent
 	MempoolSeq uint64
+	Seq        uint32 // sequence number of the ZMQ message, not to be confused with MempoolSeq.
 }
 
 // SequenceEvent is an enum describing what event triggered the sequence message.
@@ -46,26 +51,42 @@ func (se SequenceEvent) String() string {
 type subscriptions struct {
 	sync.RWMutex
 
-	exited      chan struct{}
-	zfront      *zmq.Socket
-	latestEvent time.Time
+	exited chan struct{}
+	zfront *zmq.Socket
+	// connected is whether all the endpoints are connected, connChanged
+	// is closed and replaced when it changes.
+	connected   bool
+	connChanged chan struct{}
+	// closing is closed when the client is closing, to abort blocked pushes.
+	closing <-chan struct{}
+	// opts holds the options of the subscription channels, by channel.
+	opts map[interface{}]*subOptions
 
 	hashTx    [](chan HashMsg)
 	hashBlock [](chan HashMsg)
 	rawTx     [](chan RawMsg)
 	rawBlock  [](chan RawMsg)
 	sequence  [](chan SequenceMsg)
+	gaps      [](chan GapMsg)
+	status    [](chan StatusMsg)
+	// Decoded "rawtx" and "rawblock" subscriptions.
+	txs    []txSubscription
+	blocks [](chan BlockMsg)
 }
 
 // SubscribeHashTx subscribes to the ZMQ "hashtx" messages as HashMsg items pushed onto the channel.
 //
 // Call cancel to cancel the subscription and let the client release the resources. The channel is closed
 // when the subscription is canceled or when the client is closed.
-func (bc *BitcoindClient) SubscribeHashTx() (subCh chan HashMsg, cancel func(), err error) {
-	if bc.zsub == nil {
+func (bc *BitcoindClient) SubscribeHashTx(opts ...SubOption) (subCh chan HashMsg, cancel func(), err error) {
+	if bc.zsubs["hashtx"] == nil {
 		err = ErrSubscribeDisabled
 		return
 	}
+	o, err := newSubOptions(opts)
+	if err != nil {
+		return
+	}
 	bc.subs.Lock()
 	select {
 	case <-bc.subs.exited:
@@ -82,6 +103,7 @@ func (bc *BitcoindClient) SubscribeHashTx() (subCh chan HashMsg, cancel func(),
 		}
 	}
 	subCh = make(chan HashMsg, bc.Cfg.SubChannelBufferSize)
+	registerSub(&bc.subs, subCh, o)
 	bc.subs.hashTx = append(bc.subs.hashTx, subCh)
 	bc.subs.Unlock()
 	cancel = func() { bc.unsubscribeHashTx(subCh) }
@@ -106,8 +128,8 @@ func (bc *BitcoindClient) unsubscribeHashTx(subCh chan HashMsg) (err error) {
 			break
 		}
 	}
+	closeSub(&bc.subs, subCh)
 	bc.subs.Unlock()
-	close(subCh)
 	return
 }
 
@@ -115,11 +137,15 @@ func (bc *BitcoindClient) unsubscribeHashTx(subCh chan HashMsg) (err error) {
 //
 // Call cancel to cancel the subscription and let the client release the resources. The channel is closed
 // when the subscription is canceled or when the client is closed.
-func (bc *BitcoindClient) SubscribeHashBlock() (subCh chan HashMsg, cancel func(), err error) {
-	if bc.zsub == nil {
+func (bc *BitcoindClient) SubscribeHashBlock(opts ...SubOption) (subCh chan HashMsg, cancel func(), err error) {
+	if bc.zsubs["hashblock"] == nil {
 		err = ErrSubscribeDisabled
 		return
 	}
+	o, err := newSubOptions(opts)
+	if err != nil {
+		return
+	}
 	bc.subs.Lock()
 	select {
 	case <-bc.subs.exited:
@@ -136,6 +162,7 @@ func (bc *BitcoindClient) SubscribeHashBlock() (subCh chan HashMsg, cancel func(
 		}
 	}
 	subCh = make(chan HashMsg, bc.Cfg.SubChannelBufferSize)
+	registerSub(&bc.subs, subCh, o)
 	bc.subs.hashBlock = append(bc.subs.hashBlock, subCh)
 	bc.subs.Unlock()
 	cancel = func() { bc.unsubscribeHashBlock(subCh) }
@@ -160,8 +187,8 @@ func (bc *BitcoindClient) unsubscribeHashBlock(subCh chan HashMsg) (err error) {
 			break
 		}
 	}
+	closeSub(&bc.subs, subCh)
 	bc.subs.Unlock()
-	close(subCh)
 	return
 }
 
@@ -169,11 +196,15 @@ func (bc *BitcoindClient) unsubscribeHashBlock(subCh chan HashMsg) (err error) {
 //
 // Call cancel to cancel the subscription and let the client release the resources. The channel is closed
 // when the subscription is canceled or when the client is closed.
-func (bc *BitcoindClient) SubscribeRawTx() (subCh chan RawMsg, cancel func(), err error) {
-	if bc.zsub == nil {
+func (bc *BitcoindClient) SubscribeRawTx(opts ...SubOption) (subCh chan RawMsg, cancel func(), err error) {
+	if bc.zsubs["rawtx"] == nil {
 		err = ErrSubscribeDisabled
 		return
 	}
+	o, err := newSubOptions(opts)
+	if err != nil {
+		return
+	}
 	bc.subs.Lock()
 	select {
 	case <-bc.subs.exited:
@@ -182,7 +213,7 @@ func (bc *BitcoindClient) SubscribeRawTx() (subCh chan RawMsg, cancel func(), er
 		return
 	default:
 	}
-	if len(bc.subs.rawTx) == 0 {
+	if len(bc.subs.rawTx) == 0 && len(bc.subs.txs) == 0 {
 		_, err = bc.subs.zfront.SendMessage("subscribe", "rawtx")
 		if err != nil {
 			bc.subs.Unlock()
@@ -190,6 +221,7 @@ func (bc *BitcoindClient) SubscribeRawTx() (subCh chan RawMsg, cancel func(), er
 		}
 	}
 	subCh = make(chan RawMsg, bc.Cfg.SubChannelBufferSize)
+	registerSub(&bc.subs, subCh, o)
 	bc.subs.rawTx = append(bc.subs.rawTx, subCh)
 	bc.subs.Unlock()
 	cancel = func() { bc.unsubscribeRawTx(subCh) }
@@ -208,14 +240,14 @@ func (bc *BitcoindClient) unsubscribeRawTx(subCh chan RawMsg) (err error) {
 	for i, ch := range bc.subs.rawTx {
 		if ch == subCh {
 			bc.subs.rawTx = append(bc.subs.rawTx[:i], bc.subs.rawTx[i+1:]...)
-			if len(bc.subs.rawTx) == 0 {
+			if len(bc.subs.rawTx) == 0 && len(bc.subs.txs) == 0 {
 				_, err = bc.subs.zfront.SendMessage("unsubscribe", "rawtx")
 			}
 			break
 		}
 	}
+	closeSub(&bc.subs, subCh)
 	bc.subs.Unlock()
-	close(subCh)
 	return
 }
 
@@ -223,11 +255,15 @@ func (bc *BitcoindClient) unsubscribeRawTx(subCh chan RawMsg) (err error) {
 //
 // Call cancel to cancel the subscription and let the client release the resources. The channel is closed
 // when the subscription is canceled or when the client is closed.
-func (bc *BitcoindClient) SubscribeRawBlock() (subCh chan RawMsg, cancel func(), err error) {
-	if bc.zsub == nil {
+func (bc *BitcoindClient) SubscribeRawBlock(opts ...SubOption) (subCh chan RawMsg, cancel func(), err error) {
+	if bc.zsubs["rawblock"] == nil {
 		err = ErrSubscribeDisabled
 		return
 	}
+	o, err := newSubOptions(opts)
+	if err != nil {
+		return
+	}
 	bc.subs.Lock()
 	select {
 	case <-bc.subs.exited:
@@ -236,7 +272,7 @@ func (bc *BitcoindClient) SubscribeRawBlock() (subCh chan RawMsg, cancel func(),
 		return
 	default:
 	}
-	if len(bc.subs.rawBlock) == 0 {
+	if len(bc.subs.rawBlock) == 0 && len(bc.subs.blocks) == 0 {
 		_, err = bc.subs.zfront.SendMessage("subscribe", "rawblock")
 		if err != nil {
 			bc.subs.Unlock()
@@ -244,6 +280,7 @@ func (bc *BitcoindClient) SubscribeRawBlock() (subCh chan RawMsg, cancel func(),
 		}
 	}
 	subCh = make(chan RawMsg, bc.Cfg.SubChannelBufferSize)
+	registerSub(&bc.subs, subCh, o)
 	bc.subs.rawBlock = append(bc.subs.rawBlock, subCh)
 	bc.subs.Unlock()
 	cancel = func() { bc.unsubscribeRawBlock(subCh) }
@@ -262,14 +299,14 @@ func (bc *BitcoindClient) unsubscribeRawBlock(subCh chan RawMsg) (err error) {
 	for i, ch := range bc.subs.rawBlock {
 		if ch == subCh {
 			bc.subs.rawBlock = append(bc.subs.rawBlock[:i], bc.subs.rawBlock[i+1:]...)
-			if len(bc.subs.rawBlock) == 0 {
+			if len(bc.subs.rawBlock) == 0 && len(bc.subs.blocks) == 0 {
 				_, err = bc.subs.zfront.SendMessage("unsubscribe", "rawblock")
 			}
 			break
 		}
 	}
+	closeSub(&bc.subs, subCh)
 	bc.subs.Unlock()
-	close(subCh)
 	return
 }
 
@@ -277,11 +314,15 @@ func (bc *BitcoindClient) unsubscribeRawBlock(subCh chan RawMsg) (err error) {
 //
 // Call cancel to cancel the subscription and let the client release the resources. The channel is closed
 // when the subscription is canceled or when the client is closed.
-func (bc *BitcoindClient) SubscribeSequence() (subCh chan SequenceMsg, cancel func(), err error) {
-	if bc.zsub == nil {
+func (bc *BitcoindClient) SubscribeSequence(opts ...SubOption) (subCh chan SequenceMsg, cancel func(), err error) {
+	if bc.zsubs["sequence"] == nil {
 		err = ErrSubscribeDisabled
 		return
 	}
+	o, err := newSubOptions(opts)
+	if err != nil {
+		return
+	}
 	bc.subs.Lock()
 	select {
 	case <-bc.subs.exited:
@@ -298,6 +339,7 @@ func (bc *BitcoindClient) SubscribeSequence() (subCh chan SequenceMsg, cancel fu
 		}
 	}
 	subCh = make(chan SequenceMsg, bc.Cfg.SubChannelBufferSize)
+	registerSub(&bc.subs, subCh, o)
 	bc.subs.sequence = append(bc.subs.sequence, subCh)
 	bc.subs.Unlock()
 	cancel = func() { bc.unsubscribeSequence(subCh) }
@@ -322,201 +364,259 @@ func (bc *BitcoindClient) unsubscribeSequence(subCh chan SequenceMsg) (err error
 			break
 		}
 	}
+	closeSub(&bc.subs, subCh)
 	bc.subs.Unlock()
-	close(subCh)
 	return
 }
 
 func (bc *BitcoindClient) zmqHandler() {
 	defer bc.wg.Done()
-	defer bc.zsub.Close()
 	defer bc.zback.Close()
 
 	poller := zmq.NewPoller()
-	poller.Add(bc.zsub, zmq.POLLIN)
 	poller.Add(bc.zback, zmq.POLLIN)
+	// bySocket maps the zsub and zmon sockets to their endpoint.
+	bySocket := make(map[*zmq.Socket]*endpoint)
+	for _, ep := range bc.endpoints {
+		defer ep.zsub.Close()
+		defer ep.zmon.Close()
+		poller.Add(ep.zsub, zmq.POLLIN)
+		poller.Add(ep.zmon, zmq.POLLIN)
+		bySocket[ep.zsub] = ep
+		bySocket[ep.zmon] = ep
+	}
+	// Wait forever unless the silence of the endpoints must be checked.
+	pollTimeout := time.Duration(-1)
+	if bc.Cfg.ZmqSilenceTimeout > 0 {
+		pollTimeout = min(bc.Cfg.ZmqSilenceTimeout/10, time.Second)
+	}
+	// topics holds the gap detection state of the subscribed topics, workers
+	// replaces it with Config.CatchUp.
+	topics := make(map[string]*topicState)
+	workers := make(map[string]*topicWorker)
 OUTER:
 	for {
-		// Wait forever until a message can be received or the context was cancelled.
-		polled, err := poller.Poll(-1)
+		// Wait until a message can be received or the context was cancelled.
+		polled, err := poller.Poll(pollTimeout)
 		if err != nil {
 			break OUTER
 		}
 
 		for _, p := range polled {
-			switch p.Socket {
-			case bc.zsub:
-				msg, err := bc.zsub.RecvMessage(0)
-				if err != nil {
-					break OUTER
-				}
-				bc.subs.latestEvent = time.Now()
-				switch msg[0] {
-				case "hashtx":
-					var hashMsg HashMsg
-					copy(hashMsg.Hash[:], msg[1])
-					hashMsg.Seq = binary.LittleEndian.Uint32([]byte(msg[2]))
-					bc.subs.RLock()
-					for _, ch := range bc.subs.hashTx {
-						select {
-						case ch <- hashMsg:
-						default:
-							select {
-							// Pop the oldest item and push the newest item (the user will miss a message).
-							case _ = <-ch:
-								ch <- hashMsg
-							case ch <- hashMsg:
-							default:
-							}
-						}
-					}
-					bc.subs.RUnlock()
-				case "hashblock":
-					var hashMsg HashMsg
-					copy(hashMsg.Hash[:], msg[1])
-					hashMsg.Seq = binary.LittleEndian.Uint32([]byte(msg[2]))
-					bc.subs.RLock()
-					for _, ch := range bc.subs.hashBlock {
-						select {
-						case ch <- hashMsg:
-						default:
-							select {
-							// Pop the oldest item and push the newest item (the user will miss a message).
-							case _ = <-ch:
-								ch <- hashMsg
-							case ch <- hashMsg:
-							default:
-							}
-						}
-					}
-					bc.subs.RUnlock()
-				case "rawtx":
-					var rawMsg RawMsg
-					rawMsg.Serialized = []byte(msg[1])
-					rawMsg.Seq = binary.LittleEndian.Uint32([]byte(msg[2]))
-					bc.subs.RLock()
-					for _, ch := range bc.subs.rawTx {
-						select {
-						case ch <- rawMsg:
-						default:
-							select {
-							// Pop the oldest item and push the newest item (the user will miss a message).
-							case _ = <-ch:
-								ch <- rawMsg
-							case ch <- rawMsg:
-							default:
-							}
-						}
-					}
-					bc.subs.RUnlock()
-				case "rawblock":
-					var rawMsg RawMsg
-					rawMsg.Serialized = []byte(msg[1])
-					rawMsg.Seq = binary.LittleEndian.Uint32([]byte(msg[2]))
-					bc.subs.RLock()
-					for _, ch := range bc.subs.rawBlock {
-						select {
-						case ch <- rawMsg:
-						default:
-							select {
-							// Pop the oldest item and push the newest item (the user will miss a message).
-							case _ = <-ch:
-								ch <- rawMsg
-							case ch <- rawMsg:
-							default:
-							}
-						}
-					}
-					bc.subs.RUnlock()
-				case "sequence":
-					var sequenceMsg SequenceMsg
-					copy(sequenceMsg.Hash[:], msg[1])
-					switch msg[1][32] {
-					case 'C':
-						sequenceMsg.Event = BlockConnected
-					case 'D':
-						sequenceMsg.Event = BlockDisconnected
-					case 'R':
-						sequenceMsg.Event = TransactionRemoved
-						sequenceMsg.MempoolSeq = binary.LittleEndian.Uint64([]byte(msg[1][33:]))
-					case 'A':
-						sequenceMsg.Event = TransactionAdded
-						sequenceMsg.MempoolSeq = binary.LittleEndian.Uint64([]byte(msg[1][33:]))
-					default:
-						// This is a fault. Drop the message.
-						continue
-					}
-					bc.subs.RLock()
-					for _, ch := range bc.subs.sequence {
-						select {
-						case ch <- sequenceMsg:
-						default:
-							select {
-							// Pop the oldest item and push the newest item (the user will miss a message).
-							case _ = <-ch:
-								ch <- sequenceMsg
-							case ch <- sequenceMsg:
-							default:
-							}
-						}
-					}
-					bc.subs.RUnlock()
-				}
-
-			case bc.zback:
+			if p.Socket == bc.zback {
 				msg, err := bc.zback.RecvMessage(0)
 				if err != nil {
 					break OUTER
 				}
 				switch msg[0] {
 				case "subscribe":
-					if err := bc.zsub.SetSubscribe(msg[1]); err != nil {
+					ep := bc.zsubs[msg[1]]
+					if err := ep.zsub.SetSubscribe(msg[1]); err != nil {
 						break OUTER
 					}
+					if ep.topics == 0 {
+						ep.latestEvent = time.Now()
+					}
+					ep.topics++
 				case "unsubscribe":
-					if err := bc.zsub.SetUnsubscribe(msg[1]); err != nil {
+					ep := bc.zsubs[msg[1]]
+					if err := ep.zsub.SetUnsubscribe(msg[1]); err != nil {
 						break OUTER
 					}
+					ep.topics--
+					// The sequence numbers of the topic will jump when it is
+					// subscribed again.
+					delete(topics, msg[1])
+					if w := workers[msg[1]]; w != nil {
+						w.queue.put(topicMsg{reset: true})
+					}
 				case "term":
 					break OUTER
 				}
+				continue
+			}
+
+			ep := bySocket[p.Socket]
+			if p.Socket == ep.zmon {
+				event, _, _, err := ep.zmon.RecvEvent(0)
+				if err != nil {
+					break OUTER
+				}
+				state := ZmqDisconnected
+				if event == zmq.EVENT_CONNECTED {
+					state = ZmqConnected
+				}
+				bc.setState(ep, state, time.Now())
+				continue
+			}
+
+			msg, err := ep.zsub.RecvMessage(0)
+			if err != nil {
+				break OUTER
+			}
+			ep.latestEvent = time.Now()
+			ep.backoff = 0
+			if len(msg) < 3 || len(msg[2]) != 4 {
+				// This is a fault. Drop the message.
+				continue
+			}
+			seq := binary.LittleEndian.Uint32([]byte(msg[2]))
+			if bc.Cfg.CatchUp {
+				w := workers[msg[0]]
+				if w == nil {
+					w = bc.newTopicWorker(msg[0])
+					workers[msg[0]] = w
+				}
+				w.queue.put(topicMsg{body: []byte(msg[1]), seq: seq})
+				continue
+			}
+			bc.checkGap(topics, msg[0], seq, []byte(msg[1]))
+			bc.dispatch(msg[0], []byte(msg[1]), seq)
+		}
+
+		if bc.Cfg.ZmqSilenceTimeout > 0 {
+			if err := bc.checkSilence(time.Now()); err != nil {
+				break OUTER
 			}
 		}
 	}
 
+	// The workers must be done with the subscription channels before they are closed.
+	for _, w := range workers {
+		w.stop()
+	}
+	for _, ep := range bc.endpoints {
+		bc.setState(ep, ZmqDisconnected, time.Now())
+	}
 	bc.subs.Lock()
 	close(bc.subs.exited)
 	bc.subs.zfront.Close()
 	// Close all subscriber channels, that will make them notice that we failed.
 	if len(bc.subs.hashTx) > 0 {
-		bc.zsub.SetUnsubscribe("hashtx")
+		bc.zsubs["hashtx"].zsub.SetUnsubscribe("hashtx")
 	}
 	for _, ch := range bc.subs.hashTx {
-		close(ch)
+		closeSub(&bc.subs, ch)
 	}
 	if len(bc.subs.hashBlock) > 0 {
-		bc.zsub.SetUnsubscribe("hashblock")
+		bc.zsubs["hashblock"].zsub.SetUnsubscribe("hashblock")
 	}
 	for _, ch := range bc.subs.hashBlock {
-		close(ch)
+		closeSub(&bc.subs, ch)
 	}
-	if len(bc.subs.rawTx) > 0 {
-		bc.zsub.SetUnsubscribe("rawtx")
+	if len(bc.subs.rawTx) > 0 || len(bc.subs.txs) > 0 {
+		bc.zsubs["rawtx"].zsub.SetUnsubscribe("rawtx")
 	}
 	for _, ch := range bc.subs.rawTx {
-		close(ch)
+		closeSub(&bc.subs, ch)
 	}
-	if len(bc.subs.rawBlock) > 0 {
-		bc.zsub.SetUnsubscribe("rawblock")
+	for _, sub := range bc.subs.txs {
+		closeSub(&bc.subs, sub.ch)
+	}
+	if len(bc.subs.rawBlock) > 0 || len(bc.subs.blocks) > 0 {
+		bc.zsubs["rawblock"].zsub.SetUnsubscribe("rawblock")
 	}
 	for _, ch := range bc.subs.rawBlock {
-		close(ch)
+		closeSub(&bc.subs, ch)
+	}
+	for _, ch := range bc.subs.blocks {
+		closeSub(&bc.subs, ch)
 	}
 	if len(bc.subs.sequence) > 0 {
-		bc.zsub.SetUnsubscribe("sequence")
+		bc.zsubs["sequence"].zsub.SetUnsubscribe("sequence")
 	}
 	for _, ch := range bc.subs.sequence {
-		close(ch)
+		closeSub(&bc.subs, ch)
+	}
+	for _, ch := range bc.subs.gaps {
+		closeSub(&bc.subs, ch)
+	}
+	for _, ch := range bc.subs.status {
+		closeSub(&bc.subs, ch)
 	}
 	bc.subs.Unlock()
 }
+
+// dispatch pushes the message of topic with body msg and sequence number seq onto the subscription channels.
+func (bc *BitcoindClient) dispatch(topic string, msg []byte, seq uint32) {
+	bc.subs.RLock()
+	defer bc.subs.RUnlock()
+	switch topic {
+	case "hashtx":
+		var hashMsg HashMsg
+		copy(hashMsg.Hash[:], msg)
+		hashMsg.Seq = seq
+		push(&bc.subs, bc.subs.hashTx, hashMsg)
+	case "hashblock":
+		var hashMsg HashMsg
+		copy(hashMsg.Hash[:], msg)
+		hashMsg.Seq = seq
+		push(&bc.subs, bc.subs.hashBlock, hashMsg)
+	case "rawtx":
+		var rawMsg RawMsg
+		rawMsg.Serialized = msg
+		rawMsg.Seq = seq
+		bc.subs.pushRawTx(rawMsg)
+	case "rawblock":
+		var rawMsg RawMsg
+		rawMsg.Serialized = msg
+		rawMsg.Seq = seq
+		bc.subs.pushRawBlock(rawMsg)
+	case "sequence":
+		if len(msg) < 33 {
+			return
+		}
+		var sequenceMsg SequenceMsg
+		copy(sequenceMsg.Hash[:], msg)
+		sequenceMsg.Seq = seq
+		switch msg[32] {
+		case 'C':
+			sequenceMsg.Event = BlockConnected
+		case 'D':
+			sequenceMsg.Event = BlockDisconnected
+		case 'R', 'A':
+			if len(msg) < 41 {
+				// The mempool sequence number is missing. Drop the message.
+				return
+			}
+			sequenceMsg.Event = TransactionRemoved
+			if msg[32] == 'A' {
+				sequenceMsg.Event = TransactionAdded
+			}
+			sequenceMsg.MempoolSeq = binary.LittleEndian.Uint64(msg[33:])
+		default:
+			// This is a fault. Drop the message.
+			return
+		}
+		push(&bc.subs, bc.subs.sequence, sequenceMsg)
+	}
+}
+
+// setState records the connection state of ep and pushes it to the status
+// subscribers. A stale endpoint is reconnecting, so it counts as disconnected
+// until the monitor reports the new connection, and it is reported every time.
+func (bc *BitcoindClient) setState(ep *endpoint, state ConnState, now time.Time) {
+	connected := state == ZmqConnected
+	bc.subs.Lock()
+	if ep.connected == connected && state != ZmqStale {
+		bc.subs.Unlock()
+		return
+	}
+	ep.connected = connected
+	all := true
+	for _, ep := range bc.endpoints {
+		all = all && ep.connected
+	}
+	if bc.subs.connected != all {
+		bc.subs.connected = all
+		close(bc.subs.connChanged)
+		bc.subs.connChanged = make(chan struct{})
+	}
+	bc.subs.Unlock()
+
+	// Pushed read locked like dispatch, a blocking subscriber mustn't hold up
+	// the readers of the state.
+	bc.subs.RLock()
+	push(&bc.subs, bc.subs.status, StatusMsg{Address: ep.address, State: state, Time: now})
+	bc.subs.RUnlock()
+}